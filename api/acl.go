@@ -0,0 +1,277 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// ACLClientToken and ACLManagementToken are the types of ACL tokens.
+	// Client tokens are granted the capabilities of their policies and
+	// roles, while management tokens are granted every capability.
+	ACLClientToken     = "client"
+	ACLManagementToken = "management"
+)
+
+// ACLTokens is used to query the ACL token endpoints.
+type ACLTokens struct {
+	client *Client
+}
+
+// ACLTokens returns a new handle on the ACL tokens.
+func (c *Client) ACLTokens() *ACLTokens {
+	return &ACLTokens{client: c}
+}
+
+// Create is used to create an ACL token. The servers check that every role
+// linked to the token exists, returning an error if one does not.
+func (a *ACLTokens) Create(token *ACLToken, q *WriteOptions) (*ACLToken, *WriteMeta, error) {
+	if token.AccessorID != "" {
+		return nil, nil, fmt.Errorf("cannot specify Accessor ID")
+	}
+	if err := token.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if a.client.config.DryRun {
+		return nil, &WriteMeta{DryRun: &DryRunResult{Operation: fmt.Sprintf("create ACL token %q", token.Name), Validated: true}}, nil
+	}
+
+	var resp ACLToken
+	wm, err := a.client.write("/v1/acl/token", token, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Update is used to update an existing ACL token.
+func (a *ACLTokens) Update(token *ACLToken, q *WriteOptions) (*ACLToken, *WriteMeta, error) {
+	if token.AccessorID == "" {
+		return nil, nil, fmt.Errorf("missing accessor ID")
+	}
+	if err := token.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if a.client.config.DryRun {
+		return nil, &WriteMeta{DryRun: &DryRunResult{Operation: fmt.Sprintf("update ACL token %q", token.AccessorID), Validated: true}}, nil
+	}
+
+	var resp ACLToken
+	wm, err := a.client.write("/v1/acl/token/"+token.AccessorID, token, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Info is used to query a single ACL token by its accessor ID.
+func (a *ACLTokens) Info(accessorID string, q *QueryOptions) (*ACLToken, *QueryMeta, error) {
+	var resp ACLToken
+	qm, err := a.client.query("/v1/acl/token/"+accessorID, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Delete is used to delete an ACL token by its accessor ID.
+func (a *ACLTokens) Delete(accessorID string, q *WriteOptions) (*WriteMeta, error) {
+	if accessorID == "" {
+		return nil, fmt.Errorf("missing accessor ID")
+	}
+	wm, err := a.client.delete("/v1/acl/token/"+accessorID, nil, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// ACLRoles is used to query the ACL role endpoints.
+type ACLRoles struct {
+	client *Client
+}
+
+// ACLRoles returns a new handle on the ACL roles.
+func (c *Client) ACLRoles() *ACLRoles {
+	return &ACLRoles{client: c}
+}
+
+// List is used to list all of the ACL roles.
+func (a *ACLRoles) List(q *QueryOptions) ([]*ACLRoleListStub, *QueryMeta, error) {
+	var resp []*ACLRoleListStub
+	qm, err := a.client.query("/v1/acl/roles", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(ACLRoleNameSort(resp))
+	return resp, qm, nil
+}
+
+// Get is used to query a single ACL role by its ID.
+func (a *ACLRoles) Get(roleID string, q *QueryOptions) (*ACLRole, *QueryMeta, error) {
+	if roleID == "" {
+		return nil, nil, fmt.Errorf("missing role ID")
+	}
+	var resp ACLRole
+	qm, err := a.client.query("/v1/acl/role/"+roleID, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// GetByName is used to query a single ACL role by its name.
+func (a *ACLRoles) GetByName(roleName string, q *QueryOptions) (*ACLRole, *QueryMeta, error) {
+	if roleName == "" {
+		return nil, nil, fmt.Errorf("missing role name")
+	}
+	var resp ACLRole
+	qm, err := a.client.query("/v1/acl/role/name/"+roleName, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Upsert is used to create an ACL role, or update it if its ID is set. The
+// stored role is returned.
+func (a *ACLRoles) Upsert(role *ACLRole, q *WriteOptions) (*ACLRole, *WriteMeta, error) {
+	if err := role.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if a.client.config.DryRun {
+		return nil, &WriteMeta{DryRun: &DryRunResult{Operation: fmt.Sprintf("upsert ACL role %q", role.Name), Validated: true}}, nil
+	}
+
+	endpoint := "/v1/acl/role"
+	if role.ID != "" {
+		endpoint += "/" + role.ID
+	}
+
+	var resp ACLRole
+	wm, err := a.client.write(endpoint, role, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Delete is used to delete an ACL role by its ID.
+func (a *ACLRoles) Delete(roleID string, q *WriteOptions) (*WriteMeta, error) {
+	if roleID == "" {
+		return nil, fmt.Errorf("missing role ID")
+	}
+	wm, err := a.client.delete("/v1/acl/role/"+roleID, nil, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// ACLToken is a token used to authenticate requests. The SecretID is sent
+// with requests while the AccessorID identifies the token in the API.
+type ACLToken struct {
+	AccessorID string
+	SecretID   string
+	Name       string
+	Type       string
+	Policies   []string `json:",omitempty"`
+
+	// Roles are the ACL roles whose policies are granted to the token
+	Roles []*ACLTokenRoleLink `json:",omitempty"`
+
+	Global      bool
+	CreateTime  int64
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Validate is used to sanity check an ACL token before it is written.
+func (t *ACLToken) Validate() error {
+	var mErr multierror.Error
+	switch t.Type {
+	case ACLClientToken:
+		if len(t.Policies) == 0 && len(t.Roles) == 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("client tokens must have at least one policy or role"))
+		}
+	case ACLManagementToken:
+		if len(t.Policies) != 0 || len(t.Roles) != 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("management tokens cannot have policies or roles"))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("token type must be %q or %q", ACLClientToken, ACLManagementToken))
+	}
+	for idx, link := range t.Roles {
+		if link == nil || (link.ID == "" && link.Name == "") {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Role link %d must reference a role ID or name", idx+1))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// ACLTokenRoleLink links a token to an ACL role. Either the ID or the name
+// of the role must be set; the servers fill in the other.
+type ACLTokenRoleLink struct {
+	ID   string `json:",omitempty"`
+	Name string `json:",omitempty"`
+}
+
+// ACLRole groups ACL policies so they can be granted to tokens together.
+type ACLRole struct {
+	ID          string
+	Name        string
+	Description string
+	Policies    []*ACLRolePolicyLink
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Validate is used to sanity check an ACL role before it is written.
+func (r *ACLRole) Validate() error {
+	var mErr multierror.Error
+	if r.Name == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing role name"))
+	}
+	if len(r.Policies) == 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("At least one policy must be specified"))
+	}
+	for idx, link := range r.Policies {
+		if link == nil || link.Name == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Policy link %d must reference a policy name", idx+1))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// ACLRolePolicyLink links an ACL role to a policy by name.
+type ACLRolePolicyLink struct {
+	Name string
+}
+
+// ACLRoleListStub is used to return a subset of an ACL role during list
+// operations.
+type ACLRoleListStub struct {
+	ID          string
+	Name        string
+	Description string
+	Policies    []*ACLRolePolicyLink
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// ACLRoleNameSort is used to sort ACL roles by their names.
+type ACLRoleNameSort []*ACLRoleListStub
+
+func (a ACLRoleNameSort) Len() int {
+	return len(a)
+}
+
+func (a ACLRoleNameSort) Less(i, j int) bool {
+	return a[i].Name < a[j].Name
+}
+
+func (a ACLRoleNameSort) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestACLRoles(t *testing.T) {
+	roles := map[string]*ACLRole{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "3")
+		enc := json.NewEncoder(w)
+		switch {
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/v1/acl/role"):
+			var role ACLRole
+			if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if id := strings.TrimPrefix(r.URL.Path, "/v1/acl/role/"); id != r.URL.Path && id != role.ID {
+				t.Fatalf("bad role update path: %q", r.URL.Path)
+			}
+			if role.ID == "" {
+				role.ID = "role-" + role.Name
+			}
+			roles[role.ID] = &role
+			enc.Encode(&role)
+		case r.Method == "GET" && r.URL.Path == "/v1/acl/roles":
+			var stubs []*ACLRoleListStub
+			for _, role := range roles {
+				stubs = append(stubs, &ACLRoleListStub{ID: role.ID, Name: role.Name, Policies: role.Policies})
+			}
+			enc.Encode(stubs)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/acl/role/name/"):
+			name := strings.TrimPrefix(r.URL.Path, "/v1/acl/role/name/")
+			for _, role := range roles {
+				if role.Name == name {
+					enc.Encode(role)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/acl/role/"):
+			role, ok := roles[strings.TrimPrefix(r.URL.Path, "/v1/acl/role/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			enc.Encode(role)
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/v1/acl/role/"):
+			delete(roles, strings.TrimPrefix(r.URL.Path, "/v1/acl/role/"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	a := c.ACLRoles()
+
+	// Roles must link policies
+	if _, _, err := a.Upsert(&ACLRole{Name: "ops"}, nil); err == nil {
+		t.Fatalf("expected policy error")
+	}
+
+	for _, name := range []string{"ops", "dev"} {
+		role := &ACLRole{Name: name, Policies: []*ACLRolePolicyLink{{Name: name + "-policy"}}}
+		out, wm, err := a.Upsert(role, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		assertWriteMeta(t, wm)
+		if out.ID != "role-"+name {
+			t.Fatalf("bad role: %#v", out)
+		}
+	}
+
+	// Updates go to the role's own endpoint
+	update := &ACLRole{ID: "role-ops", Name: "ops", Description: "operators",
+		Policies: []*ACLRolePolicyLink{{Name: "ops-policy"}}}
+	if _, _, err := a.Upsert(update, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	stubs, _, err := a.List(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(stubs) != 2 || stubs[0].Name != "dev" || stubs[1].Name != "ops" {
+		t.Fatalf("bad roles: %#v", stubs)
+	}
+
+	role, _, err := a.Get("role-ops", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if role.Description != "operators" {
+		t.Fatalf("bad role: %#v", role)
+	}
+	role, _, err = a.GetByName("dev", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if role.ID != "role-dev" {
+		t.Fatalf("bad role: %#v", role)
+	}
+
+	if _, err := a.Delete("role-dev", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := a.Get("role-dev", nil); err == nil {
+		t.Fatalf("expected deleted role to be missing")
+	}
+}
+
+func TestACLTokens_Create_Roles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/agent/self" {
+			// The version of the agent is unknown, so requests aren't gated
+			http.NotFound(w, r)
+			return
+		}
+		var token ACLToken
+		if err := json.NewDecoder(r.Body).Decode(&token); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, link := range token.Roles {
+			if link.ID != "role-ops" && link.Name != "ops" {
+				http.Error(w, "cannot find role "+link.ID+link.Name, http.StatusBadRequest)
+				return
+			}
+		}
+		token.AccessorID = "accessor1"
+		token.SecretID = "secret1"
+		w.Header().Set("X-Nomad-Index", "5")
+		json.NewEncoder(w).Encode(&token)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	tokens := c.ACLTokens()
+
+	token := &ACLToken{Name: "deployer", Type: ACLClientToken, Roles: []*ACLTokenRoleLink{{Name: "ops"}}}
+	out, _, err := tokens.Create(token, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.SecretID != "secret1" || len(out.Roles) != 1 || out.Roles[0].Name != "ops" {
+		t.Fatalf("bad token: %#v", out)
+	}
+
+	// Links must reference a role
+	token.Roles = []*ACLTokenRoleLink{{}}
+	if _, _, err := tokens.Create(token, nil); err == nil || !strings.Contains(err.Error(), "role ID or name") {
+		t.Fatalf("expected link error, got: %v", err)
+	}
+
+	// Missing roles are reported by the servers
+	token.Roles = []*ACLTokenRoleLink{{ID: "role-missing"}}
+	if _, _, err := tokens.Create(token, nil); err == nil || !strings.Contains(err.Error(), "cannot find role role-missing") {
+		t.Fatalf("expected missing role error, got: %v", err)
+	}
+
+	// Management tokens cannot have roles
+	token.Type = ACLManagementToken
+	if _, _, err := tokens.Create(token, nil); err == nil {
+		t.Fatalf("expected management token error")
+	}
+}
@@ -1,8 +1,12 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/url"
+	"strconv"
 )
 
 // Agent encapsulates an API client which talks to Nomad's
@@ -131,7 +135,17 @@ func (a *Agent) Members() ([]*AgentMember, error) {
 
 // ForceLeave is used to eject an existing node from the cluster.
 func (a *Agent) ForceLeave(node string) error {
-	_, err := a.client.write("/v1/agent/force-leave?node="+node, nil, nil, nil)
+	return a.ForceLeaveOpts(node, nil)
+}
+
+// ForceLeaveOpts is used to eject an existing node from the cluster with the
+// given write options. Setting the Region of the options forwards the request
+// to the servers of that region, such as to clean up a dead server of a
+// remote region.
+func (a *Agent) ForceLeaveOpts(node string, q *WriteOptions) error {
+	v := url.Values{}
+	v.Set("node", node)
+	_, err := a.client.write("/v1/agent/force-leave?"+v.Encode(), nil, nil, q)
 	return err
 }
 
@@ -157,6 +171,147 @@ func (a *Agent) SetServers(addrs []string) error {
 	return err
 }
 
+// MonitorOptions selects the agent whose logs are streamed by Monitor and
+// the level they are streamed at.
+type MonitorOptions struct {
+	// LogLevel is the minimum level of the streamed logs, such as "DEBUG"
+	// or "ERR". It defaults to the log level of the agent.
+	LogLevel string
+
+	// NodeID streams the logs of the given client node instead of the
+	// queried agent.
+	NodeID string
+
+	// ServerID streams the logs of the given server instead of the queried
+	// agent. It may not be combined with NodeID.
+	ServerID string
+}
+
+// Monitor streams the log lines of an agent until the context is
+// cancelled. The returned channel is closed once the stream ends, and
+// cancelling the context tears down the underlying connection.
+func (a *Agent) Monitor(ctx context.Context, opts *MonitorOptions) (<-chan []byte, error) {
+	q := (&QueryOptions{Params: make(map[string]string)}).WithContext(ctx)
+	if opts != nil {
+		if opts.NodeID != "" && opts.ServerID != "" {
+			return nil, fmt.Errorf("only one of node ID or server ID may be set")
+		}
+		if opts.LogLevel != "" {
+			q.Params["log_level"] = opts.LogLevel
+		}
+		if opts.NodeID != "" {
+			q.Params["node_id"] = opts.NodeID
+		}
+		if opts.ServerID != "" {
+			q.Params["server_id"] = opts.ServerID
+		}
+	}
+
+	r, err := a.client.rawQuery("/v1/agent/monitor", q)
+	if err != nil {
+		return nil, err
+	}
+
+	logCh := make(chan []byte, 10)
+	go func() {
+		defer close(logCh)
+		defer r.Close()
+
+		dec := json.NewDecoder(r)
+		for {
+			var frame StreamFrame
+			if err := dec.Decode(&frame); err != nil {
+				return
+			}
+
+			// Discard heartbeat frames
+			if frame.IsHeartbeat() {
+				continue
+			}
+
+			select {
+			case logCh <- frame.Data:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return logCh, nil
+}
+
+const (
+	// PprofProfileCPU, PprofProfileHeap, PprofProfileGoroutine and
+	// PprofProfileTrace are the profiles that may be collected from an agent.
+	PprofProfileCPU       = "cpu"
+	PprofProfileHeap      = "heap"
+	PprofProfileGoroutine = "goroutine"
+	PprofProfileTrace     = "trace"
+)
+
+// PprofOptions selects the profile collected by PprofProfile and the agent
+// it is collected from.
+type PprofOptions struct {
+	// Profile is the profile to collect. It defaults to a CPU profile.
+	Profile string
+
+	// Seconds is the duration of CPU profiles and traces. The agent
+	// defaults it when unset.
+	Seconds int
+
+	// NodeID collects the profile from the given client node instead of
+	// the queried agent.
+	NodeID string
+
+	// ServerID collects the profile from the given server instead of the
+	// queried agent. It may not be combined with NodeID.
+	ServerID string
+}
+
+// PprofProfile collects a raw pprof profile from an agent. The returned bytes
+// are in the format of the runtime/pprof package and may be read with
+// "go tool pprof", or "go tool trace" for traces.
+func (a *Agent) PprofProfile(ctx context.Context, opts PprofOptions) ([]byte, error) {
+	if opts.NodeID != "" && opts.ServerID != "" {
+		return nil, fmt.Errorf("only one of node ID or server ID may be set")
+	}
+	if opts.Seconds < 0 {
+		return nil, fmt.Errorf("seconds must be greater than or equal to 0")
+	}
+
+	// CPU profiles and traces are served by their own endpoints, while the
+	// other profiles are looked up by name.
+	var endpoint string
+	switch opts.Profile {
+	case "", PprofProfileCPU:
+		endpoint = "/v1/agent/pprof/profile"
+	case PprofProfileTrace:
+		endpoint = "/v1/agent/pprof/trace"
+	case PprofProfileHeap, PprofProfileGoroutine:
+		endpoint = "/v1/agent/pprof/" + opts.Profile
+	default:
+		return nil, fmt.Errorf("unknown profile %q", opts.Profile)
+	}
+
+	q := (&QueryOptions{Params: make(map[string]string)}).WithContext(ctx)
+	if opts.Seconds != 0 {
+		q.Params["seconds"] = strconv.Itoa(opts.Seconds)
+	}
+	if opts.NodeID != "" {
+		q.Params["node_id"] = opts.NodeID
+	}
+	if opts.ServerID != "" {
+		q.Params["server_id"] = opts.ServerID
+	}
+
+	r, err := a.client.rawQuery(endpoint, q)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
 // joinResponse is used to decode the response we get while
 // sending a member join request.
 type joinResponse struct {
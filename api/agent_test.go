@@ -1,9 +1,16 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/testutil"
 )
@@ -237,3 +244,162 @@ func TestAgents_Sort(t *testing.T) {
 		}
 	}
 }
+
+func TestAgent_Monitor(t *testing.T) {
+	closedCh := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/agent/monitor" {
+			http.NotFound(w, r)
+			return
+		}
+		if level := r.URL.Query().Get("log_level"); level != "DEBUG" {
+			t.Fatalf("bad log level: %q", level)
+		}
+		if nodeID := r.URL.Query().Get("node_id"); nodeID != "node1" {
+			t.Fatalf("bad node id: %q", nodeID)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.Encode(&StreamFrame{Data: []byte("[DEBUG] first\n")})
+		enc.Encode(&StreamFrame{})
+		enc.Encode(&StreamFrame{Data: []byte("[DEBUG] second\n")})
+		w.(http.Flusher).Flush()
+
+		// Keep streaming until the client hangs up
+		<-r.Context().Done()
+		close(closedCh)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logCh, err := c.Agent().Monitor(ctx, &MonitorOptions{LogLevel: "DEBUG", NodeID: "node1"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for _, expected := range []string{"[DEBUG] first\n", "[DEBUG] second\n"} {
+		select {
+		case line := <-logCh:
+			if string(line) != expected {
+				t.Fatalf("bad log line: %q", line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", expected)
+		}
+	}
+
+	// Cancelling closes the channel and the connection
+	cancel()
+	select {
+	case _, ok := <-logCh:
+		if ok {
+			t.Fatalf("expected closed channel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("monitor did not stop after cancel")
+	}
+	select {
+	case <-closedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("connection not closed after cancel")
+	}
+
+	// Node and server targets are exclusive
+	_, err = c.Agent().Monitor(ctx, &MonitorOptions{NodeID: "node1", ServerID: "server1"})
+	if err == nil || !strings.Contains(err.Error(), "only one of") {
+		t.Fatalf("expected target error, got: %v", err)
+	}
+}
+
+func TestAgent_PprofProfile(t *testing.T) {
+	var path, seconds, serverID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		seconds = r.URL.Query().Get("seconds")
+		serverID = r.URL.Query().Get("server_id")
+		w.Write([]byte("profile:" + path))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	a := c.Agent()
+	ctx := context.Background()
+
+	cases := []struct {
+		profile  string
+		endpoint string
+	}{
+		{"", "/v1/agent/pprof/profile"},
+		{PprofProfileCPU, "/v1/agent/pprof/profile"},
+		{PprofProfileTrace, "/v1/agent/pprof/trace"},
+		{PprofProfileHeap, "/v1/agent/pprof/heap"},
+		{PprofProfileGoroutine, "/v1/agent/pprof/goroutine"},
+	}
+	for _, tc := range cases {
+		out, err := a.PprofProfile(ctx, PprofOptions{Profile: tc.profile, Seconds: 5, ServerID: "server1"})
+		if err != nil {
+			t.Fatalf("%q: err: %v", tc.profile, err)
+		}
+		if string(out) != "profile:"+tc.endpoint {
+			t.Fatalf("%q: bad profile: %q", tc.profile, out)
+		}
+		if seconds != "5" || serverID != "server1" {
+			t.Fatalf("%q: bad params: %q %q", tc.profile, seconds, serverID)
+		}
+	}
+
+	if _, err := a.PprofProfile(ctx, PprofOptions{Profile: "mutex"}); err == nil {
+		t.Fatalf("expected unknown profile error")
+	}
+	if _, err := a.PprofProfile(ctx, PprofOptions{NodeID: "node1", ServerID: "server1"}); err == nil {
+		t.Fatalf("expected target error")
+	}
+}
+
+func TestAgent_ForceLeaveOpts_Region(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/v1/agent/force-leave" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := c.Agent().ForceLeaveOpts("server1.europe", &WriteOptions{Region: "europe"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if query.Get("node") != "server1.europe" || query.Get("region") != "europe" {
+		t.Fatalf("bad query: %v", query)
+	}
+
+	// Without options the region of the client is used
+	c.SetRegion("asia")
+	if err := c.Agent().ForceLeave("server2.asia"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if query.Get("node") != "server2.asia" || query.Get("region") != "asia" {
+		t.Fatalf("bad query: %v", query)
+	}
+}
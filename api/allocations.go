@@ -2,10 +2,11 @@ package api
 
 import (
 	"fmt"
+	"io"
+	"path"
 	"sort"
+	"strings"
 	"time"
-
-	"github.com/hashicorp/go-cleanhttp"
 )
 
 // Allocations is used to query the alloc-related endpoints.
@@ -43,6 +44,50 @@ func (a *Allocations) Info(allocID string, q *QueryOptions) (*Allocation, *Query
 	return &resp, qm, nil
 }
 
+// RescheduleChain is used to walk the allocations an allocation replaced,
+// following their previous allocations back to the first of them. The
+// chain is returned oldest first, ending with the given allocation. The walk
+// stops at allocations that were garbage collected.
+func (a *Allocations) RescheduleChain(allocID string, q *QueryOptions) ([]*Allocation, error) {
+	alloc, _, err := a.Info(allocID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]struct{}{alloc.ID: {}}
+	chain := []*Allocation{alloc}
+	for {
+		prevID := alloc.PreviousAllocation
+		if prevID == "" && alloc.RescheduleTracker != nil && len(alloc.RescheduleTracker.Events) != 0 {
+			events := alloc.RescheduleTracker.Events
+			prevID = events[len(events)-1].PrevAllocID
+		}
+		if prevID == "" {
+			break
+		}
+		if _, ok := seen[prevID]; ok {
+			break
+		}
+		seen[prevID] = struct{}{}
+
+		prev, _, err := a.Info(prevID, q)
+		if err != nil {
+			if strings.Contains(err.Error(), "Unexpected response code: 404") {
+				break
+			}
+			return nil, fmt.Errorf("failed to look up allocation %q: %v", prevID, err)
+		}
+		chain = append(chain, prev)
+		alloc = prev
+	}
+
+	// Reverse the chain so it is oldest first
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
 func (a *Allocations) Stats(alloc *Allocation, q *QueryOptions) (*AllocResourceUsage, error) {
 	node, _, err := a.client.Nodes().Info(alloc.NodeID, q)
 	if err != nil {
@@ -51,10 +96,7 @@ func (a *Allocations) Stats(alloc *Allocation, q *QueryOptions) (*AllocResourceU
 	if node.HTTPAddr == "" {
 		return nil, fmt.Errorf("http addr of the node where alloc %q is running is not advertised", alloc.ID)
 	}
-	client, err := NewClient(&Config{
-		Address:    fmt.Sprintf("http://%s", node.HTTPAddr),
-		HttpClient: cleanhttp.DefaultClient(),
-	})
+	client, err := NewClient(a.client.config.nodeConfig(fmt.Sprintf("http://%s", node.HTTPAddr)))
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +105,368 @@ func (a *Allocations) Stats(alloc *Allocation, q *QueryOptions) (*AllocResourceU
 	return &resp, err
 }
 
+// taskDataDirs are the directories of a task directory that hold the data of
+// the task. The other directories of a task directory are the shared alloc/
+// directory and the host directories embedded in its chroot, which aren't
+// counted as used by the task.
+var taskDataDirs = map[string]bool{"local": true, "secrets": true, "tmp": true}
+
+// DiskUsage is used to measure the disk space used by the allocation
+// directory of the allocation with the given ID, broken down by the shared
+// alloc/ directory and the directories of each task. The directories of a
+// task count its files and its local/, secrets/ and tmp/ directories, but
+// not the alloc/ directory shared into it or the chroot directories, such as
+// bin/ and usr/, embedded into it. The directories are walked on the client
+// node running the allocation with the fs endpoints, so the cost grows with
+// the number of directories.
+func (a *Allocations) DiskUsage(allocID string, q *QueryOptions) (*AllocDiskUsage, error) {
+	alloc, _, err := a.Info(allocID, q)
+	if err != nil {
+		return nil, err
+	}
+	node, _, err := a.client.Nodes().Info(alloc.NodeID, q)
+	if err != nil {
+		return nil, err
+	}
+	client, err := a.client.AllocFS().getNodeClient(node.HTTPAddr, alloc.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	walker := &allocDirWalker{client: client, allocID: alloc.ID}
+	if q != nil {
+		walker.authToken = q.AuthToken
+	}
+	root, err := walker.list("/")
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &AllocDiskUsage{AllocID: alloc.ID, Tasks: make(map[string]*TaskDiskUsage)}
+	for _, entry := range root {
+		if !entry.IsDir {
+			usage.TotalBytes += entry.Size
+			continue
+		}
+
+		if entry.Name == "alloc" {
+			size, err := walker.size(entry.Name)
+			if err != nil {
+				return nil, err
+			}
+			usage.SharedBytes = size
+			usage.TotalBytes += size
+			continue
+		}
+
+		// Every other directory is the directory of a task
+		files, err := walker.list(entry.Name)
+		if err != nil {
+			return nil, err
+		}
+		task := &TaskDiskUsage{}
+		for _, file := range files {
+			if file.IsDir && !taskDataDirs[file.Name] {
+				continue
+			}
+			size := file.Size
+			if file.IsDir {
+				if size, err = walker.size(path.Join(entry.Name, file.Name)); err != nil {
+					return nil, err
+				}
+			}
+			if file.Name == "local" && file.IsDir {
+				task.LocalBytes = size
+			}
+			task.TotalBytes += size
+		}
+		usage.Tasks[entry.Name] = task
+		usage.TotalBytes += task.TotalBytes
+	}
+	return usage, nil
+}
+
+// allocDirWalker walks an allocation directory on the client node running
+// the allocation.
+type allocDirWalker struct {
+	client    *Client
+	allocID   string
+	authToken string
+}
+
+// list lists the directory at the given path of the allocation directory.
+func (w *allocDirWalker) list(dir string) ([]*AllocFileInfo, error) {
+	q := &QueryOptions{AuthToken: w.authToken, Params: map[string]string{"path": dir}}
+	var resp []*AllocFileInfo
+	if _, err := w.client.query("/v1/client/fs/ls/"+w.allocID, &resp, q); err != nil {
+		return nil, fmt.Errorf("failed to list %q: %v", dir, err)
+	}
+	return resp, nil
+}
+
+// size returns the total size of the files under the directory at the given
+// path of the allocation directory.
+func (w *allocDirWalker) size(dir string) (int64, error) {
+	files, err := w.list(dir)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, file := range files {
+		if !file.IsDir {
+			total += file.Size
+			continue
+		}
+		size, err := w.size(path.Join(dir, file.Name))
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// logsTailLineBytes is the line length LogsTail initially assumes to size
+// the window of logs it reads.
+const logsTailLineBytes = 128
+
+// LogsTail returns the last lines of the stdout or stderr logs of a task of
+// an allocation, oldest first, without following them. The logs are read
+// from their end in a window sized for the requested number of lines, which
+// is grown until it holds enough lines or covers the whole logs. Fewer lines
+// are returned if the logs are shorter.
+func (a *Allocations) LogsTail(allocID, task, logType string, lines int, q *QueryOptions) ([]string, error) {
+	if lines <= 0 {
+		return nil, fmt.Errorf("number of lines must be positive")
+	}
+	alloc, _, err := a.Info(allocID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// The log requests set params on the query options, so they are copied
+	lq := &QueryOptions{}
+	if q != nil {
+		*lq = *q
+	}
+	lq.Params = make(map[string]string)
+	if q != nil {
+		for k, v := range q.Params {
+			lq.Params[k] = v
+		}
+	}
+
+	offset := int64(lines * logsTailLineBytes)
+	for {
+		data, err := a.readLogs(alloc, task, logType, offset, lq)
+		if err != nil {
+			return nil, err
+		}
+
+		// The window covers the whole logs if it is only partially filled,
+		// otherwise its first line may be truncated and is only kept if
+		// enough lines follow it
+		complete := int64(len(data)) < offset
+		tail := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(data) == 0 {
+			tail = nil
+		}
+		if len(tail) > lines || complete {
+			if len(tail) > lines {
+				tail = tail[len(tail)-lines:]
+			}
+			return tail, nil
+		}
+		offset *= 4
+	}
+}
+
+// readLogs reads the logs of a task from the given offset from their end.
+func (a *Allocations) readLogs(alloc *Allocation, task, logType string, offset int64, q *QueryOptions) ([]byte, error) {
+	cancel := make(chan struct{})
+	defer close(cancel)
+	frames, err := a.client.AllocFS().Logs(alloc, false, task, logType, "end", offset, cancel, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for frame := range frames {
+		data = append(data, frame.Data...)
+	}
+	return data, nil
+}
+
+// AllocDiskUsage is the disk space used by an allocation directory.
+type AllocDiskUsage struct {
+	AllocID string
+
+	// TotalBytes is the size of every file of the allocation directory.
+	TotalBytes int64
+
+	// SharedBytes is the size of the alloc/ directory shared by the tasks.
+	SharedBytes int64
+
+	// Tasks is the disk usage of the directory of each task, by task name.
+	Tasks map[string]*TaskDiskUsage
+}
+
+// TaskDiskUsage is the disk space used by the directory of a task.
+type TaskDiskUsage struct {
+	// TotalBytes is the size of every file of the task directory.
+	TotalBytes int64
+
+	// LocalBytes is the size of the local/ directory of the task.
+	LocalBytes int64
+}
+
+// Stat is used to stat a file at the given path of the allocation directory
+// of the allocation with the given ID.
+func (a *Allocations) Stat(allocID, path string, q *QueryOptions) (*AllocFileInfo, error) {
+	alloc, _, err := a.Info(allocID, q)
+	if err != nil {
+		return nil, err
+	}
+	info, _, err := a.client.AllocFS().Stat(alloc, path, q)
+	return info, err
+}
+
+// ListFiles is used to list the directory at the given path of the
+// allocation directory of the allocation with the given ID.
+func (a *Allocations) ListFiles(allocID, path string, q *QueryOptions) ([]*AllocFileInfo, error) {
+	alloc, _, err := a.Info(allocID, q)
+	if err != nil {
+		return nil, err
+	}
+	files, _, err := a.client.AllocFS().List(alloc, path, q)
+	return files, err
+}
+
+// ReadAt is used to read limit bytes at the given offset of the file at the
+// given path of the allocation directory of the allocation with the given ID.
+// If limit is <= 0, there is no limit.
+func (a *Allocations) ReadAt(allocID, path string, offset, limit int64, q *QueryOptions) (io.ReadCloser, error) {
+	alloc, _, err := a.Info(allocID, q)
+	if err != nil {
+		return nil, err
+	}
+	return a.client.AllocFS().ReadAt(alloc, path, offset, limit, q)
+}
+
+// ServiceHealth is used to retrieve the current status of the Consul checks
+// of the services registered by the tasks of an allocation. The status is
+// read from the client node running the allocation, which lets a running
+// but unhealthy allocation be told apart from a healthy one.
+func (a *Allocations) ServiceHealth(allocID string, q *QueryOptions) ([]*AllocCheckStatus, error) {
+	alloc, _, err := a.Info(allocID, q)
+	if err != nil {
+		return nil, err
+	}
+	node, _, err := a.client.Nodes().Info(alloc.NodeID, q)
+	if err != nil {
+		return nil, err
+	}
+	if node.HTTPAddr == "" {
+		return nil, fmt.Errorf("http addr of the node where alloc %q is running is not advertised", alloc.ID)
+	}
+	client, err := NewClient(a.client.config.nodeConfig(fmt.Sprintf("http://%s", node.HTTPAddr)))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []*AllocCheckStatus
+	if _, err := client.query("/v1/client/allocation/"+alloc.ID+"/checks", &resp, q); err != nil {
+		return nil, err
+	}
+	sort.Sort(AllocCheckStatusSort(resp))
+	return resp, nil
+}
+
+// Restart is used to restart the tasks of a running allocation in place. If
+// taskName is empty, every task of the allocation is restarted. Restarts are
+// performed by the client node running the allocation. They are applied
+// immediately and don't count against the restart attempts of the task
+// group's restart policy.
+func (a *Allocations) Restart(alloc *Allocation, taskName string, q *WriteOptions) error {
+	var nq *QueryOptions
+	if q != nil {
+		nq = &QueryOptions{Region: q.Region, Namespace: q.Namespace, AuthToken: q.AuthToken}
+	}
+	node, _, err := a.client.Nodes().Info(alloc.NodeID, nq)
+	if err != nil {
+		return err
+	}
+	if node.HTTPAddr == "" {
+		return fmt.Errorf("http addr of the node where alloc %q is running is not advertised", alloc.ID)
+	}
+	client, err := NewClient(a.client.config.nodeConfig(fmt.Sprintf("http://%s", node.HTTPAddr)))
+	if err != nil {
+		return err
+	}
+
+	req := &AllocRestartRequest{TaskName: taskName}
+	_, err = client.write("/v1/client/allocation/"+alloc.ID+"/restart", req, nil, q)
+	return err
+}
+
+// AllocRestartRequest is used to restart the tasks of an allocation.
+type AllocRestartRequest struct {
+	TaskName string `json:",omitempty"`
+}
+
+// AllocCheckStatus is the status of a single Consul check of a service
+// registered by a task.
+type AllocCheckStatus struct {
+	Task    string
+	Service string
+	Check   string
+
+	// Status is one of HealthPassing, HealthWarning or HealthCritical
+	Status string
+
+	// Output is the output of the last run of the check
+	Output string
+}
+
+// Healthy returns whether the check is passing.
+func (c *AllocCheckStatus) Healthy() bool {
+	return c.Status == HealthPassing
+}
+
+// AllocCheckStatusSort sorts check statuses by task, service and check name.
+type AllocCheckStatusSort []*AllocCheckStatus
+
+func (a AllocCheckStatusSort) Len() int {
+	return len(a)
+}
+
+func (a AllocCheckStatusSort) Less(i, j int) bool {
+	if a[i].Task != a[j].Task {
+		return a[i].Task < a[j].Task
+	}
+	if a[i].Service != a[j].Service {
+		return a[i].Service < a[j].Service
+	}
+	return a[i].Check < a[j].Check
+}
+
+func (a AllocCheckStatusSort) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+const (
+	AllocDesiredStatusRun  = "run"
+	AllocDesiredStatusStop = "stop"
+)
+
+const (
+	AllocClientStatusPending  = "pending"
+	AllocClientStatusRunning  = "running"
+	AllocClientStatusComplete = "complete"
+	AllocClientStatusFailed   = "failed"
+	AllocClientStatusLost     = "lost"
+)
+
 // Allocation is used for serialization of allocations.
 type Allocation struct {
 	ID                 string
@@ -81,9 +485,51 @@ type Allocation struct {
 	ClientStatus       string
 	ClientDescription  string
 	TaskStates         map[string]*TaskState
-	CreateIndex        uint64
-	ModifyIndex        uint64
-	CreateTime         int64
+
+	// PreviousAllocation is the allocation this allocation replaced, when
+	// it was rescheduled or migrated, and NextAllocation the allocation
+	// that replaced it.
+	PreviousAllocation string `json:",omitempty"`
+	NextAllocation     string `json:",omitempty"`
+
+	// RescheduleTracker tracks the reschedules that led to the allocation.
+	RescheduleTracker *RescheduleTracker `json:",omitempty"`
+
+	CreateIndex uint64
+	ModifyIndex uint64
+	CreateTime  int64
+}
+
+// RescheduleTracker tracks the previous reschedules of an allocation.
+type RescheduleTracker struct {
+	// Events are the reschedules that led to the allocation, oldest first.
+	Events []*RescheduleEvent
+}
+
+// RescheduleEvent is a reschedule of a failed allocation.
+type RescheduleEvent struct {
+	// RescheduleTime is the time the failed allocation was rescheduled, in
+	// nanoseconds since the epoch.
+	RescheduleTime int64
+
+	// PrevAllocID and PrevNodeID are the failed allocation and the node it
+	// ran on.
+	PrevAllocID string
+	PrevNodeID  string
+
+	// Delay is the delay the failed allocation was rescheduled after.
+	Delay time.Duration
+}
+
+// ClientTerminalStatus returns whether the client has finished running the
+// allocation.
+func (a *Allocation) ClientTerminalStatus() bool {
+	switch a.ClientStatus {
+	case AllocClientStatusComplete, AllocClientStatusFailed, AllocClientStatusLost:
+		return true
+	default:
+		return false
+	}
 }
 
 // AllocationMetric is used to deserialize allocation metrics.
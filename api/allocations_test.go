@@ -1,9 +1,19 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestAllocations_List(t *testing.T) {
@@ -115,3 +125,392 @@ func TestAllocations_CreateIndexSort(t *testing.T) {
 		t.Fatalf("\n\n%#v\n\n%#v", allocs, expect)
 	}
 }
+
+func TestAllocations_RescheduleChain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		switch r.URL.Path {
+		case "/v1/allocation/alloc4":
+			fmt.Fprint(w, `{"ID": "alloc4", "PreviousAllocation": "alloc3", "RescheduleTracker": {"Events": [
+				{"RescheduleTime": 100, "PrevAllocID": "alloc2", "PrevNodeID": "node1", "Delay": 5000000000},
+				{"RescheduleTime": 200, "PrevAllocID": "alloc3", "PrevNodeID": "node2", "Delay": 10000000000}
+			]}}`)
+		case "/v1/allocation/alloc3":
+			// Older allocations only reference their previous allocation
+			// through their reschedule tracker
+			fmt.Fprint(w, `{"ID": "alloc3", "NextAllocation": "alloc4", "RescheduleTracker": {"Events": [
+				{"RescheduleTime": 100, "PrevAllocID": "alloc2", "PrevNodeID": "node1", "Delay": 5000000000}
+			]}}`)
+		case "/v1/allocation/alloc2":
+			fmt.Fprint(w, `{"ID": "alloc2", "NextAllocation": "alloc3", "PreviousAllocation": "alloc1"}`)
+		default:
+			// alloc1 was garbage collected
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	chain, err := c.Allocations().RescheduleChain("alloc4", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var ids []string
+	for _, alloc := range chain {
+		ids = append(ids, alloc.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"alloc2", "alloc3", "alloc4"}) {
+		t.Fatalf("bad chain: %v", ids)
+	}
+
+	events := chain[2].RescheduleTracker.Events
+	if len(events) != 2 || events[1].PrevAllocID != "alloc3" || events[1].PrevNodeID != "node2" ||
+		events[1].RescheduleTime != 200 || events[1].Delay != 10*time.Second {
+		t.Fatalf("bad reschedule events: %#v", events)
+	}
+
+	if _, err := c.Allocations().RescheduleChain("alloc1", nil); err == nil {
+		t.Fatalf("expected not found error")
+	}
+}
+
+func TestAllocations_LogsTail(t *testing.T) {
+	var logs strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&logs, "line %d %s\n", i, strings.Repeat("x", 200))
+	}
+	var offsets []string
+	c, srv := makeNodeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/client/fs/logs/alloc1" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("task") != "web" || query.Get("type") != "stderr" ||
+			query.Get("origin") != "end" || query.Get("follow") != "false" {
+			t.Fatalf("bad query: %v", query)
+		}
+		offsets = append(offsets, query.Get("offset"))
+
+		// The logs are served from the offset from their end in two frames
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		data := logs.String()
+		if offset < len(data) {
+			data = data[len(data)-offset:]
+		}
+		enc := json.NewEncoder(w)
+		half := len(data) / 2
+		enc.Encode(&StreamFrame{Data: []byte(data[:half]), File: "web.stderr.0"})
+		enc.Encode(&StreamFrame{Data: []byte(data[half:]), File: "web.stderr.0"})
+	})
+	defer srv.Close()
+
+	lines, err := c.Allocations().LogsTail("alloc1", "web", "stderr", 3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "line 98 ") || !strings.HasPrefix(lines[2], "line 100 ") {
+		t.Fatalf("bad lines: %q", lines)
+	}
+
+	// The window is grown until it holds enough lines
+	if !reflect.DeepEqual(offsets, []string{"384", "1536"}) {
+		t.Fatalf("bad offsets: %v", offsets)
+	}
+
+	// Shorter logs are returned whole
+	lines, err = c.Allocations().LogsTail("alloc1", "web", "stderr", 500, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(lines) != 100 || !strings.HasPrefix(lines[0], "line 1 ") {
+		t.Fatalf("expected all 100 lines, got %d", len(lines))
+	}
+
+	if _, err := c.Allocations().LogsTail("alloc1", "web", "stderr", 0, nil); err == nil {
+		t.Fatalf("expected error for no lines")
+	}
+}
+
+func TestAllocations_DiskUsage(t *testing.T) {
+	// Lay out an allocation directory the way clients build it, with the
+	// shared alloc/ directory and a chroot embedded in the web task
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]int{
+		"lock":                         1,
+		"alloc/logs/web.stdout.0":      100,
+		"alloc/logs/web.stderr.0":      20,
+		"alloc/data/":                  0,
+		"alloc/tmp/":                   0,
+		"web/executor.out":             3,
+		"web/local/cache":              1000,
+		"web/local/config/app.conf":    10,
+		"web/secrets/vault_token":      36,
+		"web/tmp/":                     0,
+		"web/alloc/logs/web.stdout.0":  100,
+		"web/bin/sh":                   5000,
+		"web/usr/lib/libc.so":          7000,
+		"web/etc/hosts":                50,
+		"sidecar/local/":               0,
+		"sidecar/alloc/logs/sidecar.0": 20,
+	}
+	for name, size := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(p, 0755); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := ioutil.WriteFile(p, make([]byte, size), 0644); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	c, srv := makeNodeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/client/fs/ls/alloc1" {
+			http.NotFound(w, r)
+			return
+		}
+		infos, err := ioutil.ReadDir(filepath.Join(dir, filepath.FromSlash(r.URL.Query().Get("path"))))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		var resp []*AllocFileInfo
+		for _, info := range infos {
+			resp = append(resp, &AllocFileInfo{Name: info.Name(), IsDir: info.IsDir(), Size: info.Size()})
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	defer srv.Close()
+
+	usage, err := c.Allocations().DiskUsage("alloc1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := &AllocDiskUsage{
+		AllocID:     "alloc1",
+		TotalBytes:  1170,
+		SharedBytes: 120,
+		Tasks: map[string]*TaskDiskUsage{
+			"web":     {TotalBytes: 1049, LocalBytes: 1010},
+			"sidecar": {},
+		},
+	}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Fatalf("bad usage: %#v", usage)
+	}
+
+	// Nodes without an advertised address can't be reached
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/allocation/alloc1":
+			fmt.Fprint(w, `{"ID": "alloc1", "NodeID": "node1"}`)
+		case "/v1/node/node1":
+			fmt.Fprint(w, `{"ID": "node1"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer unreachable.Close()
+	conf := DefaultConfig()
+	conf.Address = unreachable.URL
+	c2, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c2.Allocations().DiskUsage("alloc1", nil); err == nil || !strings.Contains(err.Error(), "not advertised") {
+		t.Fatalf("expected unreachable node error, got: %v", err)
+	}
+}
+
+func TestAllocations_FileSystem(t *testing.T) {
+	c, srv := makeNodeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Query().Get("path")
+		switch r.URL.Path {
+		case "/v1/client/fs/stat/alloc1":
+			fmt.Fprintf(w, `{"Name": %q, "Size": 12, "FileMode": "-rw-r--r--"}`, path)
+		case "/v1/client/fs/ls/alloc1":
+			fmt.Fprint(w, `[{"Name": "alloc", "IsDir": true}, {"Name": "web", "IsDir": true}]`)
+		case "/v1/client/fs/readat/alloc1":
+			q := r.URL.Query()
+			fmt.Fprintf(w, "%s@%s+%s", path, q.Get("offset"), q.Get("limit"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	a := c.Allocations()
+
+	info, err := a.Stat("alloc1", "alloc/logs/web.stdout.0", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.Name != "alloc/logs/web.stdout.0" || info.Size != 12 {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	files, err := a.ListFiles("alloc1", "/", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(files) != 2 || files[0].Name != "alloc" || !files[1].IsDir {
+		t.Fatalf("bad: %#v", files)
+	}
+
+	r, err := a.ReadAt("alloc1", "web/local/out", 5, 10, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer r.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != "web/local/out@5+10" {
+		t.Fatalf("bad: %q", out)
+	}
+}
+
+func TestAllocations_ServiceHealth(t *testing.T) {
+	var token string
+	c, srv := makeNodeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/client/allocation/alloc1/checks" {
+			http.NotFound(w, r)
+			return
+		}
+		token = r.Header.Get("X-Nomad-Token")
+		fmt.Fprint(w, `[
+			{"Task": "web", "Service": "web-http", "Check": "ready", "Status": "critical", "Output": "connection refused"},
+			{"Task": "web", "Service": "web-http", "Check": "alive", "Status": "passing"},
+			{"Task": "cache", "Service": "redis", "Check": "ping", "Status": "passing"}
+		]`)
+	})
+	defer srv.Close()
+
+	checks, err := c.Allocations().ServiceHealth("alloc1", &QueryOptions{AuthToken: "secret1"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(checks) != 3 {
+		t.Fatalf("bad checks: %#v", checks)
+	}
+	if token != "secret1" {
+		t.Fatalf("expected the token of the options to be sent to the node, got %q", token)
+	}
+
+	var names []string
+	for _, check := range checks {
+		names = append(names, check.Task+"/"+check.Service+"/"+check.Check)
+	}
+	expected := []string{"cache/redis/ping", "web/web-http/alive", "web/web-http/ready"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("bad check order: %v", names)
+	}
+	if !checks[1].Healthy() || checks[2].Healthy() || checks[2].Output != "connection refused" {
+		t.Fatalf("bad check status: %#v %#v", checks[1], checks[2])
+	}
+}
+
+func TestAllocations_Restart(t *testing.T) {
+	var restart *http.Request
+	var req AllocRestartRequest
+	c, srv := makeNodeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/client/allocation/alloc1/restart" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		restart = r
+	})
+	defer srv.Close()
+
+	// The write options are applied to the restart request
+	alloc := &Allocation{ID: "alloc1", NodeID: "node1"}
+	q := &WriteOptions{Region: "east", AuthToken: "secret"}
+	if err := c.Allocations().Restart(alloc, "web", q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if restart == nil || restart.Method != "PUT" {
+		t.Fatalf("bad restart request: %#v", restart)
+	}
+	if region := restart.URL.Query().Get("region"); region != "east" {
+		t.Fatalf("bad region: %q", region)
+	}
+	if token := restart.Header.Get("X-Nomad-Token"); token != "secret" {
+		t.Fatalf("bad token: %q", token)
+	}
+	if req.TaskName != "web" {
+		t.Fatalf("bad task: %q", req.TaskName)
+	}
+}
+
+func TestAllocations_TaskStates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		fmt.Fprint(w, `{
+			"ID": "alloc1",
+			"TaskStates": {
+				"web": {
+					"State": "running",
+					"Failed": false,
+					"Restarts": 2,
+					"StartedAt": "2017-01-01T00:05:00Z",
+					"FinishedAt": "0001-01-01T00:00:00Z",
+					"Events": [
+						{"Type": "Started", "Time": 1},
+						{"Type": "Terminated", "Time": 2, "ExitCode": 1,
+						 "Details": {"exit_code": "1"}},
+						{"Type": "Restarting", "Time": 3, "RestartReason": "exited"}
+					]
+				},
+				"init": {"State": "pending"}
+			}
+		}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	alloc, _, err := c.Allocations().Info("alloc1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	web := alloc.TaskStates["web"]
+	if web.State != "running" || web.Failed || web.Restarts != 2 {
+		t.Fatalf("bad task state: %#v", web)
+	}
+	if !web.StartedAt.Equal(time.Date(2017, 1, 1, 0, 5, 0, 0, time.UTC)) || !web.FinishedAt.IsZero() {
+		t.Fatalf("bad task times: %v %v", web.StartedAt, web.FinishedAt)
+	}
+	if web.Events[1].Details["exit_code"] != "1" {
+		t.Fatalf("bad event details: %#v", web.Events[1])
+	}
+	if last := web.LastEvent(); last == nil || last.Type != TaskRestarting {
+		t.Fatalf("bad last event: %#v", last)
+	}
+	if last := alloc.TaskStates["init"].LastEvent(); last != nil {
+		t.Fatalf("unexpected last event: %#v", last)
+	}
+}
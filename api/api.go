@@ -3,17 +3,24 @@ package api
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-cleanhttp"
+	version "github.com/hashicorp/go-version"
 )
 
 // QueryOptions are used to parameterize a query
@@ -22,6 +29,11 @@ type QueryOptions struct {
 	// by the Config
 	Region string
 
+	// Namespace is the namespace to target, overwriting the namespace
+	// provided by the Config. Job IDs are only unique within a namespace,
+	// on servers that support namespaces; others ignore it.
+	Namespace string
+
 	// AllowStale allows any Nomad server (non-leader) to service
 	// a read. This allows for lower latency and higher throughput
 	AllowStale bool
@@ -30,6 +42,17 @@ type QueryOptions struct {
 	// until the timeout or the next index is reached
 	WaitIndex uint64
 
+	// RequireMonotonicIndex guards stale blocking queries against servers
+	// lagging behind the index already seen. If the index of the response
+	// to a query with AllowStale regresses below the WaitIndex by more than
+	// IndexRegressionThreshold, the query is retried against the leader, so
+	// watch loops don't go back in time when they hit a lagging follower.
+	// The tradeoff is that every regressed read costs a second request,
+	// served by the leader, which stale reads are meant to offload; with
+	// followers that often lag the guard erodes the benefit of AllowStale.
+	RequireMonotonicIndex    bool
+	IndexRegressionThreshold uint64
+
 	// WaitTime is used to bound the duration of a wait.
 	// Defaults to that of the Config, but can be overridden.
 	WaitTime time.Duration
@@ -37,8 +60,68 @@ type QueryOptions struct {
 	// If set, used as prefix for resource list searches
 	Prefix string
 
+	// Filter is a go-bexpr expression the servers filter the results of
+	// list endpoints with, such as `Status == "running" and Type ==
+	// "service"`. Selectors are the fields of the listed objects, nested
+	// with dots: the JobListStub fields for Jobs.List, such as Status,
+	// Type and Priority, the AllocationListStub fields for
+	// Allocations.List, such as ClientStatus, JobID and TaskGroup, and the
+	// NodeListStub fields for Nodes.List, such as Status, NodeClass and
+	// Datacenter. The expression is checked to parse before the request
+	// is sent, returning a *FilterSyntaxError if it doesn't. Servers that
+	// predate filtering ignore it and return every result.
+	Filter string
+
+	// Reverse requests the results of list endpoints newest first, in
+	// descending order of their create index, from the servers that
+	// support it. Lists ordered by ID by default, such as Jobs.List, are
+	// ordered by descending create index instead, while those already
+	// ordered newest first, such as Allocations.List, are unaffected.
+	// Reverse composes with Prefix and Filter. Jobs.List also sorts the
+	// jobs of each response client-side, so they are newest first from
+	// servers without support too, but only within that response: the
+	// sort doesn't order jobs across responses.
+	Reverse bool
+
 	// Set HTTP parameters on the query.
 	Params map[string]string
+
+	// Headers are set on the request, overriding the headers of the Config
+	// and the defaults of the client, such as the User-Agent.
+	Headers map[string]string
+
+	// AuthToken is the secret ID of the ACL token used for the request,
+	// overriding the SecretID of the Config.
+	AuthToken string
+
+	// Timeout bounds the duration of the request, overriding the default
+	// timeout of the Config. Blocking queries and streaming requests are
+	// only bounded if a Timeout is set.
+	Timeout time.Duration
+
+	// ctx is an optional context governing the request. When set, the
+	// default timeout of the Config is not applied.
+	ctx context.Context
+}
+
+// WithContext returns a copy of the query options that uses the given
+// context for the request.
+func (o *QueryOptions) WithContext(ctx context.Context) *QueryOptions {
+	o2 := new(QueryOptions)
+	if o != nil {
+		*o2 = *o
+	}
+	o2.ctx = ctx
+	return o2
+}
+
+// Context returns the context of the query options, defaulting to the
+// background context.
+func (o *QueryOptions) Context() context.Context {
+	if o != nil && o.ctx != nil {
+		return o.ctx
+	}
+	return context.Background()
 }
 
 // WriteOptions are used to parameterize a write
@@ -46,6 +129,57 @@ type WriteOptions struct {
 	// Providing a datacenter overwrites the region provided
 	// by the Config
 	Region string
+
+	// Namespace is the namespace to target, overwriting the namespace
+	// provided by the Config.
+	Namespace string
+
+	// VaultToken, if set, is submitted as the Vault token of jobs being
+	// registered, overriding the VaultToken of the job. It is never
+	// persisted on the job passed to the write.
+	VaultToken string
+
+	// AuthToken is the secret ID of the ACL token used for the request,
+	// overriding the SecretID of the Config.
+	AuthToken string
+
+	// Headers are set on the request, overriding the headers of the Config
+	// and the defaults of the client, such as the User-Agent.
+	Headers map[string]string
+
+	// EvalPriority, if set, is the priority of the evaluations created by
+	// job writes, such as registrations, deregistrations, scaling, reverts,
+	// forced evaluations and dispatches. A priority set in the options of
+	// the write itself takes precedence.
+	EvalPriority int
+
+	// Timeout bounds the duration of the request, overriding the default
+	// timeout of the Config.
+	Timeout time.Duration
+
+	// ctx is an optional context governing the request. When set, the
+	// default timeout of the Config is not applied.
+	ctx context.Context
+}
+
+// WithContext returns a copy of the write options that uses the given
+// context for the request.
+func (o *WriteOptions) WithContext(ctx context.Context) *WriteOptions {
+	o2 := new(WriteOptions)
+	if o != nil {
+		*o2 = *o
+	}
+	o2.ctx = ctx
+	return o2
+}
+
+// Context returns the context of the write options, defaulting to the
+// background context.
+func (o *WriteOptions) Context() context.Context {
+	if o != nil && o.ctx != nil {
+		return o.ctx
+	}
+	return context.Background()
 }
 
 // QueryMeta is used to return meta data about a query
@@ -73,6 +207,10 @@ type WriteMeta struct {
 
 	// How long did the request take
 	RequestTime time.Duration
+
+	// DryRun is set on writes made by a client in dry run mode, which were
+	// not applied, describing what they would have done.
+	DryRun *DryRunResult
 }
 
 // HttpBasicAuth is used to authenticate http client with HTTP Basic Authentication
@@ -86,33 +224,237 @@ type HttpBasicAuth struct {
 
 // Config is used to configure the creation of a client
 type Config struct {
-	// Address is the address of the Nomad agent
+	// Address is the address of the Nomad agent. It may be a unix:// URL,
+	// such as unix:///var/run/nomad.sock, to talk to the agent over a unix
+	// socket.
 	Address string
 
 	// Region to use. If not provided, the default agent region is used.
 	Region string
 
-	// HttpClient is the client to use. Default will be
-	// used if not provided.
+	// Namespace to use. If not provided, the default namespace is used. It
+	// defaults to the NOMAD_NAMESPACE environment variable.
+	Namespace string
+
+	// HttpClient is the client to use. DefaultConfig provides one whose
+	// transport is tuned by TransportConfig and DisableHTTP2 once the
+	// client is created, so both can still be changed after DefaultConfig,
+	// as can its transport, such as its TLS configuration. If nil, such a
+	// client is built.
 	HttpClient *http.Client
 
+	// TransportConfig tunes the connection reuse of the transport of the
+	// HttpClient provided by DefaultConfig or built in its absence, and of
+	// the clients used to talk directly to client nodes. It is ignored for
+	// any other HttpClient, whose transport is used unchanged. If nil,
+	// DefaultTransportConfig is used.
+	TransportConfig *TransportConfig
+
+	// DisableHTTP2 restricts the transports built by the client to
+	// HTTP/1.1. By default HTTP/2 is negotiated with agents served over
+	// TLS, multiplexing concurrent requests and streams such as followed
+	// logs over a single connection. Some proxies mishandle HTTP/2, in
+	// which case it can be disabled. It has no effect on a supplied
+	// HttpClient, nor on plaintext connections, which always use HTTP/1.1.
+	DisableHTTP2 bool
+
+	// Headers are set on every request, overriding the defaults of the
+	// client, such as the DefaultUserAgent. Headers set in the options of a
+	// request take precedence over them. They can be used to tell apart the
+	// tools sharing a cluster in the logs of proxies.
+	Headers http.Header
+
 	// HttpAuth is the auth info to use for http access.
 	HttpAuth *HttpBasicAuth
 
+	// SecretID is the secret ID of the ACL token sent with every request.
+	// It defaults to the NOMAD_TOKEN environment variable.
+	SecretID string
+
 	// WaitTime limits how long a Watch will block. If not provided,
 	// the agent default values will be used.
 	WaitTime time.Duration
+
+	// Timeout is the default timeout of non-blocking requests that are not
+	// governed by a context or a per-request timeout. If zero,
+	// DefaultRequestTimeout is used and if negative requests are not
+	// bounded. Blocking queries are bounded by WaitTime on the server and
+	// streaming requests, such as following logs, are never bounded by the
+	// default.
+	Timeout time.Duration
+
+	// RequestHook, if set, is invoked with every HTTP request before it is
+	// sent and the returned request is sent in its place. It can be used to
+	// inject headers such as trace IDs.
+	RequestHook func(*http.Request) *http.Request
+
+	// ResponseHook, if set, is invoked with every HTTP response along with
+	// the time taken to receive it. It can be used to record latencies.
+	ResponseHook func(*http.Response, time.Duration)
+
+	// CacheTTL, if positive, caches the responses of non-blocking queries
+	// without RequireMonotonicIndex in memory for the TTL, so repeated
+	// queries of the same endpoint with the same options are served without
+	// a request. Any write through the client purges the cache. Zero
+	// disables caching, as cached responses may be stale.
+	CacheTTL time.Duration
+
+	// DryRun makes the client refuse to mutate state. Job registrations are
+	// planned instead and ACL, CSI volume and recommendation writes are only
+	// validated. They return a WriteMeta holding the DryRunResult of the
+	// write. Job registrations return a response holding only the warnings
+	// found locally, and other writes no response. Every other write returns
+	// a *DryRunUnsupportedError without being sent.
+	DryRun bool
+
+	// PreferMsgpack asks the agent to encode responses with msgpack, which
+	// is smaller and faster to decode than JSON for bulk reads such as
+	// listing allocations. Responses are decoded by their content type, so
+	// agents that only encode JSON are still understood. Streams, such as
+	// logs and event streams, are always JSON.
+	PreferMsgpack bool
+
+	// closeCtx is cancelled once the client using the config is closed.
+	closeCtx context.Context
+
+	// defaultHttpClient is the HttpClient provided by DefaultConfig, whose
+	// transport is tuned when the client is created.
+	defaultHttpClient *http.Client
+
+	// nodeHttpClient is shared by the clients made to talk directly to the
+	// client nodes, so their connections are reused.
+	nodeHttpClient *http.Client
+}
+
+// nodeConfig returns the configuration used to talk directly to the client
+// node at the given address, carrying over the settings that apply to every
+// request. The HTTP client talking to the nodes is shared by the clients
+// made from the same client.
+func (c *Config) nodeConfig(address string) *Config {
+	httpClient := c.nodeHttpClient
+	if httpClient == nil {
+		httpClient = newHttpClient(c.TransportConfig, "", c.DisableHTTP2)
+	}
+	return &Config{
+		Address:         address,
+		HttpClient:      httpClient,
+		TransportConfig: c.TransportConfig,
+		DisableHTTP2:    c.DisableHTTP2,
+		SecretID:        c.SecretID,
+		Timeout:         c.Timeout,
+		RequestHook:     c.RequestHook,
+		ResponseHook:    c.ResponseHook,
+		DryRun:          c.DryRun,
+		PreferMsgpack:   c.PreferMsgpack,
+		Headers:         c.Headers,
+		closeCtx:        c.closeCtx,
+		nodeHttpClient:  httpClient,
+	}
+}
+
+// TransportConfig configures how the transport of a client reuses its
+// connections to the agent.
+type TransportConfig struct {
+	// IdleConnTimeout is how long an idle connection is kept open before
+	// it is closed. Zero means no limit.
+	IdleConnTimeout time.Duration
+
+	// MaxIdleConns is the maximum number of idle connections kept open to
+	// the agent. Zero keeps the default of the net/http package.
+	MaxIdleConns int
+
+	// DisableKeepAlives disables connection reuse, opening a new
+	// connection for every request.
+	DisableKeepAlives bool
+}
+
+// DefaultTransportConfig returns a transport configuration that reuses
+// connections, which suits clients that make many requests to the same
+// agent such as dashboards and controllers.
+func DefaultTransportConfig() *TransportConfig {
+	return &TransportConfig{
+		IdleConnTimeout: 90 * time.Second,
+		MaxIdleConns:    100,
+	}
+}
+
+// newHttpClient returns an HTTP client whose transport is tuned by the given
+// configuration. If socket is set, every connection is dialed to that unix
+// socket.
+func newHttpClient(tc *TransportConfig, socket string, disableHTTP2 bool) *http.Client {
+	transport := cleanhttp.DefaultPooledTransport()
+	configureTransport(transport, tc, socket, disableHTTP2)
+	return &http.Client{Transport: transport}
 }
 
+// configureTransport tunes the transport by the given configuration. If
+// socket is set, every connection is dialed to that unix socket.
+func configureTransport(transport *http.Transport, tc *TransportConfig, socket string, disableHTTP2 bool) {
+	if tc == nil {
+		tc = DefaultTransportConfig()
+	}
+	if socket != "" {
+		transport.Proxy = nil
+		transport.Dial = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+	transport.IdleConnTimeout = tc.IdleConnTimeout
+	transport.DisableKeepAlives = tc.DisableKeepAlives
+
+	// All requests go to the same agent so the idle connections are not
+	// limited per host beyond the overall limit.
+	if tc.MaxIdleConns > 0 {
+		transport.MaxIdleConns = tc.MaxIdleConns
+		transport.MaxIdleConnsPerHost = tc.MaxIdleConns
+	}
+	configureHTTP2(transport, disableHTTP2)
+}
+
+// configureHTTP2 sets whether the transport negotiates HTTP/2 over TLS. The
+// transport dials with a custom dialer, so net/http would not attempt HTTP/2
+// unless forced to.
+func configureHTTP2(transport *http.Transport, disable bool) {
+	if disable {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		return
+	}
+	transport.ForceAttemptHTTP2 = true
+}
+
+// DefaultUserAgent is the User-Agent of requests whose Config and options
+// don't set one. It carries no version as the package isn't versioned
+// separately from Nomad.
+const DefaultUserAgent = "Nomad API Go"
+
+// DefaultRequestTimeout is the timeout applied to non-blocking requests when
+// neither the Config nor the request specify one.
+const DefaultRequestTimeout = 60 * time.Second
+
+// DefaultNamespace is the namespace targeted when neither the Config nor the
+// request specify one.
+const DefaultNamespace = "default"
+
 // DefaultConfig returns a default configuration for the client
 func DefaultConfig() *Config {
 	config := &Config{
-		Address:    "http://127.0.0.1:4646",
-		HttpClient: cleanhttp.DefaultClient(),
+		Address:         "http://127.0.0.1:4646",
+		HttpClient:      &http.Client{Transport: cleanhttp.DefaultPooledTransport()},
+		TransportConfig: DefaultTransportConfig(),
 	}
+	config.defaultHttpClient = config.HttpClient
 	if addr := os.Getenv("NOMAD_ADDR"); addr != "" {
 		config.Address = addr
 	}
+	if namespace := os.Getenv("NOMAD_NAMESPACE"); namespace != "" {
+		config.Namespace = namespace
+	}
+	if token := os.Getenv("NOMAD_TOKEN"); token != "" {
+		config.SecretID = token
+	}
 	if auth := os.Getenv("NOMAD_HTTP_AUTH"); auth != "" {
 		var username, password string
 		if strings.Contains(auth, ":") {
@@ -134,6 +476,24 @@ func DefaultConfig() *Config {
 // Client provides a client to the Nomad API
 type Client struct {
 	config Config
+
+	// cache caches query responses if the Config has a CacheTTL.
+	cache *responseCache
+
+	// ownsTransport is set if the client created its HTTP client, in
+	// which case Close closes its idle connections.
+	ownsTransport bool
+
+	// closeFn cancels the closeCtx of the config, aborting the requests in
+	// flight, once the client is closed.
+	closeFn   context.CancelFunc
+	closeOnce sync.Once
+
+	// agentVersion is the version of the agent, read the first time an
+	// endpoint that older agents don't serve is requested. It is nil if the
+	// version couldn't be read.
+	agentVersion     *version.Version
+	agentVersionOnce sync.Once
 }
 
 // NewClient returns a new client
@@ -147,16 +507,112 @@ func NewClient(config *Config) (*Client, error) {
 		return nil, fmt.Errorf("invalid address '%s': %v", config.Address, err)
 	}
 
-	if config.HttpClient == nil {
-		config.HttpClient = defConfig.HttpClient
+	socket, ok := unixSocketPath(config.Address)
+	if ok {
+		info, err := os.Stat(socket)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unix socket address '%s': %v", config.Address, err)
+		}
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("invalid unix socket address '%s': %s is not a socket", config.Address, socket)
+		}
+	}
+
+	ownsTransport := false
+	switch {
+	case config.HttpClient == nil:
+		config.HttpClient = newHttpClient(config.TransportConfig, socket, config.DisableHTTP2)
+		ownsTransport = true
+	case config.HttpClient == config.defaultHttpClient:
+		if transport, ok := config.HttpClient.Transport.(*http.Transport); ok {
+			configureTransport(transport, config.TransportConfig, socket, config.DisableHTTP2)
+		}
+		ownsTransport = true
 	}
 
 	client := &Client{
-		config: *config,
+		config:        *config,
+		ownsTransport: ownsTransport,
+	}
+	if config.nodeHttpClient == nil {
+		client.config.nodeHttpClient = newHttpClient(config.TransportConfig, "", config.DisableHTTP2)
+	}
+
+	// Clients made to talk to the nodes directly are closed along with the
+	// client they were made from.
+	parent := config.closeCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	client.config.closeCtx, client.closeFn = context.WithCancel(parent)
+	if config.CacheTTL > 0 {
+		client.cache = newResponseCache(config.CacheTTL)
 	}
 	return client, nil
 }
 
+// ErrClientClosed is returned by requests made with a closed client.
+var ErrClientClosed = errors.New("client is closed")
+
+// Close releases the resources of the client. Requests in flight, including
+// blocking queries, watches and streams such as logs and monitors, are
+// aborted and return errors, which stops the goroutines serving them. If
+// the client created its HTTP client, its idle connections are closed too;
+// an HttpClient given in the Config is left for its owner to clean up. The
+// client is unusable afterwards, any request made with it returning
+// ErrClientClosed. Close may be called more than once.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		if c.closeFn != nil {
+			c.closeFn()
+		}
+		if c.cache != nil {
+			c.cache.purge()
+		}
+		if c.ownsTransport {
+			c.config.HttpClient.CloseIdleConnections()
+		}
+		if c.config.nodeHttpClient != nil {
+			c.config.nodeHttpClient.CloseIdleConnections()
+		}
+	})
+}
+
+// Ping checks that the agent is up with a request to its health endpoint,
+// which the agent answers without contacting the servers. It returns nil if
+// the agent responds without a server error, so agents that predate the
+// health endpoint and answer 404 are considered up. The request is bounded
+// by the timeout of the Config.
+func (c *Client) Ping() error {
+	r := c.newRequest("GET", "/v1/agent/health")
+	_, resp, err := c.doRequest(r)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		var buf bytes.Buffer
+		io.Copy(&buf, resp.Body)
+		return fmt.Errorf("Unexpected response code: %d (%s)", resp.StatusCode, buf.Bytes())
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
+
+// unixSocketPath returns the path of the unix socket addressed by a unix://
+// address.
+func unixSocketPath(address string) (string, bool) {
+	u, err := url.Parse(address)
+	if err != nil || u.Scheme != "unix" {
+		return "", false
+	}
+	return u.Path, true
+}
+
 // SetRegion sets the region to forward API requests to.
 func (c *Client) SetRegion(region string) {
 	c.config.Region = region
@@ -170,6 +626,19 @@ type request struct {
 	params url.Values
 	body   io.Reader
 	obj    interface{}
+
+	// ctx and timeout govern the lifetime of the request. Blocking and
+	// streaming requests are exempt from the default timeout.
+	ctx      context.Context
+	timeout  time.Duration
+	blocking bool
+	stream   bool
+
+	// token is the secret ID of the ACL token the request is made with
+	token string
+
+	// headers are the headers set by the options of the request
+	headers map[string]string
 }
 
 // setQueryOptions is used to annotate the request with
@@ -181,6 +650,9 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.Region != "" {
 		r.params.Set("region", q.Region)
 	}
+	if q.Namespace != "" {
+		r.params.Set("namespace", q.Namespace)
+	}
 	if q.AllowStale {
 		r.params.Set("stale", "")
 	}
@@ -193,9 +665,22 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.Prefix != "" {
 		r.params.Set("prefix", q.Prefix)
 	}
+	if q.Filter != "" {
+		r.params.Set("filter", q.Filter)
+	}
+	if q.Reverse {
+		r.params.Set("reverse", "true")
+	}
 	for k, v := range q.Params {
 		r.params.Set(k, v)
 	}
+	if q.AuthToken != "" {
+		r.token = q.AuthToken
+	}
+	r.headers = q.Headers
+	r.ctx = q.ctx
+	r.timeout = q.Timeout
+	r.blocking = q.WaitIndex != 0
 }
 
 // durToMsec converts a duration to a millisecond specified string
@@ -212,10 +697,42 @@ func (r *request) setWriteOptions(q *WriteOptions) {
 	if q.Region != "" {
 		r.params.Set("region", q.Region)
 	}
+	if q.Namespace != "" {
+		r.params.Set("namespace", q.Namespace)
+	}
+	if q.AuthToken != "" {
+		r.token = q.AuthToken
+	}
+	r.headers = q.Headers
+	r.ctx = q.ctx
+	r.timeout = q.Timeout
+}
+
+// requestTimeout returns the timeout to apply to the request or zero if the
+// request is not bounded.
+func (r *request) requestTimeout() time.Duration {
+	switch {
+	case r.timeout > 0:
+		return r.timeout
+	case r.ctx != nil || r.blocking || r.stream:
+		return 0
+	case r.config.Timeout < 0:
+		return 0
+	case r.config.Timeout > 0:
+		return r.config.Timeout
+	default:
+		return DefaultRequestTimeout
+	}
 }
 
 // toHTTP converts the request to an HTTP request
 func (r *request) toHTTP() (*http.Request, error) {
+	if filter := r.params.Get("filter"); filter != "" {
+		if err := validateFilter(filter); err != nil {
+			return nil, err
+		}
+	}
+
 	// Encode the query parameters
 	r.url.RawQuery = r.params.Encode()
 
@@ -233,6 +750,9 @@ func (r *request) toHTTP() (*http.Request, error) {
 	if err != nil {
 		return nil, err
 	}
+	if r.ctx != nil {
+		req = req.WithContext(r.ctx)
+	}
 
 	// Optionally configure HTTP basic authentication
 	if r.url.User != nil {
@@ -243,7 +763,27 @@ func (r *request) toHTTP() (*http.Request, error) {
 		req.SetBasicAuth(r.config.HttpAuth.Username, r.config.HttpAuth.Password)
 	}
 
+	if r.token != "" {
+		req.Header.Set("X-Nomad-Token", r.token)
+	}
+
+	req.Header.Set("User-Agent", DefaultUserAgent)
 	req.Header.Add("Accept-Encoding", "gzip")
+	if r.config.PreferMsgpack && !r.stream {
+		req.Header.Set("Accept", msgpackAccept)
+	}
+
+	// Headers of the Config override the defaults and are overridden by
+	// those of the request
+	for k, values := range r.config.Headers {
+		req.Header.Del(k)
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
 	req.URL.Host = r.url.Host
 	req.URL.Scheme = r.url.Scheme
 	req.Host = r.url.Host
@@ -254,6 +794,12 @@ func (r *request) toHTTP() (*http.Request, error) {
 func (c *Client) newRequest(method, path string) *request {
 	base, _ := url.Parse(c.config.Address)
 	u, _ := url.Parse(path)
+
+	// Requests over a unix socket are plain HTTP, the transport takes care
+	// of dialing the socket
+	if base.Scheme == "unix" {
+		base = &url.URL{Scheme: "http", Host: "localhost"}
+	}
 	r := &request{
 		config: &c.config,
 		method: method,
@@ -264,10 +810,14 @@ func (c *Client) newRequest(method, path string) *request {
 			Path:   u.Path,
 		},
 		params: make(map[string][]string),
+		token:  c.config.SecretID,
 	}
 	if c.config.Region != "" {
 		r.params.Set("region", c.config.Region)
 	}
+	if c.config.Namespace != "" {
+		r.params.Set("namespace", c.config.Namespace)
+	}
 	if c.config.WaitTime != 0 {
 		r.params.Set("wait", durToMsec(r.config.WaitTime))
 	}
@@ -302,15 +852,81 @@ func (m *multiCloser) Read(p []byte) (int, error) {
 	return m.reader.Read(p)
 }
 
+// cancelCloser wraps a response body such that the context bounding the
+// request is cancelled once the body is closed.
+type cancelCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
 // doRequest runs a request with our client
 func (c *Client) doRequest(r *request) (time.Duration, *http.Response, error) {
 	req, err := r.toHTTP()
 	if err != nil {
 		return 0, nil, err
 	}
+	if c.config.RequestHook != nil {
+		req = c.config.RequestHook(req)
+	}
+
+	// Bound the request by its timeout and the lifetime of the client. Both
+	// cover reading the body so they are only released once the body is
+	// closed.
+	closeCtx := c.config.closeCtx
+	if closeCtx != nil && closeCtx.Err() != nil {
+		return 0, nil, ErrClientClosed
+	}
+	if c.config.DryRun && !r.dryRunSafe() {
+		return 0, nil, &DryRunUnsupportedError{Method: r.method, Endpoint: r.url.Path}
+	}
+	if err := c.checkEndpoint(r); err != nil {
+		return 0, nil, err
+	}
+	ctx := req.Context()
+	var cancel context.CancelFunc
+	if timeout := r.requestTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	if closeCtx != nil {
+		if cancel == nil {
+			ctx, cancel = context.WithCancel(ctx)
+		}
+		go func(ctx context.Context, cancel context.CancelFunc) {
+			select {
+			case <-closeCtx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}(ctx, cancel)
+	}
+	if cancel != nil {
+		req = req.WithContext(ctx)
+	}
+
 	start := time.Now()
 	resp, err := c.config.HttpClient.Do(req)
 	diff := time.Now().Sub(start)
+	if err != nil && closeCtx != nil && closeCtx.Err() != nil {
+		err = ErrClientClosed
+	}
+	if c.cache != nil && r.method != "GET" {
+		c.cache.purge()
+	}
+	if cancel != nil {
+		if resp == nil {
+			cancel()
+		} else {
+			resp.Body = &cancelCloser{ReadCloser: resp.Body, cancel: cancel}
+		}
+	}
+	if resp != nil && c.config.ResponseHook != nil {
+		c.config.ResponseHook(resp, diff)
+	}
 
 	// If the response is compressed, we swap the body's reader.
 	if resp != nil && resp.Header != nil {
@@ -319,6 +935,10 @@ func (c *Client) doRequest(r *request) (time.Duration, *http.Response, error) {
 		case "gzip":
 			greader, err := gzip.NewReader(resp.Body)
 			if err != nil {
+				resp.Body.Close()
+				if cancel != nil {
+					cancel()
+				}
 				return 0, nil, err
 			}
 
@@ -338,10 +958,12 @@ func (c *Client) doRequest(r *request) (time.Duration, *http.Response, error) {
 }
 
 // rawQuery makes a GET request to the specified endpoint but returns just the
-// response body.
+// response body. As the body may be streamed, the request is only bounded by
+// an explicit timeout or context.
 func (c *Client) rawQuery(endpoint string, q *QueryOptions) (io.ReadCloser, error) {
 	r := c.newRequest("GET", endpoint)
 	r.setQueryOptions(q)
+	r.stream = true
 	_, resp, err := requireOK(c.doRequest(r))
 	if err != nil {
 		return nil, err
@@ -356,6 +978,12 @@ func (c *Client) rawQuery(endpoint string, q *QueryOptions) (io.ReadCloser, erro
 func (c *Client) query(endpoint string, out interface{}, q *QueryOptions) (*QueryMeta, error) {
 	r := c.newRequest("GET", endpoint)
 	r.setQueryOptions(q)
+
+	// Cached responses would skip the check of their index, so queries
+	// guarded against index regressions always reach the servers
+	if c.cache != nil && !r.blocking && (q == nil || !q.RequireMonotonicIndex) {
+		return c.cachedQuery(r, out)
+	}
 	rtt, resp, err := requireOK(c.doRequest(r))
 	if err != nil {
 		return nil, err
@@ -366,12 +994,69 @@ func (c *Client) query(endpoint string, out interface{}, q *QueryOptions) (*Quer
 	parseQueryMeta(resp, qm)
 	qm.RequestTime = rtt
 
+	if indexRegressed(q, qm) {
+		return c.query(endpoint, out, q.fromLeader())
+	}
+
 	if err := decodeBody(resp, out); err != nil {
 		return nil, err
 	}
 	return qm, nil
 }
 
+// indexRegressed returns whether the response to a stale query regressed
+// below the index already seen by more than the threshold of its options.
+func indexRegressed(q *QueryOptions, qm *QueryMeta) bool {
+	if q == nil || !q.RequireMonotonicIndex || !q.AllowStale || q.WaitIndex == 0 {
+		return false
+	}
+	return q.WaitIndex > q.IndexRegressionThreshold && qm.LastIndex < q.WaitIndex-q.IndexRegressionThreshold
+}
+
+// fromLeader returns a copy of the query options that is served by the
+// leader.
+func (o *QueryOptions) fromLeader() *QueryOptions {
+	o2 := *o
+	o2.AllowStale = false
+	return &o2
+}
+
+// cachedQuery serves a query from the response cache, making the request
+// and caching its response on a miss.
+func (c *Client) cachedQuery(r *request, out interface{}) (*QueryMeta, error) {
+	key := r.cacheKey()
+	if entry, ok := c.cache.get(key); ok {
+		qm := entry.meta
+		qm.RequestTime = 0
+		if err := decodeBytes(entry.contentType, entry.body, out); err != nil {
+			return nil, err
+		}
+		return &qm, nil
+	}
+
+	gen := c.cache.generation()
+	rtt, resp, err := requireOK(c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if err := decodeBytes(contentType, body, out); err != nil {
+		return nil, err
+	}
+	c.cache.put(key, gen, contentType, body, qm)
+	return qm, nil
+}
+
 // write is used to do a PUT request against an endpoint
 // and serialize/deserialized using the standard Nomad conventions.
 func (c *Client) write(endpoint string, in, out interface{}, q *WriteOptions) (*WriteMeta, error) {
@@ -397,9 +1082,10 @@ func (c *Client) write(endpoint string, in, out interface{}, q *WriteOptions) (*
 
 // write is used to do a PUT request against an endpoint
 // and serialize/deserialized using the standard Nomad conventions.
-func (c *Client) delete(endpoint string, out interface{}, q *WriteOptions) (*WriteMeta, error) {
+func (c *Client) delete(endpoint string, in, out interface{}, q *WriteOptions) (*WriteMeta, error) {
 	r := c.newRequest("DELETE", endpoint)
 	r.setWriteOptions(q)
+	r.obj = in
 	rtt, resp, err := requireOK(c.doRequest(r))
 	if err != nil {
 		return nil, err
@@ -458,10 +1144,10 @@ func parseWriteMeta(resp *http.Response, q *WriteMeta) error {
 	return nil
 }
 
-// decodeBody is used to JSON decode a body
+// decodeBody is used to decode a body, which is JSON unless the agent
+// responded with msgpack.
 func decodeBody(resp *http.Response, out interface{}) error {
-	dec := json.NewDecoder(resp.Body)
-	return dec.Decode(out)
+	return decodeReader(resp.Header.Get("Content-Type"), resp.Body, out)
 }
 
 // encodeBody is used to encode a request body
@@ -1,10 +1,19 @@
 package api
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -80,7 +89,7 @@ func TestRequestTime(t *testing.T) {
 		t.Errorf("bad request time: %d", wm.RequestTime)
 	}
 
-	wm, err = client.delete("/", &out, nil)
+	wm, err = client.delete("/", nil, &out, nil)
 	if err != nil {
 		t.Fatalf("delete err: %v", err)
 	}
@@ -124,6 +133,7 @@ func TestSetQueryOptions(t *testing.T) {
 		AllowStale: true,
 		WaitIndex:  1000,
 		WaitTime:   100 * time.Second,
+		Filter:     `Status == "running"`,
 	}
 	r.setQueryOptions(q)
 
@@ -139,6 +149,9 @@ func TestSetQueryOptions(t *testing.T) {
 	if r.params.Get("wait") != "100000ms" {
 		t.Fatalf("bad: %v", r.params)
 	}
+	if r.params.Get("filter") != `Status == "running"` {
+		t.Fatalf("bad: %v", r.params)
+	}
 }
 
 func TestSetWriteOptions(t *testing.T) {
@@ -178,6 +191,56 @@ func TestRequestToHTTP(t *testing.T) {
 	}
 }
 
+func TestQuery_RequireMonotonicIndex(t *testing.T) {
+	// The follower lags behind the leader
+	var stale []bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, isStale := r.URL.Query()["stale"]
+		stale = append(stale, isStale)
+		index, value := "100", "leader"
+		if isStale {
+			index, value = "90", "follower"
+		}
+		w.Header().Set("X-Nomad-Index", index)
+		fmt.Fprintf(w, `{"Value": %q}`, value)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		q      *QueryOptions
+		stale  []bool
+		index  uint64
+		source string
+	}{
+		{"unguarded", &QueryOptions{AllowStale: true, WaitIndex: 95}, []bool{true}, 90, "follower"},
+		{"regressed", &QueryOptions{AllowStale: true, WaitIndex: 95, RequireMonotonicIndex: true}, []bool{true, false}, 100, "leader"},
+		{"within threshold", &QueryOptions{AllowStale: true, WaitIndex: 95, RequireMonotonicIndex: true, IndexRegressionThreshold: 5},
+			[]bool{true}, 90, "follower"},
+		{"beyond threshold", &QueryOptions{AllowStale: true, WaitIndex: 95, RequireMonotonicIndex: true, IndexRegressionThreshold: 4},
+			[]bool{true, false}, 100, "leader"},
+		{"not regressed", &QueryOptions{AllowStale: true, WaitIndex: 80, RequireMonotonicIndex: true}, []bool{true}, 90, "follower"},
+	}
+	for _, tc := range cases {
+		stale = nil
+		var out struct{ Value string }
+		qm, err := c.query("/v1/test", &out, tc.q)
+		if err != nil {
+			t.Fatalf("%s: err: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(stale, tc.stale) || qm.LastIndex != tc.index || out.Value != tc.source {
+			t.Fatalf("%s: bad query: requests %v, index %d, value %q", tc.name, stale, qm.LastIndex, out.Value)
+		}
+	}
+}
+
 func TestParseQueryMeta(t *testing.T) {
 	resp := &http.Response{
 		Header: make(map[string][]string),
@@ -235,3 +298,534 @@ func TestQueryString(t *testing.T) {
 		t.Fatalf("bad uri: %q", uri)
 	}
 }
+
+func TestRequestHeaders(t *testing.T) {
+	var received http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.Header().Set("X-Nomad-Index", "1")
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var out struct{}
+	if _, err := c.query("/v1/test", &out, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ua := received.Get("User-Agent"); ua != "Nomad API Go" {
+		t.Fatalf("bad default User-Agent: %q", ua)
+	}
+
+	// The headers of the Config override the defaults
+	conf = DefaultConfig()
+	conf.Address = srv.URL
+	conf.Headers = http.Header{
+		"User-Agent": {"deployer/1.0"},
+		"X-Tool":     {"deployer"},
+		"X-Team":     {"infra", "platform"},
+	}
+	c, err = NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c.query("/v1/test", &out, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if received.Get("User-Agent") != "deployer/1.0" || received.Get("X-Tool") != "deployer" ||
+		!reflect.DeepEqual(received["X-Team"], []string{"infra", "platform"}) {
+		t.Fatalf("bad config headers: %v", received)
+	}
+
+	// The headers of the request options override those of the Config
+	q := &QueryOptions{Headers: map[string]string{"User-Agent": "deployer/1.1", "x-tool": "deploy-check"}}
+	if _, err := c.query("/v1/test", &out, q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if received.Get("User-Agent") != "deployer/1.1" || received.Get("X-Tool") != "deploy-check" || received.Get("X-Team") != "infra" {
+		t.Fatalf("bad query headers: %v", received)
+	}
+
+	w := &WriteOptions{Headers: map[string]string{"X-Tool": "deploy-write"}}
+	if _, err := c.write("/v1/test", struct{}{}, nil, w); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if received.Get("User-Agent") != "deployer/1.0" || received.Get("X-Tool") != "deploy-write" {
+		t.Fatalf("bad write headers: %v", received)
+	}
+}
+
+func TestRequestHooks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Trace-ID", r.Header.Get("X-Trace-ID"))
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	var traceID string
+	var latency time.Duration
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.RequestHook = func(req *http.Request) *http.Request {
+		req.Header.Set("X-Trace-ID", "abc123")
+		return req
+	}
+	conf.ResponseHook = func(resp *http.Response, d time.Duration) {
+		traceID = resp.Header.Get("X-Trace-ID")
+		latency = d
+	}
+
+	client, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var out interface{}
+	if _, err := client.write("/", nil, &out, nil); err != nil {
+		t.Fatalf("write err: %v", err)
+	}
+	if traceID != "abc123" {
+		t.Fatalf("request hook not applied; got trace id %q", traceID)
+	}
+	if latency == 0 {
+		t.Fatalf("response hook not called with latency")
+	}
+}
+
+// closeRecorder records whether the body it wraps was closed.
+type closeRecorder struct {
+	io.ReadCloser
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return c.ReadCloser.Close()
+}
+
+func TestRequest_InvalidGzip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("not gzip"))
+	}))
+	defer srv.Close()
+
+	var body *closeRecorder
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.Timeout = time.Minute
+	conf.ResponseHook = func(resp *http.Response, d time.Duration) {
+		body = &closeRecorder{ReadCloser: resp.Body}
+		resp.Body = body
+	}
+	client, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The body of responses that fail to decompress is closed
+	var out interface{}
+	if _, err := client.query("/v1/jobs", &out, nil); err == nil {
+		t.Fatalf("expected error")
+	}
+	if body == nil || !body.closed {
+		t.Fatalf("response body not closed")
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.Timeout = 10 * time.Millisecond
+	client, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The config timeout bounds non-blocking requests
+	var out interface{}
+	if _, err := client.query("/", &out, nil); err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if _, err := client.write("/", nil, &out, nil); err == nil {
+		t.Fatalf("expected timeout error")
+	}
+
+	// Per-request timeouts override the config
+	if _, err := client.query("/", &out, &QueryOptions{Timeout: time.Second}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := client.write("/", nil, &out, &WriteOptions{Timeout: time.Second}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Blocking queries are left to the wait time
+	if _, err := client.query("/", &out, &QueryOptions{WaitIndex: 10}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A context replaces the default timeout
+	q := (&QueryOptions{}).WithContext(context.Background())
+	if _, err := client.query("/", &out, q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.query("/", &out, q.WithContext(ctx)); err == nil {
+		t.Fatalf("expected context deadline error")
+	}
+}
+
+func TestRequestTimeout_Default(t *testing.T) {
+	client, err := NewClient(DefaultConfig())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	r := client.newRequest("GET", "/v1/jobs")
+	r.setQueryOptions(nil)
+	if d := r.requestTimeout(); d != DefaultRequestTimeout {
+		t.Fatalf("bad timeout: %v", d)
+	}
+
+	client.config.Timeout = -1
+	if d := r.requestTimeout(); d != 0 {
+		t.Fatalf("expected unbounded request, got: %v", d)
+	}
+}
+
+func TestTransportConfig(t *testing.T) {
+	// The default transport reuses connections
+	c, err := NewClient(DefaultConfig())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	transport := c.config.HttpClient.Transport.(*http.Transport)
+	if transport.DisableKeepAlives || transport.MaxIdleConnsPerHost != 100 || transport.IdleConnTimeout != 90*time.Second {
+		t.Fatalf("bad default transport: %#v", transport)
+	}
+
+	// The client of DefaultConfig can be customized before creating the
+	// client and is tuned by the transport config set after DefaultConfig
+	conf := DefaultConfig()
+	if conf.HttpClient == nil {
+		t.Fatalf("DefaultConfig must provide an HttpClient")
+	}
+	tlsConfig := &tls.Config{ServerName: "nomad.example"}
+	conf.HttpClient.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	conf.TransportConfig = &TransportConfig{
+		IdleConnTimeout:   time.Second,
+		MaxIdleConns:      3,
+		DisableKeepAlives: true,
+	}
+	c, err = NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	transport = c.config.HttpClient.Transport.(*http.Transport)
+	if !transport.DisableKeepAlives || transport.MaxIdleConns != 3 || transport.IdleConnTimeout != time.Second {
+		t.Fatalf("bad transport: %#v", transport)
+	}
+	if transport.TLSClientConfig != tlsConfig {
+		t.Fatalf("customized TLS config was replaced")
+	}
+
+	// The clients talking directly to the nodes are tuned too, and share
+	// their connections
+	node := c.config.nodeConfig("http://127.0.0.1:4646")
+	transport = node.HttpClient.Transport.(*http.Transport)
+	if !transport.DisableKeepAlives || transport.MaxIdleConns != 3 || transport.IdleConnTimeout != time.Second {
+		t.Fatalf("bad node transport: %#v", transport)
+	}
+	if other := c.config.nodeConfig("http://127.0.0.2:4646"); other.HttpClient != node.HttpClient {
+		t.Fatalf("node clients are not shared")
+	}
+
+	// A supplied client is used unchanged
+	custom := &http.Client{}
+	conf.HttpClient = custom
+	c, err = NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if c.config.HttpClient != custom || custom.Transport != nil {
+		t.Fatalf("custom client was modified")
+	}
+}
+
+func TestDisableHTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stream frames, flushing each, as the log endpoints do
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "%s %d\n", r.Proto, i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	for _, disable := range []bool{false, true} {
+		conf := DefaultConfig()
+		conf.Address = srv.URL
+		conf.DisableHTTP2 = disable
+		c, err := NewClient(conf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		c.config.HttpClient.Transport.(*http.Transport).TLSClientConfig = &tls.Config{RootCAs: roots}
+
+		body, err := c.rawQuery("/v1/stream", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		proto := "HTTP/2.0"
+		if disable {
+			proto = "HTTP/1.1"
+		}
+		expected := fmt.Sprintf("%[1]s 0\n%[1]s 1\n%[1]s 2\n", proto)
+		if string(out) != expected {
+			t.Fatalf("disable %v: expected %q, got %q", disable, expected, out)
+		}
+		c.Close()
+	}
+
+	// Clients talking to the nodes directly inherit the setting
+	conf := DefaultConfig()
+	conf.DisableHTTP2 = true
+	node := conf.nodeConfig("http://127.0.0.1:4646")
+	if transport := node.HttpClient.Transport.(*http.Transport); transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Fatalf("node transport negotiates HTTP/2")
+	}
+}
+
+func TestNewClient_UnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomad-api")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "nomad.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer ln.Close()
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/regions" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`["global"]`))
+	}))
+
+	conf := DefaultConfig()
+	conf.Address = "unix://" + socket
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	regions, err := c.Regions().List()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(regions) != 1 || regions[0] != "global" {
+		t.Fatalf("bad regions: %v", regions)
+	}
+
+	// Missing sockets are reported when creating the client
+	conf = DefaultConfig()
+	conf.Address = "unix://" + filepath.Join(dir, "missing.sock")
+	if _, err := NewClient(conf); err == nil || !strings.Contains(err.Error(), "missing.sock") {
+		t.Fatalf("expected missing socket error, got: %v", err)
+	}
+
+	// As are paths that are not sockets
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	conf = DefaultConfig()
+	conf.Address = "unix://" + file
+	if _, err := NewClient(conf); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Fatalf("expected not a socket error, got: %v", err)
+	}
+}
+
+func TestRequestAuthToken(t *testing.T) {
+	var token string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Nomad-Token")
+		w.Header().Set("X-Nomad-Index", "1")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.SecretID = "config-secret"
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var out map[string]interface{}
+	if _, err := c.query("/v1/jobs", &out, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if token != "config-secret" {
+		t.Fatalf("bad token: %q", token)
+	}
+
+	if _, err := c.query("/v1/jobs", &out, &QueryOptions{AuthToken: "query-secret"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if token != "query-secret" {
+		t.Fatalf("bad token: %q", token)
+	}
+
+	if _, err := c.write("/v1/jobs", nil, &out, &WriteOptions{AuthToken: "write-secret"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if token != "write-secret" {
+		t.Fatalf("bad token: %q", token)
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		switch r.URL.Path {
+		case "/v1/agent/monitor":
+			// Stream until the client goes away
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/v1/jobs":
+			if r.URL.Query().Get("index") != "" {
+				// Block until the client goes away
+				<-r.Context().Done()
+				return
+			}
+			w.Write([]byte("[]"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// Let the goroutines of the server settle before counting
+	time.Sleep(10 * time.Millisecond)
+	before := runtime.NumGoroutine()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Leave an idle connection, a stream and a watch behind
+	if _, _, err := c.Jobs().List(nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c.Agent().Monitor(context.Background(), nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- c.Watch(context.Background(), &QueryOptions{WaitIndex: 10}, func(q *QueryOptions) (*QueryMeta, error) {
+			_, qm, err := c.Jobs().List(q)
+			return qm, err
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if runtime.NumGoroutine() <= before {
+		t.Fatalf("expected the client to run goroutines")
+	}
+
+	c.Close()
+	c.Close()
+
+	select {
+	case err := <-watchErr:
+		if err != ErrClientClosed {
+			t.Fatalf("expected ErrClientClosed, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch not stopped by close")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			t.Fatalf("leaked goroutines: %d > %d\n%s", runtime.NumGoroutine(), before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The client is unusable once closed
+	if _, _, err := c.Jobs().List(nil); err != ErrClientClosed {
+		t.Fatalf("expected ErrClientClosed, got: %v", err)
+	}
+}
+
+func TestClient_Ping(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/agent/health" {
+			t.Fatalf("unexpected request: %s", r.URL.Path)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"ok": true}`))
+	}))
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.Timeout = time.Second
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := c.Ping(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Agents without the health endpoint are up
+	status = http.StatusNotFound
+	if err := c.Ping(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := c.Ping(); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected server error, got: %v", err)
+	}
+
+	// Unreachable agents fail the ping
+	srv.Close()
+	if err := c.Ping(); err == nil {
+		t.Fatalf("expected connection error")
+	}
+}
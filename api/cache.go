@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// responseCache caches the responses of non-blocking queries for a TTL. Any
+// write through the client purges it.
+type responseCache struct {
+	ttl time.Duration
+
+	l       sync.Mutex
+	entries map[string]*cacheEntry
+
+	// gen is incremented by every purge, so that responses to queries sent
+	// before a purge are not cached after it.
+	gen uint64
+}
+
+// cacheEntry is a cached response body along with its content type and query
+// meta.
+type cacheEntry struct {
+	contentType string
+	body        []byte
+	meta        QueryMeta
+	expires     time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// cacheKey returns the key a query is cached under. Queries made with
+// different tokens may be authorized to see different data, and the headers
+// of a query may change its response, so the token actually sent and the
+// headers of the query are part of the key.
+func (r *request) cacheKey() string {
+	key := r.url.Path + "?" + r.params.Encode() + "\x00" + r.sentToken()
+	names := make([]string, 0, len(r.headers))
+	for name := range r.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key += "\x00" + http.CanonicalHeaderKey(name) + ": " + r.headers[name]
+	}
+	return key
+}
+
+// sentToken returns the ACL token sent with the request, which the headers
+// of the Config and of the request override.
+func (r *request) sentToken() string {
+	for name, value := range r.headers {
+		if http.CanonicalHeaderKey(name) == "X-Nomad-Token" {
+			return value
+		}
+	}
+	for name, values := range r.config.Headers {
+		if http.CanonicalHeaderKey(name) == "X-Nomad-Token" {
+			return strings.Join(values, ",")
+		}
+	}
+	return r.token
+}
+
+// get returns the unexpired entry cached under the key.
+func (c *responseCache) get(key string) (*cacheEntry, bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// generation returns the current generation of the cache, to be passed to put
+// along with the response of a query sent afterwards.
+func (c *responseCache) generation() uint64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.gen
+}
+
+// put caches the response under the key, pruning expired entries. The
+// response is dropped if the cache was purged since the given generation, as
+// a write may have made it stale while the query was in flight.
+func (c *responseCache) put(key string, gen uint64, contentType string, body []byte, meta *QueryMeta) {
+	now := time.Now()
+	c.l.Lock()
+	defer c.l.Unlock()
+	if gen != c.gen {
+		return
+	}
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = &cacheEntry{
+		contentType: contentType,
+		body:        body,
+		meta:        *meta,
+		expires:     now.Add(c.ttl),
+	}
+}
+
+// purge removes every cached response.
+func (c *responseCache) purge() {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.entries = make(map[string]*cacheEntry)
+	c.gen++
+}
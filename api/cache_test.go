@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_ResponseCache(t *testing.T) {
+	gets := 0
+	var racingWrite func()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		switch r.Method {
+		case "GET":
+			gets++
+			if racingWrite != nil {
+				racingWrite()
+				racingWrite = nil
+			}
+			json.NewEncoder(w).Encode([]*JobListStub{{ID: "job1"}})
+		default:
+			json.NewEncoder(w).Encode(&JobRegisterResponse{EvalID: "eval1"})
+		}
+	}))
+	defer srv.Close()
+
+	newClient := func(ttl time.Duration) *Client {
+		conf := DefaultConfig()
+		conf.Address = srv.URL
+		conf.CacheTTL = ttl
+		c, err := NewClient(conf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return c
+	}
+	list := func(c *Client, q *QueryOptions) {
+		jobs, qm, err := c.Jobs().List(q)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(jobs) != 1 || jobs[0].ID != "job1" || qm.LastIndex != 10 {
+			t.Fatalf("bad response: %#v %#v", jobs, qm)
+		}
+	}
+	expectGets := func(expected int) {
+		t.Helper()
+		if gets != expected {
+			t.Fatalf("expected %d requests, got %d", expected, gets)
+		}
+	}
+
+	// Caching is disabled by default
+	c := newClient(0)
+	list(c, nil)
+	list(c, nil)
+	expectGets(2)
+
+	// Repeated queries are served from the cache
+	gets = 0
+	c = newClient(time.Hour)
+	list(c, nil)
+	list(c, nil)
+	expectGets(1)
+
+	// Queries with other options or tokens are cached separately
+	list(c, &QueryOptions{Prefix: "job"})
+	list(c, &QueryOptions{AuthToken: "other"})
+	expectGets(3)
+
+	// Tokens and headers set by headers are part of the key
+	list(c, &QueryOptions{Headers: map[string]string{"x-nomad-token": "other"}})
+	list(c, &QueryOptions{Headers: map[string]string{"X-Nomad-Token": "third"}})
+	list(c, &QueryOptions{Headers: map[string]string{"X-Tool": "ci"}})
+	list(c, &QueryOptions{Headers: map[string]string{"X-Tool": "ci"}})
+	expectGets(6)
+
+	// Blocking queries are never cached
+	list(c, &QueryOptions{WaitIndex: 5})
+	list(c, &QueryOptions{WaitIndex: 5})
+	expectGets(8)
+
+	// Neither are queries guarded against index regressions
+	monotonic := &QueryOptions{AllowStale: true, RequireMonotonicIndex: true}
+	list(c, monotonic)
+	list(c, monotonic)
+	expectGets(10)
+
+	// Writes purge the cache
+	if _, _, err := c.Jobs().ForceEvaluate("job1", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	list(c, nil)
+	expectGets(11)
+
+	// Responses to queries in flight during a write are not cached
+	gets = 0
+	c.cache.purge()
+	racingWrite = func() {
+		if _, _, err := c.Jobs().ForceEvaluate("job1", nil); err != nil {
+			t.Errorf("err: %v", err)
+		}
+	}
+	list(c, nil)
+	list(c, nil)
+	expectGets(2)
+
+	// Cached responses expire
+	gets = 0
+	c = newClient(10 * time.Millisecond)
+	list(c, nil)
+	time.Sleep(20 * time.Millisecond)
+	list(c, nil)
+	expectGets(2)
+}
+
+func TestRequest_SentToken(t *testing.T) {
+	conf := DefaultConfig()
+	conf.SecretID = "secret"
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r := c.newRequest("GET", "/v1/jobs")
+	if token := r.sentToken(); token != "secret" {
+		t.Fatalf("expected the configured token, got %q", token)
+	}
+
+	// Headers of the Config override the token and are overridden by those
+	// of the request
+	conf.Headers = http.Header{"X-Nomad-Token": []string{"config"}}
+	if c, err = NewClient(conf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r = c.newRequest("GET", "/v1/jobs")
+	if token := r.sentToken(); token != "config" {
+		t.Fatalf("expected the token of the config headers, got %q", token)
+	}
+	r.setQueryOptions(&QueryOptions{AuthToken: "query", Headers: map[string]string{"x-nomad-token": "header"}})
+	if token := r.sentToken(); token != "header" {
+		t.Fatalf("expected the token of the request headers, got %q", token)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"reflect"
+
+	"github.com/hashicorp/go-msgpack/codec"
+)
+
+const (
+	// MsgpackContentType is the media type of msgpack encoded responses.
+	MsgpackContentType = "application/msgpack"
+
+	// msgpackAccept is the Accept header sent when msgpack is preferred.
+	// JSON is still accepted from agents that only encode JSON.
+	msgpackAccept = MsgpackContentType + ", application/json;q=0.9"
+)
+
+// msgpackHandle decodes msgpack responses the way the servers encode them,
+// decoding maps into a nil interface{} as map[string]interface{}.
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{RawToString: true}
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}()
+
+// isMsgpack returns whether the content type is one of the media types used
+// for msgpack.
+func isMsgpack(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case MsgpackContentType, "application/x-msgpack", "application/vnd.msgpack":
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeReader decodes a body of the given content type into out. Bodies
+// that are not msgpack are decoded as JSON.
+func decodeReader(contentType string, r io.Reader, out interface{}) error {
+	if isMsgpack(contentType) {
+		return codec.NewDecoder(r, msgpackHandle).Decode(out)
+	}
+	return json.NewDecoder(r).Decode(out)
+}
+
+// decodeBytes decodes a buffered body of the given content type into out.
+func decodeBytes(contentType string, body []byte, out interface{}) error {
+	return decodeReader(contentType, bytes.NewReader(body), out)
+}
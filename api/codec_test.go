@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-msgpack/codec"
+)
+
+func testAllocStubs() []*AllocationListStub {
+	started := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []*AllocationListStub{
+		{
+			ID:           "alloc2",
+			JobID:        "job1",
+			TaskGroup:    "group1",
+			ClientStatus: "running",
+			TaskStates: map[string]*TaskState{
+				"task1": {
+					State:     "running",
+					Restarts:  2,
+					StartedAt: started,
+					Events:    []*TaskEvent{{Type: "Started", Time: started.UnixNano()}},
+				},
+			},
+			CreateIndex: 20,
+			ModifyIndex: 21,
+			CreateTime:  started.UnixNano(),
+		},
+		{
+			ID:          "alloc1",
+			JobID:       "job1",
+			CreateIndex: 10,
+			ModifyIndex: 10,
+		},
+	}
+}
+
+func TestDecode_RoundTrip(t *testing.T) {
+	in := testAllocStubs()
+
+	var jsonBuf, msgpackBuf bytes.Buffer
+	if err := json.NewEncoder(&jsonBuf).Encode(in); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := codec.NewEncoder(&msgpackBuf, msgpackHandle).Encode(in); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var fromJSON, fromMsgpack []*AllocationListStub
+	if err := decodeBytes("application/json", jsonBuf.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := decodeBytes(MsgpackContentType, msgpackBuf.Bytes(), &fromMsgpack); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, in) {
+		t.Fatalf("bad JSON round trip: %#v", fromJSON)
+	}
+	if !reflect.DeepEqual(fromMsgpack, fromJSON) {
+		t.Fatalf("msgpack decoded differently:\n%#v\n%#v", fromMsgpack, fromJSON)
+	}
+}
+
+func TestIsMsgpack(t *testing.T) {
+	for _, contentType := range []string{MsgpackContentType, "application/x-msgpack", "application/vnd.msgpack; charset=binary"} {
+		if !isMsgpack(contentType) {
+			t.Fatalf("expected %q to be msgpack", contentType)
+		}
+	}
+	for _, contentType := range []string{"", "application/json", "text/plain; charset=utf-8", "bogus;;"} {
+		if isMsgpack(contentType) {
+			t.Fatalf("expected %q not to be msgpack", contentType)
+		}
+	}
+}
+
+func TestPreferMsgpack(t *testing.T) {
+	// The agent encodes msgpack only when asked to, while the legacy agent
+	// always encodes JSON
+	legacy := false
+	var accepts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepts = append(accepts, r.Header.Get("Accept"))
+		w.Header().Set("X-Nomad-Index", "10")
+		if !legacy && r.Header.Get("Accept") == msgpackAccept {
+			w.Header().Set("Content-Type", MsgpackContentType)
+			codec.NewEncoder(w, msgpackHandle).Encode(testAllocStubs())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(testAllocStubs())
+	}))
+	defer srv.Close()
+
+	var results [][]*AllocationListStub
+	for _, preferMsgpack := range []bool{false, true} {
+		for _, legacy = range []bool{false, true} {
+			conf := DefaultConfig()
+			conf.Address = srv.URL
+			conf.PreferMsgpack = preferMsgpack
+			c, err := NewClient(conf)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			allocs, _, err := c.Allocations().List(nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			results = append(results, allocs)
+		}
+	}
+
+	if !reflect.DeepEqual(accepts, []string{"", "", msgpackAccept, msgpackAccept}) {
+		t.Fatalf("bad Accept headers: %q", accepts)
+	}
+	for i, allocs := range results {
+		if !reflect.DeepEqual(allocs, results[0]) {
+			t.Fatalf("result %d decoded differently: %#v", i, allocs)
+		}
+	}
+	if len(results[0]) != 2 || results[0][0].TaskStates["task1"].Restarts != 2 {
+		t.Fatalf("bad allocations: %#v", results[0])
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+)
+
+// endpointVersion is the version of Nomad whose agents first served an
+// endpoint. Older agents route requests to the endpoint to other handlers or
+// reject them with unhelpful errors, so the requests are gated on the
+// version of the agent.
+type endpointVersion struct {
+	// method is the method of the requests gated, or empty for every
+	// method.
+	method string
+
+	// prefix and suffix match the path of the endpoint.
+	prefix string
+	suffix string
+
+	version string
+}
+
+// endpointVersions are the endpoints of the API that agents of the version
+// of Nomad the package is released with don't serve. More specific entries
+// come first.
+var endpointVersions = []endpointVersion{
+	{method: "PUT", prefix: "/v1/job/", suffix: "/dispatch", version: "0.5.3"},
+	{method: "GET", prefix: "/v1/job/", suffix: "/submission", version: "1.6.0"},
+	{method: "GET", prefix: "/v1/job/", suffix: "/deployments", version: "0.6.0"},
+	{method: "PUT", prefix: "/v1/job/", suffix: "/revert", version: "0.6.0"},
+	{prefix: "/v1/job/", suffix: "/scale", version: "0.11.0"},
+	{prefix: "/v1/acl/role", version: "1.4.0"},
+	{prefix: "/v1/acl/", version: "0.7.0"},
+	{prefix: "/v1/agent/monitor", version: "0.10.2"},
+	{prefix: "/v1/agent/pprof/", version: "0.10.2"},
+	{prefix: "/v1/deployment", version: "0.6.0"},
+	{prefix: "/v1/event/stream", version: "1.0.0"},
+	{method: "DELETE", prefix: "/v1/evaluations", version: "1.3.2"},
+	{prefix: "/v1/operator/", version: "0.5.5"},
+	{prefix: "/v1/plugin", version: "0.11.0"},
+	{prefix: "/v1/recommendation", version: "1.0.0"},
+	{prefix: "/v1/scaling/", version: "0.11.0"},
+	{prefix: "/v1/service", version: "1.3.0"},
+	{prefix: "/v1/var", version: "1.4.0"},
+	{prefix: "/v1/volume", version: "0.11.0"},
+}
+
+// minVersion returns the version of Nomad that first served the endpoint of
+// a request, or nil if every agent serves it.
+func minVersion(method, path string) *version.Version {
+	for _, e := range endpointVersions {
+		if e.method != "" && e.method != method {
+			continue
+		}
+		if !strings.HasPrefix(path, e.prefix) || !strings.HasSuffix(path[len(e.prefix):], e.suffix) {
+			continue
+		}
+		return version.Must(version.NewVersion(e.version))
+	}
+	return nil
+}
+
+// EndpointUnsupportedError is returned by requests to endpoints that the
+// agent, going by its version, doesn't serve. The request is not sent.
+type EndpointUnsupportedError struct {
+	Method   string
+	Endpoint string
+
+	// AgentVersion is the version of the agent and MinVersion the first
+	// version serving the endpoint.
+	AgentVersion string
+	MinVersion   string
+}
+
+func (e *EndpointUnsupportedError) Error() string {
+	return fmt.Sprintf("%s %s requires Nomad %s or later but the agent runs %s",
+		e.Method, e.Endpoint, e.MinVersion, e.AgentVersion)
+}
+
+// checkEndpoint returns an EndpointUnsupportedError if the agent predates the
+// endpoint of the request. The version of the agent is read the first time
+// a gated endpoint is requested. If it can't be read, such as when the token
+// of the client may not read the agent, every request is let through.
+func (c *Client) checkEndpoint(r *request) error {
+	min := minVersion(r.method, r.url.Path)
+	if min == nil {
+		return nil
+	}
+	c.agentVersionOnce.Do(func() {
+		c.agentVersion = c.readAgentVersion()
+	})
+	if c.agentVersion == nil || !c.agentVersion.LessThan(min) {
+		return nil
+	}
+	return &EndpointUnsupportedError{
+		Method:       r.method,
+		Endpoint:     r.url.Path,
+		AgentVersion: c.agentVersion.String(),
+		MinVersion:   min.String(),
+	}
+}
+
+// readAgentVersion returns the version of the agent, or nil if it can't be
+// read.
+func (c *Client) readAgentVersion() *version.Version {
+	self, err := c.Agent().Self()
+	if err != nil {
+		return nil
+	}
+
+	// Agents report their version as a string, or as a structure in later
+	// versions of Nomad.
+	var raw string
+	switch v := self["config"]["Version"].(type) {
+	case string:
+		raw = v
+	case map[string]interface{}:
+		raw, _ = v["Version"].(string)
+	}
+	v, err := version.NewVersion(raw)
+	if err != nil {
+		return nil
+	}
+	return v
+}
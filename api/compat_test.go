@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMinVersion(t *testing.T) {
+	cases := []struct {
+		method, path string
+		expect       string
+	}{
+		{"PUT", "/v1/job/job1/dispatch", "0.5.3"},
+		{"GET", "/v1/job/job1/dispatch", ""},
+		{"PUT", "/v1/job/job1", ""},
+		{"GET", "/v1/job/job1/submission", "1.6.0"},
+		{"GET", "/v1/job/job1/deployments", "0.6.0"},
+		{"GET", "/v1/deployments", "0.6.0"},
+		{"PUT", "/v1/job/job1/revert", "0.6.0"},
+		{"GET", "/v1/job/job1/scale", "0.11.0"},
+		{"PUT", "/v1/job/job1/scale", "0.11.0"},
+		{"PUT", "/v1/deployment/allocation-health/deployment1", "0.6.0"},
+		{"GET", "/v1/acl/roles", "1.4.0"},
+		{"GET", "/v1/acl/token/accessor1", "0.7.0"},
+		{"GET", "/v1/vars", "1.4.0"},
+		{"DELETE", "/v1/evaluations", "1.3.2"},
+		{"GET", "/v1/evaluations", ""},
+		{"GET", "/v1/agent/self", ""},
+	}
+	for _, c := range cases {
+		min := minVersion(c.method, c.path)
+		switch {
+		case c.expect == "" && min != nil:
+			t.Fatalf("%s %s: expected no gate, got %s", c.method, c.path, min)
+		case c.expect != "" && (min == nil || min.String() != c.expect):
+			t.Fatalf("%s %s: expected %s, got %v", c.method, c.path, c.expect, min)
+		}
+	}
+
+	// Every entry parses
+	for _, e := range endpointVersions {
+		if minVersion(e.method, e.prefix+"x"+e.suffix) == nil {
+			t.Fatalf("entry %+v doesn't match itself", e)
+		}
+	}
+}
+
+func TestClient_CheckEndpoint(t *testing.T) {
+	var agentVersion string
+	var selfReads int
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/agent/self" {
+			selfReads++
+			if agentVersion == "" {
+				http.Error(w, "Permission denied", http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, `{"config": {"Version": %s}}`, agentVersion)
+			return
+		}
+		sent = append(sent, r.Method+" "+r.URL.Path)
+		w.Header().Set("X-Nomad-Index", "10")
+		fmt.Fprint(w, `{"EvalID": "eval1"}`)
+	}))
+	defer srv.Close()
+
+	newClient := func(version string) *Client {
+		agentVersion, selfReads, sent = version, 0, nil
+		conf := DefaultConfig()
+		conf.Address = srv.URL
+		c, err := NewClient(conf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return c
+	}
+
+	// Agents that predate an endpoint aren't sent requests to it
+	c := newClient(`"0.5.0"`)
+	for i := 0; i < 2; i++ {
+		_, _, err := c.Jobs().Dispatch("job1", nil, nil, nil)
+		uErr, ok := err.(*EndpointUnsupportedError)
+		if !ok {
+			t.Fatalf("expected unsupported endpoint, got: %v", err)
+		}
+		if uErr.Method != "PUT" || uErr.Endpoint != "/v1/job/job1/dispatch" ||
+			uErr.AgentVersion != "0.5.0" || uErr.MinVersion != "0.5.3" {
+			t.Fatalf("bad error: %#v", uErr)
+		}
+	}
+	if _, _, err := c.Jobs().ForceEvaluate("job1", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if selfReads != 1 || len(sent) != 1 || sent[0] != "PUT /v1/job/job1/evaluate" {
+		t.Fatalf("bad requests: %d reads of the agent, sent %v", selfReads, sent)
+	}
+
+	// Later agents report their version as a structure
+	c = newClient(`{"Version": "0.6.0", "VersionPrerelease": ""}`)
+	if _, _, err := c.Jobs().Dispatch("job1", nil, nil, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(sent) != 1 || sent[0] != "PUT /v1/job/job1/dispatch" {
+		t.Fatalf("bad requests: %v", sent)
+	}
+
+	// Requests are let through if the version can't be read
+	c = newClient("")
+	if _, _, err := c.Jobs().Dispatch("job1", nil, nil, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if selfReads != 1 || len(sent) != 1 {
+		t.Fatalf("bad requests: %d reads of the agent, sent %v", selfReads, sent)
+	}
+}
@@ -61,7 +61,7 @@ func TestCompose(t *testing.T) {
 		TaskGroups: []*TaskGroup{
 			&TaskGroup{
 				Name:  "grp1",
-				Count: 2,
+				Count: intToPtr(2),
 				Constraints: []*Constraint{
 					&Constraint{
 						LTarget: "kernel.name",
@@ -1,5 +1,29 @@
 package api
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	// ConstraintRegex is the operand of constraints matching the target
+	// against a regular expression.
+	ConstraintRegex = "regexp"
+
+	// ConstraintVersion is the operand of constraints matching a version
+	// target against a version constraint, such as ">= 1.2, < 2".
+	ConstraintVersion = "version"
+
+	// ConstraintDistinctHosts is the operand of constraints requiring the
+	// allocations of a job or group to be placed on distinct nodes.
+	ConstraintDistinctHosts = "distinct_hosts"
+)
+
 // Constraint is used to serialize a job placement constraint.
 type Constraint struct {
 	LTarget string
@@ -15,3 +39,164 @@ func NewConstraint(left, operand, right string) *Constraint {
 		Operand: operand,
 	}
 }
+
+// NodeIDConstraint generates a constraint pinning placements to the node with
+// the given ID.
+func NodeIDConstraint(nodeID string) *Constraint {
+	return NewConstraint("${node.unique.id}", "=", nodeID)
+}
+
+// Equal returns whether the constraints are structurally identical.
+func (c *Constraint) Equal(o *Constraint) bool {
+	if c == nil || o == nil {
+		return c == o
+	}
+	return c.LTarget == o.LTarget && c.RTarget == o.RTarget && c.Operand == o.Operand
+}
+
+// addConstraint appends the constraint unless an identical constraint is
+// already present.
+func addConstraint(constraints []*Constraint, c *Constraint) []*Constraint {
+	for _, existing := range constraints {
+		if existing.Equal(c) {
+			return constraints
+		}
+	}
+	return append(constraints, c)
+}
+
+// Validate is used to sanity check a constraint.
+func (c *Constraint) Validate() error {
+	var mErr multierror.Error
+	if c.Operand == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing constraint operand"))
+	}
+
+	// Perform additional validation based on operand
+	switch c.Operand {
+	case ConstraintRegex:
+		if _, err := regexp.Compile(c.RTarget); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Regular expression failed to compile: %v", err))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// constraintWarnings returns the likely mistakes in a set of constraints
+// that don't make them invalid: unknown operands, targets that don't
+// interpolate anything, duplicates and equality constraints on the same
+// target that can never all be satisfied.
+func constraintWarnings(constraints []*Constraint) []string {
+	var warnings []string
+	equals := make(map[string]string)
+	for idx, c := range constraints {
+		if c == nil {
+			continue
+		}
+		for _, prev := range constraints[:idx] {
+			if prev.Equal(c) {
+				warnings = append(warnings, fmt.Sprintf("Constraint %d is a duplicate", idx+1))
+				break
+			}
+		}
+
+		switch c.Operand {
+		case ConstraintDistinctHosts:
+			continue
+		case "=", "==", "is":
+			if prev, ok := equals[c.LTarget]; ok && prev != c.RTarget {
+				warnings = append(warnings, fmt.Sprintf("Constraint %d requires %s to be both %q and %q, which no node satisfies",
+					idx+1, c.LTarget, prev, c.RTarget))
+			} else {
+				equals[c.LTarget] = c.RTarget
+			}
+		case "!=", "not", "<", "<=", ">", ">=", ConstraintVersion, ConstraintRegex:
+		default:
+			warnings = append(warnings, fmt.Sprintf("Constraint %d has unknown operand %q, which no node satisfies", idx+1, c.Operand))
+		}
+
+		if !strings.Contains(c.LTarget, "${") && !strings.Contains(c.RTarget, "${") {
+			warnings = append(warnings, fmt.Sprintf("Constraint %d compares %q to %q without interpolating a node attribute, did you mean ${attr.%s}?",
+				idx+1, c.LTarget, c.RTarget, c.LTarget))
+		}
+	}
+	return warnings
+}
+
+// matchesNode returns whether the node satisfies the constraint, evaluated
+// the way the scheduler does. Constraints on how allocations are spread over
+// nodes, such as distinct_hosts, are satisfied by any node.
+func (c *Constraint) matchesNode(node *Node) bool {
+	if c.Operand == ConstraintDistinctHosts {
+		return true
+	}
+
+	lVal, ok := resolveConstraintTarget(c.LTarget, node)
+	if !ok {
+		return false
+	}
+	rVal, ok := resolveConstraintTarget(c.RTarget, node)
+	if !ok {
+		return false
+	}
+
+	switch c.Operand {
+	case "=", "==", "is":
+		return lVal == rVal
+	case "!=", "not":
+		return lVal != rVal
+	case "<":
+		return lVal < rVal
+	case "<=":
+		return lVal <= rVal
+	case ">":
+		return lVal > rVal
+	case ">=":
+		return lVal >= rVal
+	case ConstraintVersion:
+		vers, err := version.NewVersion(lVal)
+		if err != nil {
+			return false
+		}
+		constraints, err := version.NewConstraint(rVal)
+		if err != nil {
+			return false
+		}
+		return constraints.Check(vers)
+	case ConstraintRegex:
+		re, err := regexp.Compile(rVal)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(lVal)
+	default:
+		return false
+	}
+}
+
+// resolveConstraintTarget resolves the interpolated node attribute a target
+// of a constraint refers to. Targets without interpolation are literals.
+func resolveConstraintTarget(target string, node *Node) (string, bool) {
+	if !strings.HasPrefix(target, "${") {
+		return target, true
+	}
+
+	switch {
+	case target == "${node.unique.id}":
+		return node.ID, true
+	case target == "${node.datacenter}":
+		return node.Datacenter, true
+	case target == "${node.unique.name}":
+		return node.Name, true
+	case target == "${node.class}":
+		return node.NodeClass, true
+	case strings.HasPrefix(target, "${attr."):
+		val, ok := node.Attributes[strings.TrimSuffix(strings.TrimPrefix(target, "${attr."), "}")]
+		return val, ok
+	case strings.HasPrefix(target, "${meta."):
+		val, ok := node.Meta[strings.TrimSuffix(strings.TrimPrefix(target, "${meta."), "}")]
+		return val, ok
+	default:
+		return "", false
+	}
+}
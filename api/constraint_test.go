@@ -16,3 +16,94 @@ func TestCompose_Constraints(t *testing.T) {
 		t.Fatalf("expect: %#v, got: %#v", expect, c)
 	}
 }
+
+func TestConstraint_Validate(t *testing.T) {
+	if err := NewConstraint("${attr.kernel.name}", "=", "linux").Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := (&Constraint{LTarget: "${attr.kernel.name}"}).Validate(); err == nil {
+		t.Fatalf("expected missing operand error")
+	}
+	if err := NewConstraint("${attr.kernel.name}", ConstraintRegex, "(linux").Validate(); err == nil {
+		t.Fatalf("expected regexp error")
+	}
+}
+
+func TestConstraint_Dedupe(t *testing.T) {
+	job := NewBatchJob("job1", "myjob", "region1", 1).
+		Constrain(NewConstraint("kernel.name", "=", "linux")).
+		Constrain(NewConstraint("kernel.name", "=", "linux"))
+	grp := NewTaskGroup("grp1", 1).
+		Constrain(NewConstraint("kernel.name", "=", "linux")).
+		Constrain(NewConstraint("kernel.name", "=", "linux"))
+	task := NewTask("task1", "exec").
+		Constrain(NewConstraint("kernel.name", "=", "linux")).
+		Constrain(NewConstraint("kernel.name", "=", "linux"))
+
+	if n := len(job.Constraints); n != 1 {
+		t.Fatalf("expected 1 job constraint, got: %d", n)
+	}
+	if n := len(grp.Constraints); n != 1 {
+		t.Fatalf("expected 1 group constraint, got: %d", n)
+	}
+	if n := len(task.Constraints); n != 1 {
+		t.Fatalf("expected 1 task constraint, got: %d", n)
+	}
+
+	// Constraints differing in any part are kept
+	job.Constrain(NewConstraint("kernel.name", "!=", "linux"))
+	if n := len(job.Constraints); n != 2 {
+		t.Fatalf("expected 2 job constraints, got: %d", n)
+	}
+}
+
+func TestConstraint_MatchesNode(t *testing.T) {
+	node := &Node{
+		ID:         "node1",
+		Datacenter: "dc1",
+		Name:       "worker1",
+		NodeClass:  "large",
+		Attributes: map[string]string{
+			"kernel.name":     "linux",
+			"driver.docker":   "1",
+			"nomad.version":   "0.6.0",
+			"unique.hostname": "worker1.example.com",
+		},
+		Meta: map[string]string{"rack": "r1"},
+	}
+
+	cases := []struct {
+		constraint *Constraint
+		match      bool
+	}{
+		{NewConstraint("${attr.kernel.name}", "=", "linux"), true},
+		{NewConstraint("${attr.kernel.name}", "!=", "linux"), false},
+		{NewConstraint("${meta.rack}", "==", "r1"), true},
+		{NewConstraint("${node.datacenter}", "is", "dc2"), false},
+		{NewConstraint("${node.class}", "not", "small"), true},
+		{NewConstraint("${node.unique.id}", "=", "node1"), true},
+		{NewConstraint("${node.unique.name}", ">=", "worker0"), true},
+		{NewConstraint("${attr.nomad.version}", ConstraintVersion, ">= 0.5, < 1.0"), true},
+		{NewConstraint("${attr.nomad.version}", ConstraintVersion, ">= 0.7"), false},
+		{NewConstraint("${attr.unique.hostname}", ConstraintRegex, `\.example\.com$`), true},
+		{NewConstraint("${attr.driver.rkt}", "=", "1"), false},
+		{NewConstraint("${node.unknown}", "=", "1"), false},
+		{&Constraint{Operand: ConstraintDistinctHosts}, true},
+		{NewConstraint("${attr.kernel.name}", "set_contains_any", "linux"), false},
+	}
+	for _, tc := range cases {
+		if match := tc.constraint.matchesNode(node); match != tc.match {
+			t.Fatalf("constraint %#v: expected match %t", tc.constraint, tc.match)
+		}
+	}
+}
+
+func TestNodeIDConstraint(t *testing.T) {
+	c := NodeIDConstraint("node1")
+	if c.LTarget != "${node.unique.id}" || c.Operand != "=" || c.RTarget != "node1" {
+		t.Fatalf("bad constraint: %#v", c)
+	}
+	if !c.matchesNode(&Node{ID: "node1"}) || c.matchesNode(&Node{ID: "node2"}) {
+		t.Fatalf("expected only node1 to match")
+	}
+}
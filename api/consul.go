@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// ConsulConnect configures the Consul Connect service mesh integration of a
+// service. A service either integrates with Connect natively or is fronted
+// by an injected sidecar proxy.
+type ConsulConnect struct {
+	// Native marks the service as integrating with Connect itself.
+	Native bool
+
+	// SidecarService, if set, injects a sidecar proxy for the service.
+	SidecarService *ConsulSidecarService `mapstructure:"sidecar_service"`
+}
+
+// Validate is used to sanity check the Connect configuration of a service.
+func (c *ConsulConnect) Validate() error {
+	var mErr multierror.Error
+	if c.Native && c.SidecarService != nil {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Connect native services cannot have a sidecar service"))
+	}
+	if c.SidecarService != nil {
+		if err := c.SidecarService.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Sidecar service validation failed: %s", err))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// ConsulSidecarService is the sidecar proxy injected for a Connect service.
+type ConsulSidecarService struct {
+	// Port is the label of the port the sidecar proxy listens on. If unset,
+	// a dynamic port is used.
+	Port string
+
+	// Proxy configures the sidecar proxy.
+	Proxy *ConsulProxy
+}
+
+// Validate is used to sanity check a sidecar service.
+func (s *ConsulSidecarService) Validate() error {
+	if s.Proxy == nil {
+		return nil
+	}
+	return s.Proxy.Validate()
+}
+
+// ConsulProxy configures a sidecar proxy.
+type ConsulProxy struct {
+	// Upstreams are the services the proxy exposes to the task on local
+	// ports.
+	Upstreams []*ConsulUpstream
+}
+
+// Validate is used to sanity check a sidecar proxy. Every upstream must
+// bind its own local port.
+func (p *ConsulProxy) Validate() error {
+	var mErr multierror.Error
+	bound := make(map[int]string, len(p.Upstreams))
+	for idx, upstream := range p.Upstreams {
+		if upstream == nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Upstream %d must not be empty", idx+1))
+			continue
+		}
+		if err := upstream.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Upstream %d validation failed: %s", idx+1, err))
+			continue
+		}
+		if other, ok := bound[upstream.LocalBindPort]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Upstreams %q and %q both bind local port %d",
+				other, upstream.DestinationName, upstream.LocalBindPort))
+			continue
+		}
+		bound[upstream.LocalBindPort] = upstream.DestinationName
+	}
+	return mErr.ErrorOrNil()
+}
+
+// ConsulUpstream is a Connect service exposed to a task on a local port.
+type ConsulUpstream struct {
+	// DestinationName is the name of the upstream service.
+	DestinationName string `mapstructure:"destination_name"`
+
+	// LocalBindPort is the local port the upstream is exposed on.
+	LocalBindPort int `mapstructure:"local_bind_port"`
+}
+
+// Validate is used to sanity check an upstream.
+func (u *ConsulUpstream) Validate() error {
+	var mErr multierror.Error
+	if u.DestinationName == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing destination name"))
+	}
+	if u.LocalBindPort <= 0 || u.LocalBindPort > 65535 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Local bind port must be between 1 and 65535 but found %d", u.LocalBindPort))
+	}
+	return mErr.ErrorOrNil()
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConsulConnect_Validate(t *testing.T) {
+	connect := &ConsulConnect{
+		SidecarService: &ConsulSidecarService{
+			Port: "proxy",
+			Proxy: &ConsulProxy{
+				Upstreams: []*ConsulUpstream{
+					{DestinationName: "db", LocalBindPort: 5432},
+					{DestinationName: "cache", LocalBindPort: 6379},
+				},
+			},
+		},
+	}
+	if err := connect.Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := (&ConsulConnect{Native: true}).Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Upstreams can't bind the same local port
+	upstreams := &connect.SidecarService.Proxy.Upstreams
+	*upstreams = append(*upstreams, &ConsulUpstream{DestinationName: "replica", LocalBindPort: 5432})
+	err := connect.Validate()
+	if err == nil || !strings.Contains(err.Error(), `Upstreams "db" and "replica" both bind local port 5432`) {
+		t.Fatalf("expected bind port collision, got: %v", err)
+	}
+	*upstreams = (*upstreams)[:2]
+
+	(*upstreams)[1].LocalBindPort = 0
+	err = connect.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Upstream 2 validation failed") {
+		t.Fatalf("expected upstream error, got: %v", err)
+	}
+	(*upstreams)[1].LocalBindPort = 6379
+
+	// Native services don't have sidecars
+	connect.Native = true
+	if err := connect.Validate(); err == nil || !strings.Contains(err.Error(), "cannot have a sidecar") {
+		t.Fatalf("expected native error, got: %v", err)
+	}
+
+	// Connect is validated as part of the service
+	service := &Service{Name: "web", Connect: connect}
+	if err := service.Validate(); err == nil || !strings.Contains(err.Error(), "Connect validation failed") {
+		t.Fatalf("expected connect error, got: %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// CSIVolumeAccessModeSingleNodeReader, CSIVolumeAccessModeSingleNodeWriter,
+	// CSIVolumeAccessModeMultiNodeReader, CSIVolumeAccessModeMultiNodeSingleWriter
+	// and CSIVolumeAccessModeMultiNodeMultiWriter are the access modes of CSI
+	// volumes, bounding how many nodes may mount a volume and write to it.
+	CSIVolumeAccessModeSingleNodeReader      = "single-node-reader-only"
+	CSIVolumeAccessModeSingleNodeWriter      = "single-node-writer"
+	CSIVolumeAccessModeMultiNodeReader       = "multi-node-reader-only"
+	CSIVolumeAccessModeMultiNodeSingleWriter = "multi-node-single-writer"
+	CSIVolumeAccessModeMultiNodeMultiWriter  = "multi-node-multi-writer"
+
+	// CSIVolumeAttachmentModeFilesystem and CSIVolumeAttachmentModeBlockDevice
+	// are the ways a CSI volume is attached to the tasks mounting it.
+	CSIVolumeAttachmentModeFilesystem  = "file-system"
+	CSIVolumeAttachmentModeBlockDevice = "block-device"
+)
+
+// CSIVolumes is used to query the CSI volume endpoints.
+type CSIVolumes struct {
+	client *Client
+}
+
+// CSIVolumes returns a new handle on the CSI volumes.
+func (c *Client) CSIVolumes() *CSIVolumes {
+	return &CSIVolumes{client: c}
+}
+
+// List is used to list all of the CSI volumes.
+func (v *CSIVolumes) List(q *QueryOptions) ([]*CSIVolumeListStub, *QueryMeta, error) {
+	var resp []*CSIVolumeListStub
+	qm, err := v.client.query("/v1/volumes?type=csi", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(CSIVolumeIndexSort(resp))
+	return resp, qm, nil
+}
+
+// Info is used to query a single CSI volume by its ID.
+func (v *CSIVolumes) Info(id string, q *QueryOptions) (*CSIVolume, *QueryMeta, error) {
+	if id == "" {
+		return nil, nil, fmt.Errorf("missing volume ID")
+	}
+	var resp CSIVolume
+	qm, err := v.client.query("/v1/volume/csi/"+id, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Register is used to register an existing CSI volume with Nomad so jobs
+// can claim it. The volume itself must already exist in the storage
+// provider of its plugin.
+func (v *CSIVolumes) Register(vol *CSIVolume, q *WriteOptions) (*WriteMeta, error) {
+	if err := vol.Validate(); err != nil {
+		return nil, err
+	}
+	if v.client.config.DryRun {
+		return &WriteMeta{DryRun: &DryRunResult{Operation: fmt.Sprintf("register CSI volume %q", vol.ID), Validated: true}}, nil
+	}
+	req := &CSIVolumeRegisterRequest{Volumes: []*CSIVolume{vol}}
+	wm, err := v.client.write("/v1/volume/csi/"+vol.ID, req, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Deregister is used to remove a CSI volume from Nomad. The volume is left
+// untouched in the storage provider of its plugin.
+func (v *CSIVolumes) Deregister(id string, q *WriteOptions) (*WriteMeta, error) {
+	if id == "" {
+		return nil, fmt.Errorf("missing volume ID")
+	}
+	wm, err := v.client.delete("/v1/volume/csi/"+id, nil, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// CSIPlugins is used to query the CSI plugin endpoints.
+type CSIPlugins struct {
+	client *Client
+}
+
+// CSIPlugins returns a new handle on the CSI plugins.
+func (c *Client) CSIPlugins() *CSIPlugins {
+	return &CSIPlugins{client: c}
+}
+
+// List is used to list all of the CSI plugins.
+func (p *CSIPlugins) List(q *QueryOptions) ([]*CSIPluginListStub, *QueryMeta, error) {
+	var resp []*CSIPluginListStub
+	qm, err := p.client.query("/v1/plugins?type=csi", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(CSIPluginIDSort(resp))
+	return resp, qm, nil
+}
+
+// Info is used to query a single CSI plugin by its ID.
+func (p *CSIPlugins) Info(id string, q *QueryOptions) (*CSIPlugin, *QueryMeta, error) {
+	if id == "" {
+		return nil, nil, fmt.Errorf("missing plugin ID")
+	}
+	var resp CSIPlugin
+	qm, err := p.client.query("/v1/plugin/csi/"+id, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// CSIVolume is a volume provided by a CSI plugin.
+type CSIVolume struct {
+	ID        string
+	Name      string
+	Namespace string `json:",omitempty"`
+
+	// ExternalID is the ID of the volume in the storage provider.
+	ExternalID string `json:",omitempty"`
+
+	PluginID       string
+	AccessMode     string
+	AttachmentMode string
+
+	// Healthy is set by the servers if the plugin of the volume has
+	// healthy controllers and nodes, so the volume can be claimed.
+	Healthy bool
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Validate is used to sanity check a CSI volume before it is registered.
+func (v *CSIVolume) Validate() error {
+	var mErr multierror.Error
+	if v.ID == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing volume ID"))
+	}
+	if v.PluginID == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing plugin ID"))
+	}
+	if err := validateCSIModes(v.AccessMode, v.AttachmentMode); err != nil {
+		mErr.Errors = append(mErr.Errors, err.(*multierror.Error).Errors...)
+	}
+	return mErr.ErrorOrNil()
+}
+
+// validateCSIModes checks the access and attachment modes of a CSI volume.
+func validateCSIModes(accessMode, attachmentMode string) error {
+	var mErr multierror.Error
+	switch accessMode {
+	case CSIVolumeAccessModeSingleNodeReader, CSIVolumeAccessModeSingleNodeWriter,
+		CSIVolumeAccessModeMultiNodeReader, CSIVolumeAccessModeMultiNodeSingleWriter,
+		CSIVolumeAccessModeMultiNodeMultiWriter:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid access mode %q", accessMode))
+	}
+	switch attachmentMode {
+	case CSIVolumeAttachmentModeFilesystem, CSIVolumeAttachmentModeBlockDevice:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid attachment mode %q", attachmentMode))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CSIVolumeRegisterRequest is used to register CSI volumes.
+type CSIVolumeRegisterRequest struct {
+	Volumes []*CSIVolume
+}
+
+// CSIVolumeListStub is used to return a subset of a CSI volume during list
+// operations.
+type CSIVolumeListStub struct {
+	ID             string
+	Name           string
+	Namespace      string `json:",omitempty"`
+	PluginID       string
+	AccessMode     string
+	AttachmentMode string
+	Healthy        bool
+	CreateIndex    uint64
+	ModifyIndex    uint64
+}
+
+// CSIVolumeIndexSort reverse sorts CSI volumes by CreateIndex.
+type CSIVolumeIndexSort []*CSIVolumeListStub
+
+func (v CSIVolumeIndexSort) Len() int {
+	return len(v)
+}
+
+func (v CSIVolumeIndexSort) Less(i, j int) bool {
+	return v[i].CreateIndex > v[j].CreateIndex
+}
+
+func (v CSIVolumeIndexSort) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// CSIPlugin is a CSI plugin run by the tasks of jobs, either as a
+// controller, as a node plugin on each node or both.
+type CSIPlugin struct {
+	ID       string
+	Provider string
+	Version  string
+
+	// ControllerRequired is set if the plugin needs controllers to attach
+	// its volumes.
+	ControllerRequired bool
+
+	ControllersHealthy  int
+	ControllersExpected int
+	NodesHealthy        int
+	NodesExpected       int
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// CSIPluginListStub is used to return a subset of a CSI plugin during list
+// operations.
+type CSIPluginListStub struct {
+	ID                  string
+	Provider            string
+	ControllerRequired  bool
+	ControllersHealthy  int
+	ControllersExpected int
+	NodesHealthy        int
+	NodesExpected       int
+	CreateIndex         uint64
+	ModifyIndex         uint64
+}
+
+// CSIPluginIDSort is used to sort CSI plugins by their IDs.
+type CSIPluginIDSort []*CSIPluginListStub
+
+func (p CSIPluginIDSort) Len() int {
+	return len(p)
+}
+
+func (p CSIPluginIDSort) Less(i, j int) bool {
+	return p[i].ID < p[j].ID
+}
+
+func (p CSIPluginIDSort) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+func testCSIVolume() *CSIVolume {
+	return &CSIVolume{
+		ID:             "db",
+		Name:           "database",
+		ExternalID:     "vol-0123",
+		PluginID:       "ebs",
+		AccessMode:     CSIVolumeAccessModeSingleNodeWriter,
+		AttachmentMode: CSIVolumeAttachmentModeFilesystem,
+	}
+}
+
+func TestCSIVolume_Validate(t *testing.T) {
+	if err := testCSIVolume().Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	vol := testCSIVolume()
+	vol.AccessMode = "read-write-many"
+	if err := vol.Validate(); err == nil || !strings.Contains(err.Error(), "access mode") {
+		t.Fatalf("expected access mode error, got: %v", err)
+	}
+
+	err := (&CSIVolume{}).Validate()
+	if mErr, ok := err.(*multierror.Error); !ok || len(mErr.Errors) != 4 {
+		t.Fatalf("expected 4 errors, got: %v", err)
+	}
+}
+
+func TestCSIVolumes(t *testing.T) {
+	stored := make(map[string]*CSIVolume)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		enc := json.NewEncoder(w)
+		id := strings.TrimPrefix(r.URL.Path, "/v1/volume/csi/")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/volumes":
+			if r.URL.Query().Get("type") != "csi" {
+				t.Fatalf("bad volume type: %v", r.URL.Query())
+			}
+			out := []*CSIVolumeListStub{}
+			for _, vol := range stored {
+				out = append(out, &CSIVolumeListStub{ID: vol.ID, PluginID: vol.PluginID, CreateIndex: vol.CreateIndex})
+			}
+			enc.Encode(out)
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/v1/volume/csi/"):
+			var req CSIVolumeRegisterRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			for _, vol := range req.Volumes {
+				vol.CreateIndex = uint64(len(stored) + 1)
+				vol.Healthy = true
+				stored[vol.ID] = vol
+			}
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/volume/csi/"):
+			vol, ok := stored[id]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			enc.Encode(vol)
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/v1/volume/csi/"):
+			delete(stored, id)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	volumes := c.CSIVolumes()
+
+	// Invalid volumes are rejected before being submitted
+	if _, err := volumes.Register(&CSIVolume{ID: "bad"}, nil); err == nil {
+		t.Fatalf("expected validation error")
+	}
+
+	for _, id := range []string{"db", "cache"} {
+		vol := testCSIVolume()
+		vol.ID = id
+		wm, err := volumes.Register(vol, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		assertWriteMeta(t, wm)
+	}
+
+	// Volumes are listed newest first
+	list, qm, err := volumes.List(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(list) != 2 || list[0].ID != "cache" || list[1].ID != "db" {
+		t.Fatalf("bad volumes: %#v", list)
+	}
+
+	vol, _, err := volumes.Info("db", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if vol.PluginID != "ebs" || vol.AccessMode != CSIVolumeAccessModeSingleNodeWriter || !vol.Healthy {
+		t.Fatalf("bad volume: %#v", vol)
+	}
+
+	if _, err := volumes.Deregister("db", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := volumes.Info("db", nil); err == nil {
+		t.Fatalf("expected deregistered volume to be gone")
+	}
+	if _, err := volumes.Deregister("", nil); err == nil {
+		t.Fatalf("expected missing ID error")
+	}
+}
+
+func TestCSIPlugins(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		enc := json.NewEncoder(w)
+		switch {
+		case r.URL.Path == "/v1/plugins" && r.URL.Query().Get("type") == "csi":
+			enc.Encode([]*CSIPluginListStub{{ID: "gce-pd"}, {ID: "ebs"}})
+		case r.URL.Path == "/v1/plugin/csi/ebs":
+			enc.Encode(&CSIPlugin{ID: "ebs", Provider: "aws.ebs", ControllerRequired: true, NodesHealthy: 3})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	list, qm, err := c.CSIPlugins().List(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(list) != 2 || list[0].ID != "ebs" || list[1].ID != "gce-pd" {
+		t.Fatalf("bad plugins: %#v", list)
+	}
+
+	plugin, _, err := c.CSIPlugins().Info("ebs", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if plugin.Provider != "aws.ebs" || !plugin.ControllerRequired || plugin.NodesHealthy != 3 {
+		t.Fatalf("bad plugin: %#v", plugin)
+	}
+	if _, _, err := c.CSIPlugins().Info("", nil); err == nil {
+		t.Fatalf("expected missing ID error")
+	}
+}
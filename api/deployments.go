@@ -0,0 +1,166 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// DeploymentStatusRunning, DeploymentStatusPaused,
+	// DeploymentStatusFailed, DeploymentStatusSuccessful and
+	// DeploymentStatusCancelled are the statuses of deployments.
+	DeploymentStatusRunning    = "running"
+	DeploymentStatusPaused     = "paused"
+	DeploymentStatusFailed     = "failed"
+	DeploymentStatusSuccessful = "successful"
+	DeploymentStatusCancelled  = "cancelled"
+)
+
+// Deployments is used to query the deployment endpoints. Agents that predate
+// deployments aren't sent the requests, which fail with an
+// EndpointUnsupportedError.
+type Deployments struct {
+	client *Client
+}
+
+// Deployments returns a new handle on the deployments.
+func (c *Client) Deployments() *Deployments {
+	return &Deployments{client: c}
+}
+
+// List is used to list all of the deployments.
+func (d *Deployments) List(q *QueryOptions) ([]*Deployment, *QueryMeta, error) {
+	var resp []*Deployment
+	qm, err := d.client.query("/v1/deployments", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(DeploymentIndexSort(resp))
+	return resp, qm, nil
+}
+
+// Info is used to query a single deployment by its ID.
+func (d *Deployments) Info(deploymentID string, q *QueryOptions) (*Deployment, *QueryMeta, error) {
+	if deploymentID == "" {
+		return nil, nil, fmt.Errorf("missing deployment ID")
+	}
+	var resp Deployment
+	qm, err := d.client.query("/v1/deployment/"+deploymentID, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// SetAllocHealth is used to manually set the health of allocations of a
+// deployment, overriding the health their checks report. This unblocks
+// deployments whose checks are wrong, for instance to promote canaries.
+// Marking allocations unhealthy may fail the deployment and, if it is set
+// to auto revert, roll the job back.
+func (d *Deployments) SetAllocHealth(deploymentID string, healthy, unhealthy []string, q *WriteOptions) (*DeploymentUpdateResponse, *WriteMeta, error) {
+	if deploymentID == "" {
+		return nil, nil, fmt.Errorf("missing deployment ID")
+	}
+	if len(healthy) == 0 && len(unhealthy) == 0 {
+		return nil, nil, fmt.Errorf("at least one healthy or unhealthy allocation must be specified")
+	}
+	marked := make(map[string]struct{}, len(healthy))
+	for _, id := range healthy {
+		marked[id] = struct{}{}
+	}
+	for _, id := range unhealthy {
+		if _, ok := marked[id]; ok {
+			return nil, nil, fmt.Errorf("allocation %q can't be marked both healthy and unhealthy", id)
+		}
+	}
+
+	req := &DeploymentAllocHealthRequest{
+		DeploymentID:           deploymentID,
+		HealthyAllocationIDs:   healthy,
+		UnhealthyAllocationIDs: unhealthy,
+	}
+	var resp DeploymentUpdateResponse
+	wm, err := d.client.write("/v1/deployment/allocation-health/"+deploymentID, req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Deployment is the rollout of a version of a job.
+type Deployment struct {
+	ID             string
+	Namespace      string `json:",omitempty"`
+	JobID          string
+	JobVersion     uint64
+	JobModifyIndex uint64
+	JobCreateIndex uint64
+
+	// TaskGroups is the state of the rollout of each task group.
+	TaskGroups map[string]*DeploymentState
+
+	Status            string
+	StatusDescription string
+	CreateIndex       uint64
+	ModifyIndex       uint64
+}
+
+// Active returns whether the deployment is still rolling out, as opposed to
+// having finished with a final status.
+func (d *Deployment) Active() bool {
+	switch d.Status {
+	case DeploymentStatusRunning, DeploymentStatusPaused:
+		return true
+	default:
+		return false
+	}
+}
+
+// DeploymentState is the state of the rollout of a task group.
+type DeploymentState struct {
+	PlacedCanaries    []string
+	AutoRevert        bool
+	ProgressDeadline  time.Duration
+	RequireProgressBy time.Time
+	Promoted          bool
+	DesiredCanaries   int
+	DesiredTotal      int
+	PlacedAllocs      int
+	HealthyAllocs     int
+	UnhealthyAllocs   int
+}
+
+// DeploymentAllocHealthRequest is used to set the health of the allocations
+// of a deployment.
+type DeploymentAllocHealthRequest struct {
+	DeploymentID           string
+	HealthyAllocationIDs   []string
+	UnhealthyAllocationIDs []string
+}
+
+// DeploymentUpdateResponse is the result of an update of a deployment.
+type DeploymentUpdateResponse struct {
+	EvalID                string
+	EvalCreateIndex       uint64
+	DeploymentModifyIndex uint64
+
+	// RevertedJobVersion is the version the job was reverted to if the
+	// update failed the deployment of a job set to auto revert.
+	RevertedJobVersion *uint64 `json:",omitempty"`
+}
+
+// DeploymentIndexSort reverse sorts deployments by CreateIndex.
+type DeploymentIndexSort []*Deployment
+
+func (d DeploymentIndexSort) Len() int {
+	return len(d)
+}
+
+func (d DeploymentIndexSort) Less(i, j int) bool {
+	return d[i].CreateIndex > d[j].CreateIndex
+}
+
+func (d DeploymentIndexSort) Swap(i, j int) {
+	d[i], d[j] = d[j], d[i]
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeployments(t *testing.T) {
+	var submitted DeploymentAllocHealthRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/deployments":
+			fmt.Fprint(w, `[{"ID": "deploy1", "CreateIndex": 3}, {"ID": "deploy2", "CreateIndex": 7}]`)
+		case r.Method == "GET" && r.URL.Path == "/v1/deployment/deploy2":
+			fmt.Fprint(w, `{"ID": "deploy2", "JobID": "web", "JobVersion": 2, "Status": "running",
+				"TaskGroups": {"web": {"DesiredCanaries": 1, "PlacedCanaries": ["alloc3"], "HealthyAllocs": 0}}}`)
+		case r.Method == "PUT" && r.URL.Path == "/v1/deployment/allocation-health/deploy2":
+			if err := json.NewDecoder(r.Body).Decode(&submitted); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			fmt.Fprint(w, `{"EvalID": "eval1", "EvalCreateIndex": 10, "DeploymentModifyIndex": 10}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	deployments := c.Deployments()
+
+	// Deployments are listed newest first
+	list, qm, err := deployments.List(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(list) != 2 || list[0].ID != "deploy2" {
+		t.Fatalf("bad deployments: %#v", list)
+	}
+
+	deploy, _, err := deployments.Info("deploy2", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	state := deploy.TaskGroups["web"]
+	if deploy.Status != DeploymentStatusRunning || state == nil || state.PlacedCanaries[0] != "alloc3" {
+		t.Fatalf("bad deployment: %#v", deploy)
+	}
+
+	resp, wm, err := deployments.SetAllocHealth("deploy2", []string{"alloc3"}, []string{"alloc4"}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertWriteMeta(t, wm)
+	if resp.EvalID != "eval1" || resp.DeploymentModifyIndex != 10 {
+		t.Fatalf("bad response: %#v", resp)
+	}
+	expected := DeploymentAllocHealthRequest{
+		DeploymentID:           "deploy2",
+		HealthyAllocationIDs:   []string{"alloc3"},
+		UnhealthyAllocationIDs: []string{"alloc4"},
+	}
+	if !reflect.DeepEqual(submitted, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, submitted)
+	}
+}
+
+func TestDeployments_SetAllocHealth_Invalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("invalid health update should not be submitted")
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	deployments := c.Deployments()
+
+	_, _, err = deployments.SetAllocHealth("deploy1", []string{"alloc1", "alloc2"}, []string{"alloc2"}, nil)
+	if err == nil || !strings.Contains(err.Error(), `"alloc2" can't be marked both healthy and unhealthy`) {
+		t.Fatalf("expected conflicting health error, got: %v", err)
+	}
+	if _, _, err := deployments.SetAllocHealth("deploy1", nil, nil, nil); err == nil {
+		t.Fatalf("expected missing allocations error")
+	}
+	if _, _, err := deployments.SetAllocHealth("", []string{"alloc1"}, nil, nil); err == nil {
+		t.Fatalf("expected missing deployment error")
+	}
+}
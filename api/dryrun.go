@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DryRunResult describes what a write made by a client in dry run mode would
+// have done. It is returned in the WriteMeta of the write, which is the only
+// result of the write, as it was not applied.
+type DryRunResult struct {
+	// Operation describes the write, such as "register job \"example\"".
+	Operation string
+
+	// Plan is the plan of job registrations, showing the changes the
+	// registration would make.
+	Plan *JobPlanResponse `json:",omitempty"`
+
+	// Validated is set if the write was only validated locally as it has no
+	// plan equivalent.
+	Validated bool
+}
+
+// DryRunUnsupportedError is returned by writes made by a client in dry run
+// mode that have no plan or validation equivalent. The write is not sent.
+type DryRunUnsupportedError struct {
+	Method   string
+	Endpoint string
+}
+
+func (e *DryRunUnsupportedError) Error() string {
+	return fmt.Sprintf("dry run: %s %s has no dry run equivalent and was not sent", e.Method, e.Endpoint)
+}
+
+// dryRunSafe returns whether the request can be sent by a client in dry run
+// mode. Only reads and job plans, which never mutate state, are sent.
+func (r *request) dryRunSafe() bool {
+	switch r.method {
+	case "GET":
+		return true
+	case "PUT":
+		id := strings.TrimPrefix(r.url.Path, "/v1/job/")
+		return id != r.url.Path && strings.HasSuffix(id, "/plan") && id != "/plan"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_DryRun(t *testing.T) {
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Method+" "+r.URL.Path)
+		w.Header().Set("X-Nomad-Index", "10")
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/v1/job/job1/plan":
+			var req JobPlanRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if !req.Diff {
+				t.Fatalf("expected the plan to request a diff")
+			}
+			json.NewEncoder(w).Encode(&JobPlanResponse{
+				JobModifyIndex: 5,
+				Diff:           &JobDiff{Type: DiffTypeAdded, ID: req.Job.ID},
+			})
+		case r.Method == "GET" && r.URL.Path == "/v1/jobs":
+			w.Write([]byte("[]"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.DryRun = true
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reads are sent as usual
+	if _, _, err := c.Jobs().List(nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Registrations are planned
+	resp, wm, err := c.Jobs().RegisterOpts(testJob(), nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.EvalID != "" || wm == nil || wm.DryRun == nil {
+		t.Fatalf("expected dry run result, got: %#v %#v", resp, wm)
+	}
+	if result := wm.DryRun; result.Plan == nil || result.Plan.Diff.Type != DiffTypeAdded || result.Validated {
+		t.Fatalf("bad result: %#v", result)
+	}
+	if _, wm, err := c.Jobs().Register(testJob(), nil); err != nil || wm.DryRun == nil {
+		t.Fatalf("expected dry run result, got: %v", err)
+	}
+
+	// Writes without a plan are validated
+	token := &ACLToken{Name: "ci", Type: ACLManagementToken}
+	if out, wm, err := c.ACLTokens().Create(token, nil); err != nil || out != nil || !wm.DryRun.Validated {
+		t.Fatalf("expected validated dry run result, got: %#v %v", out, err)
+	}
+	if _, _, err := c.ACLTokens().Create(&ACLToken{Type: ACLClientToken}, nil); err == nil {
+		t.Fatalf("expected validation error")
+	}
+
+	// Any other write is refused
+	_, _, err = c.Jobs().Deregister("job1", nil)
+	if _, ok := err.(*DryRunUnsupportedError); !ok {
+		t.Fatalf("expected unsupported error, got: %v", err)
+	}
+	if err := c.System().GarbageCollect(); err == nil {
+		t.Fatalf("expected unsupported error")
+	}
+
+	// Only plans of jobs count as plans
+	for _, path := range []string{"/v1/job/job1/plan", "/v1/job/parent/periodic-1/plan"} {
+		r := c.newRequest("PUT", path)
+		if !r.dryRunSafe() {
+			t.Fatalf("%s: expected dry run safe", path)
+		}
+	}
+	for _, path := range []string{"/v1/job//plan", "/v1/deployment/plan", "/v1/job/job1/plan/extra", "/v1/job/job1"} {
+		r := c.newRequest("PUT", path)
+		if r.dryRunSafe() {
+			t.Fatalf("%s: expected dry run unsafe", path)
+		}
+	}
+	if r := c.newRequest("POST", "/v1/job/job1/plan"); r.dryRunSafe() {
+		t.Fatalf("expected POST plan to be dry run unsafe")
+	}
+
+	expected := []string{"GET /v1/jobs", "PUT /v1/job/job1/plan", "PUT /v1/job/job1/plan"}
+	if len(sent) != len(expected) {
+		t.Fatalf("bad requests sent: %v", sent)
+	}
+	for i := range expected {
+		if sent[i] != expected[i] {
+			t.Fatalf("bad requests sent: %v", sent)
+		}
+	}
+}
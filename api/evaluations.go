@@ -1,7 +1,9 @@
 package api
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -26,6 +28,34 @@ func (e *Evaluations) List(q *QueryOptions) ([]*Evaluation, *QueryMeta, error) {
 	return resp, qm, nil
 }
 
+// EvalListOptions holds the filters of an evaluation listing.
+type EvalListOptions struct {
+	// TriggeredBy restricts the listing to the evaluations created by the
+	// given trigger, such as EvalTriggerJobRegister.
+	TriggeredBy string
+}
+
+// ListWithOpts is used to list the evaluations matching the given options.
+// The servers don't filter evaluations by trigger, so every evaluation
+// matching the query options is fetched and filtered by the client.
+func (e *Evaluations) ListWithOpts(opts *EvalListOptions, q *QueryOptions) ([]*Evaluation, *QueryMeta, error) {
+	evals, qm, err := e.List(q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts == nil || opts.TriggeredBy == "" {
+		return evals, qm, nil
+	}
+
+	filtered := evals[:0]
+	for _, eval := range evals {
+		if eval.TriggeredBy == opts.TriggeredBy {
+			filtered = append(filtered, eval)
+		}
+	}
+	return filtered, qm, nil
+}
+
 func (e *Evaluations) PrefixList(prefix string) ([]*Evaluation, *QueryMeta, error) {
 	return e.List(&QueryOptions{Prefix: prefix})
 }
@@ -52,12 +82,130 @@ func (e *Evaluations) Allocations(evalID string, q *QueryOptions) ([]*Allocation
 	return resp, qm, nil
 }
 
+// Chain is used to retrieve the chain of evaluations the evaluation with the
+// given ID belongs to, such as to diagnose a flapping job. The PreviousEval,
+// NextEval and BlockedEval links are followed in both directions and the
+// evaluations are returned in the order they were created, oldest first.
+// Each evaluation is only visited once, so cyclic links terminate. Linked
+// evaluations that were garbage collected are skipped, but the evaluation
+// with the given ID must exist.
+func (e *Evaluations) Chain(evalID string, q *QueryOptions) ([]*Evaluation, error) {
+	start, _, err := e.Info(evalID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]struct{}{start.ID: {}}
+	chain := []*Evaluation{start}
+	for i := 0; i < len(chain); i++ {
+		eval := chain[i]
+		for _, linked := range []string{eval.PreviousEval, eval.NextEval, eval.BlockedEval} {
+			if linked == "" {
+				continue
+			}
+			if _, ok := seen[linked]; ok {
+				continue
+			}
+			seen[linked] = struct{}{}
+
+			next, _, err := e.Info(linked, q)
+			if err != nil {
+				if strings.Contains(err.Error(), "Unexpected response code: 404") {
+					continue
+				}
+				return nil, fmt.Errorf("failed to look up evaluation %q: %v", linked, err)
+			}
+			chain = append(chain, next)
+		}
+	}
+
+	sort.Sort(sort.Reverse(EvalIndexSort(chain)))
+	return chain, nil
+}
+
+// EvalDeleteRequest is used to delete a set of evaluations.
+type EvalDeleteRequest struct {
+	EvalIDs []string
+}
+
+// Delete is used to remove a batch of evaluations that are terminal or
+// blocked, such as evaluations wedged behind a backed up scheduler.
+// Pending evaluations may still be processed by a scheduler, so the whole
+// batch is rejected if any of them is pending.
+func (e *Evaluations) Delete(evalIDs []string, q *WriteOptions) (*WriteMeta, error) {
+	if len(evalIDs) == 0 {
+		return nil, fmt.Errorf("at least one evaluation ID must be given")
+	}
+
+	var iq *QueryOptions
+	if q != nil {
+		iq = &QueryOptions{Region: q.Region, Namespace: q.Namespace}
+	}
+	var pending []string
+	for _, evalID := range evalIDs {
+		eval, _, err := e.Info(evalID, iq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up evaluation %q: %v", evalID, err)
+		}
+		if eval.Status == EvalStatusPending {
+			pending = append(pending, evalID)
+		}
+	}
+	if len(pending) != 0 {
+		return nil, fmt.Errorf("refusing to delete pending evaluations: %s", strings.Join(pending, ", "))
+	}
+
+	req := &EvalDeleteRequest{EvalIDs: evalIDs}
+	wm, err := e.client.delete("/v1/evaluations", req, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+const (
+	EvalStatusBlocked   = "blocked"
+	EvalStatusPending   = "pending"
+	EvalStatusComplete  = "complete"
+	EvalStatusFailed    = "failed"
+	EvalStatusCancelled = "canceled"
+)
+
+const (
+	// EvalTriggerJobRegister and EvalTriggerJobDeregister trigger
+	// evaluations when a job is registered or deregistered, whether by an
+	// operator or a dispatch.
+	EvalTriggerJobRegister   = "job-register"
+	EvalTriggerJobDeregister = "job-deregister"
+
+	// EvalTriggerPeriodicJob triggers the evaluation of a child job
+	// launched by a periodic job.
+	EvalTriggerPeriodicJob = "periodic-job"
+
+	// EvalTriggerNodeUpdate triggers evaluations of the jobs with
+	// allocations on a node when its status or drain changes.
+	EvalTriggerNodeUpdate = "node-update"
+
+	// EvalTriggerScheduled triggers the core jobs run by the servers, such
+	// as garbage collection.
+	EvalTriggerScheduled = "scheduled"
+
+	// EvalTriggerRollingUpdate triggers the evaluation of the next batch of
+	// a rolling update.
+	EvalTriggerRollingUpdate = "rolling-update"
+
+	// EvalTriggerMaxPlans triggers the follow up of an evaluation whose
+	// plans were rejected too many times.
+	EvalTriggerMaxPlans = "max-plan-attempts"
+)
+
 // Evaluation is used to serialize an evaluation.
 type Evaluation struct {
 	ID                string
 	Priority          int
 	Type              string
 	TriggeredBy       string
+	TriggerReason     string
 	JobID             string
 	JobModifyIndex    uint64
 	NodeID            string
@@ -73,6 +221,18 @@ type Evaluation struct {
 	ModifyIndex       uint64
 }
 
+// TerminalStatus returns whether the evaluation has finished being
+// processed. Blocked evaluations are still waiting on capacity and are not
+// terminal.
+func (e *Evaluation) TerminalStatus() bool {
+	switch e.Status {
+	case EvalStatusComplete, EvalStatusFailed, EvalStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
 // EvalIndexSort is a wrapper to sort evaluations by CreateIndex.
 // We reverse the test so that we get the highest index first.
 type EvalIndexSort []*Evaluation
@@ -1,6 +1,10 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
 	"strings"
@@ -48,6 +52,45 @@ func TestEvaluations_List(t *testing.T) {
 	}
 }
 
+func TestEvaluations_ListWithOpts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		fmt.Fprintf(w, `[
+			{"ID": "eval1", "TriggeredBy": %q, "CreateIndex": 1},
+			{"ID": "eval2", "TriggeredBy": %q, "CreateIndex": 2},
+			{"ID": "eval3", "TriggeredBy": %q, "CreateIndex": 3}
+		]`, EvalTriggerJobRegister, EvalTriggerNodeUpdate, EvalTriggerJobRegister)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	evals, qm, err := c.Evaluations().ListWithOpts(&EvalListOptions{TriggeredBy: EvalTriggerJobRegister}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(evals) != 2 || evals[0].ID != "eval3" || evals[1].ID != "eval1" {
+		t.Fatalf("bad evaluations: %#v", evals)
+	}
+
+	// Without a trigger every evaluation is returned
+	evals, _, err = c.Evaluations().ListWithOpts(nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(evals) != 3 {
+		t.Fatalf("expected 3 evaluations, got: %#v", evals)
+	}
+}
+
 func TestEvaluations_PrefixList(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -155,3 +198,152 @@ func TestEvaluations_Sort(t *testing.T) {
 		t.Fatalf("\n\n%#v\n\n%#v", evals, expect)
 	}
 }
+
+func TestEvaluations_Delete(t *testing.T) {
+	statuses := map[string]string{
+		"eval1": EvalStatusComplete,
+		"eval2": EvalStatusBlocked,
+		"eval3": EvalStatusPending,
+	}
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/evaluation/"):
+			id := strings.TrimPrefix(r.URL.Path, "/v1/evaluation/")
+			status, ok := statuses[id]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, `{"ID": %q, "Status": %q}`, id, status)
+		case r.Method == "DELETE" && r.URL.Path == "/v1/evaluations":
+			var req EvalDeleteRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			deleted = append(deleted, req.EvalIDs...)
+			w.Header().Set("X-Nomad-Index", "5")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	evals := c.Evaluations()
+
+	// Terminal and blocked evals are deleted in a single batch
+	wm, err := evals.Delete([]string{"eval1", "eval2"}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if wm.LastIndex != 5 {
+		t.Fatalf("bad index: %d", wm.LastIndex)
+	}
+	if !reflect.DeepEqual(deleted, []string{"eval1", "eval2"}) {
+		t.Fatalf("bad deleted evals: %v", deleted)
+	}
+
+	// Pending evals fail the whole batch
+	deleted = nil
+	_, err = evals.Delete([]string{"eval1", "eval3"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "pending evaluations: eval3") {
+		t.Fatalf("expected pending error, got: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("nothing should have been deleted: %v", deleted)
+	}
+
+	// Unknown evals are reported
+	if _, err := evals.Delete([]string{"nope"}, nil); err == nil {
+		t.Fatalf("expected lookup error")
+	}
+	if _, err := evals.Delete(nil, nil); err == nil {
+		t.Fatalf("expected empty batch error")
+	}
+}
+
+func TestEvaluation_TerminalStatus(t *testing.T) {
+	cases := map[string]bool{
+		EvalStatusBlocked:   false,
+		EvalStatusPending:   false,
+		EvalStatusComplete:  true,
+		EvalStatusFailed:    true,
+		EvalStatusCancelled: true,
+	}
+	for status, terminal := range cases {
+		eval := &Evaluation{Status: status}
+		if eval.TerminalStatus() != terminal {
+			t.Fatalf("%s: expected terminal %v", status, terminal)
+		}
+	}
+}
+
+func TestEvaluations_Chain(t *testing.T) {
+	evals := map[string]*Evaluation{
+		"eval1": {ID: "eval1", NextEval: "eval2", CreateIndex: 1},
+		"eval2": {ID: "eval2", PreviousEval: "eval1", BlockedEval: "eval3", NextEval: "gone", CreateIndex: 2},
+		"eval3": {ID: "eval3", PreviousEval: "eval2", NextEval: "eval4", CreateIndex: 3},
+
+		// eval4 links back to eval1, forming a cycle
+		"eval4": {ID: "eval4", PreviousEval: "eval3", NextEval: "eval1", CreateIndex: 4},
+
+		"other": {ID: "other", CreateIndex: 5},
+	}
+	var lookups int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		eval, ok := evals[strings.TrimPrefix(r.URL.Path, "/v1/evaluation/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Nomad-Index", "10")
+		json.NewEncoder(w).Encode(eval)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The whole chain is found from any of its evaluations
+	for _, start := range []string{"eval1", "eval3", "eval4"} {
+		lookups = 0
+		chain, err := c.Evaluations().Chain(start, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var ids []string
+		for _, eval := range chain {
+			ids = append(ids, eval.ID)
+		}
+		if !reflect.DeepEqual(ids, []string{"eval1", "eval2", "eval3", "eval4"}) {
+			t.Fatalf("%s: bad chain: %v", start, ids)
+		}
+		if lookups != 5 {
+			t.Fatalf("%s: expected each evaluation to be looked up once, got %d lookups", start, lookups)
+		}
+	}
+
+	// Unlinked evaluations are chains of their own
+	chain, err := c.Evaluations().Chain("other", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(chain) != 1 || chain[0].ID != "other" {
+		t.Fatalf("bad chain: %#v", chain)
+	}
+
+	if _, err := c.Evaluations().Chain("gone", nil); err == nil {
+		t.Fatalf("expected missing evaluation error")
+	}
+}
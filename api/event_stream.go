@@ -0,0 +1,404 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// eventStreamReconnectWait is the delay between the first attempts to
+	// reconnect a dropped event stream. It doubles after each failed
+	// attempt, up to eventStreamReconnectMaxWait.
+	eventStreamReconnectWait    = time.Second
+	eventStreamReconnectMaxWait = 30 * time.Second
+)
+
+// Topic is a topic of the event stream, grouping the events raised by
+// changes to one kind of object.
+type Topic string
+
+const (
+	// TopicDeployment, TopicEvaluation, TopicAllocation, TopicJob and
+	// TopicNode are the topics of the changes to deployments, evaluations,
+	// allocations, jobs and nodes. TopicAll subscribes to every topic.
+	TopicDeployment Topic = "Deployment"
+	TopicEvaluation Topic = "Evaluation"
+	TopicAllocation Topic = "Allocation"
+	TopicJob        Topic = "Job"
+	TopicNode       Topic = "Node"
+	TopicAll        Topic = "*"
+)
+
+// Events is a batch of events of the event stream, all raised by the change
+// committed at Index.
+type Events struct {
+	Index  uint64
+	Events []Event
+
+	// Err is set on the last batch delivered by a stream that failed. The
+	// channel is closed after it.
+	Err error `json:"-"`
+}
+
+// Event is a change to an object of the cluster.
+type Event struct {
+	// Topic is the topic of the event and Type what happened to the
+	// object, such as "JobRegistered" or "AllocationUpdated".
+	Topic Topic
+	Type  string
+
+	// Key identifies the changed object, such as the ID of a job, and
+	// FilterKeys are other keys the event can be filtered by, such as the
+	// job ID of an allocation.
+	Key        string
+	FilterKeys []string `json:",omitempty"`
+	Namespace  string   `json:",omitempty"`
+	Index      uint64
+
+	// Payload holds the changed object under the name of its topic, such
+	// as {"Job": {...}} for events of the Job topic. It is decoded by the
+	// decoder of the topic, such as Job, or with Decode.
+	Payload json.RawMessage `json:",omitempty"`
+}
+
+// Decode decodes the payload of the event into out, which should have a
+// field named after the kind of the changed object, such as a struct with a
+// Job *Job field for events of the Job topic.
+func (e *Event) Decode(out interface{}) error {
+	if len(e.Payload) == 0 {
+		return fmt.Errorf("event %s of topic %s has no payload", e.Type, e.Topic)
+	}
+	return json.Unmarshal(e.Payload, out)
+}
+
+// Job decodes the job changed by an event of the Job topic.
+func (e *Event) Job() (*Job, error) {
+	var job Job
+	if err := e.decodeObject(TopicJob, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Allocation decodes the allocation changed by an event of the Allocation
+// topic.
+func (e *Event) Allocation() (*Allocation, error) {
+	var alloc Allocation
+	if err := e.decodeObject(TopicAllocation, &alloc); err != nil {
+		return nil, err
+	}
+	return &alloc, nil
+}
+
+// Evaluation decodes the evaluation changed by an event of the Evaluation
+// topic.
+func (e *Event) Evaluation() (*Evaluation, error) {
+	var eval Evaluation
+	if err := e.decodeObject(TopicEvaluation, &eval); err != nil {
+		return nil, err
+	}
+	return &eval, nil
+}
+
+// Node decodes the node changed by an event of the Node topic.
+func (e *Event) Node() (*Node, error) {
+	var node Node
+	if err := e.decodeObject(TopicNode, &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// Deployment decodes the deployment changed by an event of the Deployment
+// topic.
+func (e *Event) Deployment() (*Deployment, error) {
+	var deployment Deployment
+	if err := e.decodeObject(TopicDeployment, &deployment); err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// decodeObject decodes the object changed by an event of the given topic,
+// which the payload holds under the name of the topic.
+func (e *Event) decodeObject(topic Topic, out interface{}) error {
+	if e.Topic != topic {
+		return fmt.Errorf("event %s of topic %s is not of topic %s", e.Type, e.Topic, topic)
+	}
+	var objects map[string]json.RawMessage
+	if err := e.Decode(&objects); err != nil {
+		return err
+	}
+	raw, ok := objects[string(topic)]
+	if !ok || string(raw) == "null" {
+		return fmt.Errorf("event %s has no %s in its payload", e.Type, topic)
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// EventStream is used to stream the events of the cluster.
+type EventStream struct {
+	client *Client
+}
+
+// EventStream returns a new handle on the event stream.
+func (c *Client) EventStream() *EventStream {
+	return &EventStream{client: c}
+}
+
+// EventStreamOptions configures how a stream of events recovers from dropped
+// connections.
+type EventStreamOptions struct {
+	// DisableReconnect ends the stream with an error once its connection
+	// drops, rather than reconnecting and resuming it.
+	DisableReconnect bool
+}
+
+// Stream subscribes to the events of the given topics raised from the index
+// onwards, delivering them in batches as they occur. Each topic maps to the
+// keys of the objects to receive the events of, such as job IDs, or "*" for
+// every object. With no topics every event is received.
+//
+// The servers send heartbeats on idle streams, which are delivered as
+// batches without events for which IsHeartbeat returns true. If the
+// connection drops, the stream reconnects with a backoff and resumes after
+// the index of the last batch delivered. The channel is closed once the
+// context is cancelled or the stream fails, in which case the last batch
+// carries the error.
+func (e *EventStream) Stream(ctx context.Context, index uint64, topics map[Topic][]string, q *QueryOptions) (<-chan *Events, error) {
+	return e.StreamOpts(ctx, index, topics, nil, q)
+}
+
+// StreamOpts subscribes to the events of the given topics like Stream, with
+// the given options.
+func (e *EventStream) StreamOpts(ctx context.Context, index uint64, topics map[Topic][]string,
+	opts *EventStreamOptions, q *QueryOptions) (<-chan *Events, error) {
+
+	r, err := e.client.rawQuery(eventStreamEndpoint(index, topics), q.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	reconnect := opts == nil || !opts.DisableReconnect
+
+	eventsCh := make(chan *Events, 10)
+	go func() {
+		defer close(eventsCh)
+
+		// resume is the index the stream is resumed from after a dropped
+		// connection
+		resume := index
+		for {
+			err := e.consume(ctx, r, eventsCh, &resume)
+			r.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			if reconnect {
+				r, err = e.reconnect(ctx, resume, topics, q)
+				if err == nil {
+					continue
+				}
+				if ctx.Err() != nil {
+					return
+				}
+			}
+
+			select {
+			case eventsCh <- &Events{Err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+	}()
+
+	return eventsCh, nil
+}
+
+// IsHeartbeat returns whether the batch is a heartbeat the servers send to
+// keep idle streams alive, rather than actual events.
+func (e *Events) IsHeartbeat() bool {
+	return e.Err == nil && e.Index == 0 && len(e.Events) == 0
+}
+
+// consume delivers the batches of a stream until it fails, advancing resume
+// past the index of every batch delivered.
+func (e *EventStream) consume(ctx context.Context, r io.Reader, eventsCh chan<- *Events, resume *uint64) error {
+	dec := json.NewDecoder(r)
+	for {
+		var events Events
+		if err := dec.Decode(&events); err != nil {
+			return err
+		}
+
+		select {
+		case eventsCh <- &events:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if events.Index >= *resume {
+			*resume = events.Index + 1
+		}
+	}
+}
+
+// reconnect subscribes to the stream again from the index, retrying with a
+// backoff until the context is done or the client is closed. Errors the
+// servers answer with a client error status, such as for a revoked token, are
+// not retried.
+func (e *EventStream) reconnect(ctx context.Context, index uint64, topics map[Topic][]string, q *QueryOptions) (io.ReadCloser, error) {
+	var r io.ReadCloser
+	err := poll(ctx, eventStreamReconnectWait, eventStreamReconnectMaxWait, 0, func() (bool, error) {
+		var err error
+		r, err = e.client.rawQuery(eventStreamEndpoint(index, topics), q.WithContext(ctx))
+		switch {
+		case err == nil:
+			return true, nil
+		case err == ErrClientClosed:
+			return false, err
+		case strings.HasPrefix(err.Error(), "Unexpected response code: 4"):
+			return false, err
+		default:
+			return false, nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// eventStreamEndpoint returns the endpoint of the event stream subscribing
+// to the given topics from the index.
+func eventStreamEndpoint(index uint64, topics map[Topic][]string) string {
+	v := url.Values{}
+	v.Set("index", strconv.FormatUint(index, 10))
+
+	names := make([]string, 0, len(topics))
+	for topic := range topics {
+		names = append(names, string(topic))
+	}
+	sort.Strings(names)
+	for _, topic := range names {
+		keys := topics[Topic(topic)]
+		if len(keys) == 0 {
+			keys = []string{"*"}
+		}
+		for _, key := range keys {
+			v.Add("topic", topic+":"+key)
+		}
+	}
+	return "/v1/event/stream?" + v.Encode()
+}
+
+// JobEvent is a change to a job, or to one of its allocations or
+// deployments, delivered by Jobs.Events. Exactly one of Job, Allocation and
+// Deployment is set, according to the topic of the event.
+type JobEvent struct {
+	Index uint64
+	Topic Topic
+	Type  string
+
+	Job        *Job
+	Allocation *Allocation
+	Deployment *Deployment
+}
+
+// Events watches the job with the given ID, delivering the changes to the
+// job and to its allocations and deployments as they occur. Events whose
+// payload can't be decoded are reported on the error channel without ending
+// the watch, while the failure of the underlying stream is reported before
+// both channels are closed. Both channels must be drained until they are
+// closed, which happens once the context is cancelled or the stream fails.
+func (j *Jobs) Events(ctx context.Context, jobID string, q *QueryOptions) (<-chan *JobEvent, <-chan error) {
+	jobCh := make(chan *JobEvent, 10)
+	errCh := make(chan error, 1)
+
+	topics := map[Topic][]string{
+		TopicJob:        {jobID},
+		TopicAllocation: {jobID},
+		TopicDeployment: {jobID},
+	}
+	eventsCh, err := j.client.EventStream().Stream(ctx, 0, topics, q)
+	if err != nil {
+		errCh <- err
+		close(errCh)
+		close(jobCh)
+		return jobCh, errCh
+	}
+
+	go func() {
+		defer close(errCh)
+		defer close(jobCh)
+		for events := range eventsCh {
+			if events.Err != nil {
+				select {
+				case errCh <- events.Err:
+				case <-ctx.Done():
+				}
+				return
+			}
+			for i := range events.Events {
+				event := &events.Events[i]
+				if !event.hasKey(jobID) {
+					continue
+				}
+				jobEvent, err := decodeJobEvent(event)
+				if err != nil {
+					select {
+					case errCh <- err:
+						continue
+					case <-ctx.Done():
+						return
+					}
+				}
+				select {
+				case jobCh <- jobEvent:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return jobCh, errCh
+}
+
+// hasKey returns whether the event is keyed or can be filtered by the key.
+func (e *Event) hasKey(key string) bool {
+	if e.Key == key {
+		return true
+	}
+	for _, k := range e.FilterKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeJobEvent decodes the object changed by an event of a watched job.
+func decodeJobEvent(event *Event) (*JobEvent, error) {
+	jobEvent := &JobEvent{Index: event.Index, Topic: event.Topic, Type: event.Type}
+	var err error
+	switch event.Topic {
+	case TopicJob:
+		jobEvent.Job, err = event.Job()
+	case TopicAllocation:
+		jobEvent.Allocation, err = event.Allocation()
+	case TopicDeployment:
+		jobEvent.Deployment, err = event.Deployment()
+	default:
+		err = fmt.Errorf("unexpected event %s of topic %s", event.Type, event.Topic)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return jobEvent, nil
+}
@@ -0,0 +1,367 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventStream(t *testing.T) {
+	var topics []string
+	var index string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/event/stream" {
+			http.NotFound(w, r)
+			return
+		}
+		topics = r.URL.Query()["topic"]
+		index = r.URL.Query().Get("index")
+
+		fmt.Fprint(w, "{}\n")
+		fmt.Fprint(w, `{"Index": 5, "Events": [{"Topic": "Job", "Type": "JobRegistered", "Key": "job1", "Index": 5,
+			"Payload": {"Job": {"ID": "job1", "Type": "service"}}}]}`+"\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	streamTopics := map[Topic][]string{
+		TopicJob:        {"job1"},
+		TopicAllocation: nil,
+	}
+	eventsCh, err := c.EventStream().Stream(ctx, 3, streamTopics, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Heartbeats are delivered as batches without events
+	var events *Events
+	for i := 0; i < 2; i++ {
+		select {
+		case events = <-eventsCh:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for events")
+		}
+		if heartbeat := i == 0; events.IsHeartbeat() != heartbeat {
+			t.Fatalf("batch %d: expected heartbeat %v, got: %#v", i, heartbeat, events)
+		}
+	}
+	if events.Err != nil || events.Index != 5 || len(events.Events) != 1 {
+		t.Fatalf("bad events: %#v", events)
+	}
+	if !reflect.DeepEqual(topics, []string{"Allocation:*", "Job:job1"}) || index != "3" {
+		t.Fatalf("bad subscription: index %q, topics %q", index, topics)
+	}
+
+	event := events.Events[0]
+	if event.Topic != TopicJob || event.Type != "JobRegistered" || event.Key != "job1" {
+		t.Fatalf("bad event: %#v", event)
+	}
+	var payload struct{ Job *Job }
+	if err := event.Decode(&payload); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if payload.Job == nil || payload.Job.ID != "job1" || payload.Job.Type != JobTypeService {
+		t.Fatalf("bad payload: %#v", payload.Job)
+	}
+
+	// Cancelling the context closes the stream
+	cancel()
+	select {
+	case events, ok := <-eventsCh:
+		if ok {
+			t.Fatalf("unexpected events: %#v", events)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the stream to close")
+	}
+}
+
+func TestEventStream_Error(t *testing.T) {
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "Permission denied", http.StatusForbidden)
+			return
+		}
+		// The connection is dropped mid frame
+		fmt.Fprint(w, `{"Index": 5, "Events": [`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := c.EventStream().Stream(context.Background(), 0, nil, nil); err == nil {
+		t.Fatalf("expected error subscribing")
+	}
+
+	fail = false
+	opts := &EventStreamOptions{DisableReconnect: true}
+	eventsCh, err := c.EventStream().StreamOpts(context.Background(), 0, nil, opts, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	events := <-eventsCh
+	if events == nil || events.Err == nil {
+		t.Fatalf("expected stream error, got: %#v", events)
+	}
+	if _, ok := <-eventsCh; ok {
+		t.Fatalf("expected the stream to be closed")
+	}
+}
+
+func TestEventStream_Reconnect(t *testing.T) {
+	var indexes []string
+	var connects int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/agent/self" {
+			// The version of the agent is unknown, so requests aren't gated
+			http.NotFound(w, r)
+			return
+		}
+		connects++
+		indexes = append(indexes, r.URL.Query().Get("index"))
+		switch connects {
+		case 1:
+			// The connection drops after a batch
+			fmt.Fprint(w, `{"Index": 5, "Events": [{"Topic": "Job", "Key": "job1", "Index": 5}]}`+"\n")
+		case 2:
+			// The agent is briefly unavailable
+			http.Error(w, "No cluster leader", http.StatusInternalServerError)
+		case 3:
+			fmt.Fprint(w, `{"Index": 8, "Events": [{"Topic": "Job", "Key": "job1", "Index": 8}]}`+"\n")
+		default:
+			// The token was revoked
+			http.Error(w, "Permission denied", http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eventsCh, err := c.EventStream().Stream(context.Background(), 2, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var seen []uint64
+	var streamErr error
+	timeout := time.After(10 * time.Second)
+	for done := false; !done; {
+		select {
+		case events, ok := <-eventsCh:
+			if !ok {
+				done = true
+				break
+			}
+			if events.Err != nil {
+				streamErr = events.Err
+				continue
+			}
+			seen = append(seen, events.Index)
+		case <-timeout:
+			t.Fatalf("timed out waiting for the stream")
+		}
+	}
+
+	// The stream resumed after the last delivered index and gave up on the
+	// client error
+	if !reflect.DeepEqual(seen, []uint64{5, 8}) {
+		t.Fatalf("bad indexes delivered: %v", seen)
+	}
+	if !reflect.DeepEqual(indexes, []string{"2", "6", "6", "9"}) {
+		t.Fatalf("bad indexes requested: %v", indexes)
+	}
+	if streamErr == nil || !strings.Contains(streamErr.Error(), "403") {
+		t.Fatalf("expected permission error, got: %v", streamErr)
+	}
+}
+
+func TestEvent_Decode_NoPayload(t *testing.T) {
+	event := &Event{Topic: TopicJob, Type: "JobDeregistered"}
+	var payload struct{ Job *Job }
+	if err := event.Decode(&payload); err == nil {
+		t.Fatalf("expected error decoding an empty payload")
+	}
+}
+
+func TestEvent_TypedDecoders(t *testing.T) {
+	events := map[Topic]*Event{
+		TopicJob:        {Topic: TopicJob, Payload: []byte(`{"Job": {"ID": "job1"}}`)},
+		TopicAllocation: {Topic: TopicAllocation, Payload: []byte(`{"Allocation": {"ID": "alloc1", "JobID": "job1"}}`)},
+		TopicEvaluation: {Topic: TopicEvaluation, Payload: []byte(`{"Evaluation": {"ID": "eval1", "JobID": "job1"}}`)},
+		TopicNode:       {Topic: TopicNode, Payload: []byte(`{"Node": {"ID": "node1"}}`)},
+		TopicDeployment: {Topic: TopicDeployment, Payload: []byte(`{"Deployment": {"ID": "d1", "JobID": "job1"}}`)},
+	}
+
+	decoders := map[Topic]func(e *Event) (string, error){
+		TopicJob: func(e *Event) (string, error) {
+			job, err := e.Job()
+			if err != nil {
+				return "", err
+			}
+			return job.ID, nil
+		},
+		TopicAllocation: func(e *Event) (string, error) {
+			alloc, err := e.Allocation()
+			if err != nil {
+				return "", err
+			}
+			return alloc.ID, nil
+		},
+		TopicEvaluation: func(e *Event) (string, error) {
+			eval, err := e.Evaluation()
+			if err != nil {
+				return "", err
+			}
+			return eval.ID, nil
+		},
+		TopicNode: func(e *Event) (string, error) {
+			node, err := e.Node()
+			if err != nil {
+				return "", err
+			}
+			return node.ID, nil
+		},
+		TopicDeployment: func(e *Event) (string, error) {
+			deployment, err := e.Deployment()
+			if err != nil {
+				return "", err
+			}
+			return deployment.ID, nil
+		},
+	}
+
+	expectIDs := map[Topic]string{
+		TopicJob:        "job1",
+		TopicAllocation: "alloc1",
+		TopicEvaluation: "eval1",
+		TopicNode:       "node1",
+		TopicDeployment: "d1",
+	}
+	for topic, decode := range decoders {
+		for eventTopic, event := range events {
+			id, err := decode(event)
+			if eventTopic == topic {
+				if err != nil || id != expectIDs[topic] {
+					t.Fatalf("%s: expected %q, got %q, err: %v", topic, expectIDs[topic], id, err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), "is not of topic") {
+				t.Fatalf("%s: expected topic error decoding a %s event, got: %v", topic, eventTopic, err)
+			}
+		}
+	}
+
+	// Payloads without the object of the topic are rejected
+	event := &Event{Topic: TopicJob, Type: "JobDeregistered", Payload: []byte(`{"Job": null}`)}
+	if _, err := event.Job(); err == nil || !strings.Contains(err.Error(), "has no Job") {
+		t.Fatalf("expected missing job error, got: %v", err)
+	}
+}
+
+func TestJobs_Events(t *testing.T) {
+	var topics []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		topics = r.URL.Query()["topic"]
+		fmt.Fprint(w, `{"Index": 5, "Events": [
+			{"Topic": "Job", "Type": "JobRegistered", "Key": "job1", "Index": 5, "Payload": {"Job": {"ID": "job1"}}},
+			{"Topic": "Allocation", "Type": "AllocationUpdated", "Key": "alloc2", "FilterKeys": ["job2"], "Index": 5,
+			 "Payload": {"Allocation": {"ID": "alloc2", "JobID": "job2"}}}
+		]}`+"\n")
+		fmt.Fprint(w, "{}\n")
+		fmt.Fprint(w, `{"Index": 6, "Events": [
+			{"Topic": "Allocation", "Type": "AllocationUpdated", "Key": "alloc1", "FilterKeys": ["job1"], "Index": 6,
+			 "Payload": {"Allocation": {"ID": "alloc1", "JobID": "job1"}}},
+			{"Topic": "Deployment", "Type": "DeploymentStatusUpdate", "Key": "d1", "FilterKeys": ["job1"], "Index": 6,
+			 "Payload": {"Nope": {}}},
+			{"Topic": "Deployment", "Type": "DeploymentStatusUpdate", "Key": "d1", "FilterKeys": ["job1"], "Index": 6,
+			 "Payload": {"Deployment": {"ID": "d1", "JobID": "job1", "Status": "successful"}}}
+		]}`+"\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobCh, errCh := c.Jobs().Events(ctx, "job1", nil)
+
+	var jobEvents []*JobEvent
+	var errs []error
+	timeout := time.After(5 * time.Second)
+	for len(jobEvents) < 3 || len(errs) < 1 {
+		select {
+		case event := <-jobCh:
+			jobEvents = append(jobEvents, event)
+		case err := <-errCh:
+			errs = append(errs, err)
+		case <-timeout:
+			t.Fatalf("timed out waiting for events, got %#v and %v", jobEvents, errs)
+		}
+	}
+
+	if !reflect.DeepEqual(topics, []string{"Allocation:job1", "Deployment:job1", "Job:job1"}) {
+		t.Fatalf("bad topics: %q", topics)
+	}
+	if e := jobEvents[0]; e.Topic != TopicJob || e.Index != 5 || e.Job == nil || e.Job.ID != "job1" {
+		t.Fatalf("bad job event: %#v", e)
+	}
+	if e := jobEvents[1]; e.Topic != TopicAllocation || e.Allocation == nil || e.Allocation.ID != "alloc1" || e.Job != nil {
+		t.Fatalf("bad allocation event: %#v", e)
+	}
+	if e := jobEvents[2]; e.Topic != TopicDeployment || e.Deployment == nil || e.Deployment.Status != DeploymentStatusSuccessful {
+		t.Fatalf("bad deployment event: %#v", e)
+	}
+	if !strings.Contains(errs[0].Error(), "has no Deployment") {
+		t.Fatalf("expected decode error, got: %v", errs[0])
+	}
+
+	// Cancelling the context closes both channels
+	cancel()
+	for jobCh != nil || errCh != nil {
+		select {
+		case _, ok := <-jobCh:
+			if ok {
+				t.Fatalf("unexpected event")
+			}
+			jobCh = nil
+		case _, ok := <-errCh:
+			if ok {
+				t.Fatalf("unexpected error")
+			}
+			errCh = nil
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the channels to close")
+		}
+	}
+}
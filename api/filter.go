@@ -0,0 +1,301 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// FilterSyntaxError is returned for requests whose filter expression can't
+// be parsed, before the request is sent.
+type FilterSyntaxError struct {
+	// Filter is the invalid filter expression.
+	Filter string
+
+	// Pos is the byte offset in the expression the error was found at.
+	Pos int
+
+	Msg string
+}
+
+func (e *FilterSyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter expression %q: %s at position %d", e.Filter, e.Msg, e.Pos)
+}
+
+// validateFilter checks that a filter expression parses with the grammar
+// of go-bexpr, which the servers evaluate filters with. Selectors aren't
+// checked as they depend on the endpoint; the servers reject unknown ones.
+//
+// The grammar is:
+//
+//	expr     = and { "or" and }
+//	and      = unary { "and" unary }
+//	unary    = "not" unary | "(" expr ")" | match
+//	match    = selector ( "==" | "!=" ) value
+//	         | selector "is" [ "not" ] "empty"
+//	         | selector [ "not" ] ( "contains" | "matches" ) value
+//	         | value [ "not" ] "in" selector
+//	selector = identifier { "." identifier | "[" string "]" }
+func validateFilter(filter string) error {
+	p := &filterParser{filter: filter}
+	if err := p.lex(); err != nil {
+		return err
+	}
+	if len(p.tokens) == 0 {
+		return p.errorf(0, "empty expression")
+	}
+	if err := p.expr(); err != nil {
+		return err
+	}
+	if tok := p.peek(); tok != nil {
+		return p.errorf(tok.pos, "unexpected %q", tok.text)
+	}
+	return nil
+}
+
+// filterTokenKind is the kind of a token of a filter expression.
+type filterTokenKind int
+
+const (
+	filterIdent filterTokenKind = iota
+	filterString
+	filterNumber
+	filterSymbol
+)
+
+// filterToken is a token of a filter expression.
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+// filterParser is a recursive descent parser of filter expressions.
+type filterParser struct {
+	filter string
+	tokens []*filterToken
+	next   int
+}
+
+func (p *filterParser) errorf(pos int, format string, args ...interface{}) error {
+	return &FilterSyntaxError{Filter: p.filter, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// lex splits the expression into tokens.
+func (p *filterParser) lex() error {
+	s := p.filter
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '`':
+			end := i + 1
+			for end < len(s) && rune(s[end]) != c {
+				if c == '"' && s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return p.errorf(i, "unterminated string")
+			}
+			p.tokens = append(p.tokens, &filterToken{kind: filterString, text: s[i : end+1], pos: i})
+			i = end + 1
+		case strings.HasPrefix(s[i:], "=="), strings.HasPrefix(s[i:], "!="):
+			p.tokens = append(p.tokens, &filterToken{kind: filterSymbol, text: s[i : i+2], pos: i})
+			i += 2
+		case strings.ContainsRune("().[]", c):
+			p.tokens = append(p.tokens, &filterToken{kind: filterSymbol, text: string(c), pos: i})
+			i++
+		case c == '-' || unicode.IsDigit(c):
+			end := i + 1
+			for end < len(s) && (unicode.IsDigit(rune(s[end])) || s[end] == '.') {
+				end++
+			}
+			p.tokens = append(p.tokens, &filterToken{kind: filterNumber, text: s[i:end], pos: i})
+			i = end
+		case c == '_' || unicode.IsLetter(c):
+			end := i + 1
+			for end < len(s) && (s[end] == '_' || s[end] == '-' || unicode.IsLetter(rune(s[end])) || unicode.IsDigit(rune(s[end]))) {
+				end++
+			}
+			p.tokens = append(p.tokens, &filterToken{kind: filterIdent, text: s[i:end], pos: i})
+			i = end
+		default:
+			return p.errorf(i, "unexpected character %q", c)
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) peek() *filterToken {
+	if p.next < len(p.tokens) {
+		return p.tokens[p.next]
+	}
+	return nil
+}
+
+// accept consumes the next token if it is the given keyword or symbol.
+func (p *filterParser) accept(text string) bool {
+	if tok := p.peek(); tok != nil && (tok.kind == filterIdent || tok.kind == filterSymbol) && tok.text == text {
+		p.next++
+		return true
+	}
+	return false
+}
+
+// expect consumes the next token, which must be the given keyword or
+// symbol.
+func (p *filterParser) expect(text string) error {
+	if p.accept(text) {
+		return nil
+	}
+	return p.unexpected(fmt.Sprintf("%q", text))
+}
+
+// unexpected returns the error of the next token not being what was
+// expected.
+func (p *filterParser) unexpected(expected string) error {
+	tok := p.peek()
+	if tok == nil {
+		return p.errorf(len(p.filter), "expected %s but the expression ended", expected)
+	}
+	return p.errorf(tok.pos, "expected %s but found %q", expected, tok.text)
+}
+
+func (p *filterParser) expr() error {
+	if err := p.and(); err != nil {
+		return err
+	}
+	for p.accept("or") {
+		if err := p.and(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) and() error {
+	if err := p.unary(); err != nil {
+		return err
+	}
+	for p.accept("and") {
+		if err := p.unary(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) unary() error {
+	if p.accept("not") {
+		return p.unary()
+	}
+	if p.accept("(") {
+		if err := p.expr(); err != nil {
+			return err
+		}
+		return p.expect(")")
+	}
+	return p.match()
+}
+
+func (p *filterParser) match() error {
+	tok := p.peek()
+	if tok == nil {
+		return p.unexpected("a selector or value")
+	}
+
+	// Only values are quoted or numbers, so they must be matched against a
+	// selector with "in"
+	if tok.kind == filterString || tok.kind == filterNumber {
+		p.next++
+		return p.in()
+	}
+	if tok.kind != filterIdent || isFilterKeyword(tok.text) {
+		return p.unexpected("a selector or value")
+	}
+	if err := p.selector(); err != nil {
+		return err
+	}
+
+	switch {
+	case p.accept("=="), p.accept("!="):
+		return p.value()
+	case p.accept("is"):
+		p.accept("not")
+		return p.expect("empty")
+	case p.accept("contains"), p.accept("matches"):
+		return p.value()
+	case p.accept("in"):
+		// The identifier was an unquoted value
+		return p.selector()
+	case p.accept("not"):
+		switch {
+		case p.accept("contains"), p.accept("matches"):
+			return p.value()
+		case p.accept("in"):
+			return p.selector()
+		}
+		return p.unexpected(`"contains", "matches" or "in"`)
+	}
+	return p.unexpected("a match operator")
+}
+
+// in parses the rest of a match of a value against a selector.
+func (p *filterParser) in() error {
+	p.accept("not")
+	if err := p.expect("in"); err != nil {
+		return err
+	}
+	return p.selector()
+}
+
+func (p *filterParser) selector() error {
+	tok := p.peek()
+	if tok == nil || tok.kind != filterIdent || isFilterKeyword(tok.text) {
+		return p.unexpected("a selector")
+	}
+	p.next++
+	for {
+		switch {
+		case p.accept("."):
+			tok := p.peek()
+			if tok == nil || tok.kind != filterIdent {
+				return p.unexpected("a field name")
+			}
+			p.next++
+		case p.accept("["):
+			tok := p.peek()
+			if tok == nil || tok.kind != filterString {
+				return p.unexpected("a quoted key")
+			}
+			p.next++
+			if err := p.expect("]"); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *filterParser) value() error {
+	tok := p.peek()
+	if tok == nil || tok.kind == filterSymbol || (tok.kind == filterIdent && isFilterKeyword(tok.text)) {
+		return p.unexpected("a value")
+	}
+	p.next++
+	return nil
+}
+
+// isFilterKeyword returns whether the identifier is a keyword of the
+// filter grammar.
+func isFilterKeyword(ident string) bool {
+	switch ident {
+	case "and", "or", "not", "in", "is", "empty", "contains", "matches":
+		return true
+	}
+	return false
+}
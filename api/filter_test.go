@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateFilter(t *testing.T) {
+	valid := []string{
+		`Status == "running"`,
+		`Status == "running" and Type == "service"`,
+		`Status != dead or (Priority == 50 and not Stop == true)`,
+		`Meta["owner"] == "platform"`,
+		`Job.TaskGroups is not empty`,
+		`"web" in TaskGroup`,
+		`"prod" not in Meta.env`,
+		`Name matches "^web-[0-9]+$"`,
+		`Tags not contains "canary"`,
+		"Name == `raw \"string\"`",
+		`Datacenter == "dc1" and (NodeClass == "large" or NodeClass == "xlarge")`,
+	}
+	for _, filter := range valid {
+		if err := validateFilter(filter); err != nil {
+			t.Fatalf("filter %q: err: %v", filter, err)
+		}
+	}
+
+	invalid := map[string]string{
+		``:                          "empty expression",
+		`Status ==`:                 "expected a value but the expression ended",
+		`Status = "running"`:        `unexpected character '='`,
+		`Status == "running`:        "unterminated string",
+		`(Status == "running"`:      `expected ")"`,
+		`Status == "running" and`:   "expected a selector or value",
+		`Status "running"`:          "expected a match operator",
+		`Status is full`:            `expected "empty"`,
+		`"web" == TaskGroup`:        `expected "in"`,
+		`Meta[owner] == "platform"`: "expected a quoted key",
+		`Status == "a" Type`:        `unexpected "Type"`,
+		`and == "x"`:                "expected a selector or value",
+	}
+	for filter, msg := range invalid {
+		err := validateFilter(filter)
+		if _, ok := err.(*FilterSyntaxError); !ok || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("filter %q: expected error containing %q, got: %v", filter, msg, err)
+		}
+	}
+}
+
+func TestQueryOptions_Filter(t *testing.T) {
+	var filters []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filters = append(filters, r.URL.Query().Get("filter"))
+		w.Header().Set("X-Nomad-Index", "1")
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	filter := `Status == "running" and Type == "service"`
+	if _, _, err := c.Jobs().List(&QueryOptions{Filter: filter}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := c.Allocations().List(&QueryOptions{Filter: `ClientStatus == "running"`}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := c.Nodes().List(&QueryOptions{Filter: `NodeClass == "large"`}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(filters) != 3 || filters[0] != filter {
+		t.Fatalf("bad filters: %q", filters)
+	}
+
+	// Invalid filters are rejected before the request is sent
+	_, _, err = c.Jobs().List(&QueryOptions{Filter: `Status ==`})
+	if _, ok := err.(*FilterSyntaxError); !ok {
+		t.Fatalf("expected filter syntax error, got: %v", err)
+	}
+	if len(filters) != 3 {
+		t.Fatalf("invalid filter was sent")
+	}
+}
@@ -58,10 +58,8 @@ func (a *AllocFS) getNodeClient(nodeHTTPAddr, allocID string, q **QueryOptions)
 	}
 
 	// Get an API client for the node
-	nodeClientConfig := &Config{
-		Address: fmt.Sprintf("http://%s", nodeHTTPAddr),
-		Region:  a.client.config.Region,
-	}
+	nodeClientConfig := a.client.config.nodeConfig(fmt.Sprintf("http://%s", nodeHTTPAddr))
+	nodeClientConfig.Region = a.client.config.Region
 	nodeClient, err := NewClient(nodeClientConfig)
 	if err != nil {
 		return nil, err
@@ -1,9 +1,19 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/mitchellh/copystructure"
 )
 
 const (
@@ -12,14 +22,156 @@ const (
 
 	// JobTypeBatch indicates a short-lived process
 	JobTypeBatch = "batch"
+
+	// JobTypeSystem indicates a job that runs on every eligible node
+	JobTypeSystem = "system"
 )
 
+// validJobType returns whether typ is one of the known job types.
+func validJobType(typ string) bool {
+	switch typ {
+	case JobTypeService, JobTypeBatch, JobTypeSystem:
+		return true
+	}
+	return false
+}
+
 const (
+	// JobMinPriority is the lowest priority a job may have.
+	JobMinPriority = 1
+
+	// JobDefaultPriority is the priority of jobs that do not specify one.
+	JobDefaultPriority = 50
+
+	// JobMaxPriority is the highest priority a job may have.
+	JobMaxPriority = 100
+)
+
+const (
+	// DefaultForceEvaluateReason is the reason recorded on evaluations
+	// created by a force evaluate that did not specify one.
+	DefaultForceEvaluateReason = "operator forced evaluation"
+
 	// RegisterEnforceIndexErrPrefix is the prefix to use in errors caused by
 	// enforcing the job modify index during registers.
 	RegisterEnforceIndexErrPrefix = "Enforcing job modify index"
 )
 
+// JobModifyIndexError is returned by writes enforcing the job modify index
+// when the index of the job doesn't match the expected index.
+type JobModifyIndexError struct {
+	// JobID is the ID of the job the write was for.
+	JobID string
+
+	// Expected is the job modify index the write was conditioned on.
+	Expected uint64
+
+	// Actual is the current job modify index of the job. It is zero if the
+	// job does not exist or the server did not report it.
+	Actual uint64
+
+	// Exists is whether the job exists.
+	Exists bool
+
+	// Err is the error returned by the server.
+	Err error
+}
+
+func (e *JobModifyIndexError) Error() string {
+	return e.Err.Error()
+}
+
+// jobModifyIndexErrRe matches the errors returned by the servers when
+// enforcing the job modify index.
+var jobModifyIndexErrRe = regexp.MustCompile(RegisterEnforceIndexErrPrefix +
+	` (\d+): (job exists with conflicting job modify index: (\d+)|job already exists|job does not exist)`)
+
+// parseJobModifyIndexError converts an error caused by enforcing the job
+// modify index into a *JobModifyIndexError. Other errors are returned as is.
+func parseJobModifyIndexError(jobID string, err error) error {
+	m := jobModifyIndexErrRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+
+	expected, _ := strconv.ParseUint(m[1], 10, 64)
+	actual, _ := strconv.ParseUint(m[3], 10, 64)
+	return &JobModifyIndexError{
+		JobID:    jobID,
+		Expected: expected,
+		Actual:   actual,
+		Exists:   m[2] != "job does not exist",
+		Err:      err,
+	}
+}
+
+// JobValidationError is returned when registering a job the servers found
+// invalid.
+type JobValidationError struct {
+	// JobID is the ID of the job that failed validation.
+	JobID string
+
+	// Errors are the validation errors reported by the servers, with nested
+	// errors flattened.
+	Errors []string
+
+	// Warnings are the warnings of the job, which are only reported by the
+	// servers once the job is valid and are computed locally instead.
+	Warnings []string
+
+	// Err is the error returned by the server.
+	Err error
+}
+
+func (e *JobValidationError) Error() string {
+	return e.Err.Error()
+}
+
+var (
+	// unexpectedResponseRe matches the errors of requests answered with a
+	// non 200 status, capturing the response body.
+	unexpectedResponseRe = regexp.MustCompile(`(?s)^Unexpected response code: \d+ \((.*)\)$`)
+
+	// multierrorHeaderRe matches the headers of the lists of errors
+	// formatted by go-multierror, including nested ones.
+	multierrorHeaderRe = regexp.MustCompile(`:?\s*\d+ error(s|\(s\))? occurred:$`)
+)
+
+// parseJobValidationError converts an error caused by the servers failing to
+// validate the job into a *JobValidationError. Other errors, and validation
+// errors that can't be parsed, are returned as is.
+func parseJobValidationError(job *Job, err error) error {
+	m := unexpectedResponseRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	lines := strings.Split(m[1], "\n")
+	if len(lines) == 0 || !multierrorHeaderRe.MatchString(strings.TrimSpace(lines[0])) {
+		return err
+	}
+
+	var errs []string
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "* ") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "* ")
+		if line = multierrorHeaderRe.ReplaceAllString(line, ""); line != "" {
+			errs = append(errs, line)
+		}
+	}
+	if len(errs) == 0 {
+		return err
+	}
+	return &JobValidationError{
+		JobID:    job.ID,
+		Errors:   errs,
+		Warnings: job.Warnings(),
+		Err:      err,
+	}
+}
+
 // Jobs is used to access the job-specific endpoints.
 type Jobs struct {
 	client *Client
@@ -30,14 +182,138 @@ func (c *Client) Jobs() *Jobs {
 	return &Jobs{client: c}
 }
 
-// Register is used to register a new job. It returns the ID
-// of the evaluation, along with any errors encountered.
-func (j *Jobs) Register(job *Job, q *WriteOptions) (string, *WriteMeta, error) {
+// RegisterOptions is used to control how a job is registered.
+type RegisterOptions struct {
+	// EnforceIndex only registers the job if its job modify index matches
+	// ModifyIndex. A ModifyIndex of zero requires that the job does not
+	// exist yet.
+	EnforceIndex bool
+	ModifyIndex  uint64
+
+	// EvalPriority, if set, is the priority of the evaluation created for
+	// the registration, overriding the priority of the job.
+	EvalPriority int
+
+	// Submission, if set, is the source the job was parsed from, which the
+	// servers store with the registered version of the job. Servers that
+	// predate submissions ignore it.
+	Submission *JobSubmission
+}
+
+// RegisterOpts is used to register a job with the given options. A
+// canonicalized copy of the job is validated and submitted, leaving the job
+// itself untouched. Non-fatal issues found
+// with the job, locally or by the servers, are returned in the Warnings of
+// the response. If the job modify index is enforced and doesn't match, a
+// *JobModifyIndexError is returned, and if the servers find the job invalid,
+// a *JobValidationError.
+func (j *Jobs) RegisterOpts(job *Job, opts *RegisterOptions, q *WriteOptions) (*JobRegisterResponse, *WriteMeta, error) {
+	if job == nil {
+		return nil, nil, fmt.Errorf("must pass non-nil job")
+	}
+	q, err := job.writeOptions(q, j.client.config.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts, err := j.currentCounts(job, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	submitted, err := job.prepareSubmit(counts)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	var resp registerJobResponse
+	req := &RegisterJobRequest{Job: submitted.withTokens(q)}
+	var override int
+	if opts != nil {
+		if opts.EnforceIndex {
+			req.EnforceIndex = true
+			req.JobModifyIndex = opts.ModifyIndex
+		}
+		override = opts.EvalPriority
+		if opts.Submission != nil {
+			if err := opts.Submission.Validate(); err != nil {
+				return nil, nil, err
+			}
+			req.Submission = opts.Submission
+		}
+	}
+	if req.EvalPriority, err = evalPriority(override, q); err != nil {
+		return nil, nil, err
+	}
 
-	req := &RegisterJobRequest{Job: job}
+	if j.client.config.DryRun {
+		plan, _, err := j.plan(submitted, true, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		resp := &JobRegisterResponse{Warnings: mergeWarnings(submitted.Warnings(), "")}
+		return resp, &WriteMeta{DryRun: &DryRunResult{Operation: fmt.Sprintf("register job %q", job.ID), Plan: plan}}, nil
+	}
+
+	var resp JobRegisterResponse
 	wm, err := j.client.write("/v1/jobs", req, &resp, q)
+	if err != nil {
+		if req.EnforceIndex {
+			err = parseJobModifyIndexError(job.ID, err)
+		}
+		if _, ok := err.(*JobModifyIndexError); !ok {
+			err = parseJobValidationError(submitted, err)
+		}
+		return nil, nil, err
+	}
+
+	resp.Warnings = mergeWarnings(submitted.Warnings(), resp.Warnings)
+	return &resp, wm, nil
+}
+
+// currentCounts returns the current counts of the task groups of the
+// registered job, by group name, if any group of the job has no count. The
+// counts fill in the unset counts of the submitted job, so that registering
+// a job without counts doesn't rescale its groups. The counts of groups the
+// registered job doesn't have are left for Canonicalize to default.
+func (j *Jobs) currentCounts(job *Job, q *WriteOptions) (map[string]int, error) {
+	unset := false
+	for _, tg := range job.TaskGroups {
+		if tg.Count == nil {
+			unset = true
+			break
+		}
+	}
+	if !unset {
+		return nil, nil
+	}
+
+	var iq *QueryOptions
+	if q != nil {
+		iq = &QueryOptions{Region: q.Region, Namespace: q.Namespace, AuthToken: q.AuthToken}
+	}
+	current, _, err := j.Info(job.ID, iq)
+	if err != nil {
+		if strings.Contains(err.Error(), "Unexpected response code: 404") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up the counts of job %q: %v", job.ID, err)
+	}
+
+	counts := make(map[string]int, len(current.TaskGroups))
+	for _, tg := range current.TaskGroups {
+		if tg.Count != nil {
+			counts[tg.Name] = *tg.Count
+		}
+	}
+	return counts, nil
+}
+
+// Register is used to register a new job. It returns the ID
+// of the evaluation, along with any errors encountered. A
+// canonicalized copy of the job is validated and submitted.
+//
+// Deprecated: Register discards the warnings of the registration, use
+// RegisterOpts instead.
+func (j *Jobs) Register(job *Job, q *WriteOptions) (string, *WriteMeta, error) {
+	resp, wm, err := j.RegisterOpts(job, nil, q)
 	if err != nil {
 		return "", nil, err
 	}
@@ -45,38 +321,273 @@ func (j *Jobs) Register(job *Job, q *WriteOptions) (string, *WriteMeta, error) {
 }
 
 // EnforceRegister is used to register a job enforcing its job modify index.
+// If the index doesn't match, a *JobModifyIndexError is returned.
 func (j *Jobs) EnforceRegister(job *Job, modifyIndex uint64, q *WriteOptions) (string, *WriteMeta, error) {
+	opts := &RegisterOptions{EnforceIndex: true, ModifyIndex: modifyIndex}
+	resp, wm, err := j.RegisterOpts(job, opts, q)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.EvalID, wm, nil
+}
+
+// RegisterAndWait registers the job and blocks until the evaluation created
+// by the registration is terminal, returning it. The evaluation is watched
+// with blocking queries seeded at the EvalCreateIndex of the registration,
+// so no query is wasted on the evaluation before it exists. If the
+// evaluation isn't terminal within the timeout, an error is returned. A
+// timeout of zero or less waits without a limit. Registering periodic and
+// parameterized jobs creates no evaluation, in which case a nil evaluation
+// is returned.
+func (j *Jobs) RegisterAndWait(job *Job, timeout time.Duration, q *WriteOptions) (*Evaluation, *WriteMeta, error) {
+	resp, wm, err := j.RegisterOpts(job, nil, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.EvalID == "" {
+		return nil, wm, nil
+	}
 
-	var resp registerJobResponse
+	eq := &QueryOptions{WaitIndex: resp.EvalCreateIndex}
+	if q != nil {
+		eq.Region = q.Region
+		eq.Namespace = q.Namespace
+		eq.AuthToken = q.AuthToken
+	}
+	if eq.Namespace == "" && job != nil {
+		eq.Namespace = job.Namespace
+	}
 
-	req := &RegisterJobRequest{
-		Job:            job,
-		EnforceIndex:   true,
-		JobModifyIndex: modifyIndex,
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
 	}
-	wm, err := j.client.write("/v1/jobs", req, &resp, q)
+	defer cancel()
+
+	var eval *Evaluation
+	errTerminal := errors.New("evaluation is terminal")
+	err = j.client.Watch(ctx, eq, func(wq *QueryOptions) (*QueryMeta, error) {
+		out, qm, err := j.client.Evaluations().Info(resp.EvalID, wq)
+		if err != nil {
+			return nil, err
+		}
+		if out.TerminalStatus() {
+			eval = out
+			return nil, errTerminal
+		}
+		return qm, nil
+	})
+	switch err {
+	case errTerminal:
+		return eval, wm, nil
+	case context.DeadlineExceeded:
+		return nil, wm, fmt.Errorf("timed out after %s waiting for evaluation %q", timeout, resp.EvalID)
+	default:
+		return nil, wm, err
+	}
+}
+
+// JobApplyResult is the result of applying a job.
+type JobApplyResult struct {
+	// Changed is whether the job differed from the registered job and was
+	// registered.
+	Changed bool
+
+	// Diff is the difference between the registered job and the applied
+	// job. Its Type is "None" if the job was unchanged.
+	Diff *JobDiff
+
+	// EvalID and JobModifyIndex are those of the registration if the job
+	// changed.
+	EvalID         string
+	JobModifyIndex uint64
+
+	// Warnings are the non-fatal issues found with the job, one per line.
+	Warnings string
+}
+
+// Apply is used to ensure the job is registered as specified: the job is
+// only registered if it differs from the registered job, if any. The job is
+// compared to the registered job by planning it, so both are canonicalized
+// by the servers and defaults they fill in don't count as changes. The
+// registration is conditioned on the job modify index seen by the plan, so
+// a *JobModifyIndexError is returned if the job changed concurrently.
+func (j *Jobs) Apply(job *Job, q *WriteOptions) (*JobApplyResult, error) {
+	plan, _, err := j.Plan(job, true, q)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
-	return resp.EvalID, wm, nil
+	result := &JobApplyResult{Diff: plan.Diff}
+	if plan.Diff != nil && plan.Diff.Type == DiffTypeNone {
+		result.JobModifyIndex = plan.JobModifyIndex
+		return result, nil
+	}
+
+	opts := &RegisterOptions{EnforceIndex: true, ModifyIndex: plan.JobModifyIndex}
+	resp, _, err := j.RegisterOpts(job, opts, q)
+	if err != nil {
+		return nil, err
+	}
+	result.Changed = true
+	result.EvalID = resp.EvalID
+	result.JobModifyIndex = resp.JobModifyIndex
+	result.Warnings = resp.Warnings
+	return result, nil
 }
 
 // List is used to list all of the existing jobs.
 func (j *Jobs) List(q *QueryOptions) ([]*JobListStub, *QueryMeta, error) {
+	return j.ListWithOpts(nil, q)
+}
+
+// ListByDriver is used to list the jobs with a task using the given driver,
+// such as to audit the jobs left to migrate off a driver. Drivers are only
+// part of full jobs, so every listed job is fetched: the cost grows with the
+// number of jobs and narrowing the listing with a Prefix in the query
+// options is recommended on large clusters. Jobs deleted while listing are
+// skipped. The query meta is that of the listing.
+func (j *Jobs) ListByDriver(driver string, q *QueryOptions) ([]*Job, *QueryMeta, error) {
+	stubs, qm, err := j.List(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var iq *QueryOptions
+	if q != nil {
+		iq = &QueryOptions{Region: q.Region, Namespace: q.Namespace, AllowStale: q.AllowStale, AuthToken: q.AuthToken}
+	}
+	var jobs []*Job
+	for _, stub := range stubs {
+		job, _, err := j.Info(stub.ID, iq)
+		if err != nil {
+			if strings.Contains(err.Error(), "Unexpected response code: 404") {
+				continue
+			}
+			return nil, nil, fmt.Errorf("failed to fetch job %q: %v", stub.ID, err)
+		}
+		if job.UsesDriver(driver) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, qm, nil
+}
+
+// JobListOptions holds the filters of a job listing.
+type JobListOptions struct {
+	// ParentID restricts the listing to the child jobs of the given
+	// periodic or parameterized job.
+	ParentID string
+}
+
+// ListWithOpts is used to list the jobs matching the given options.
+func (j *Jobs) ListWithOpts(opts *JobListOptions, q *QueryOptions) ([]*JobListStub, *QueryMeta, error) {
+	endpoint := "/v1/jobs"
+	if opts != nil && opts.ParentID != "" {
+		v := url.Values{}
+		v.Set("parent", opts.ParentID)
+		endpoint += "?" + v.Encode()
+	}
+
 	var resp []*JobListStub
-	qm, err := j.client.query("/v1/jobs", &resp, q)
+	qm, err := j.client.query(endpoint, &resp, q)
 	if err != nil {
 		return nil, qm, err
 	}
-	sort.Sort(JobIDSort(resp))
+	sortJobs(resp, q)
 	return resp, qm, nil
 }
 
+// ListAllRegions is used to list the jobs of every region of the cluster.
+// The regions are queried concurrently and the results are keyed by region.
+// If querying some regions fails, the jobs of the other regions are still
+// returned along with a RegionErrors holding the error of each failed region.
+func (j *Jobs) ListAllRegions(q *QueryOptions) (map[string][]*JobListStub, error) {
+	regions, err := j.client.Regions().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string][]*JobListStub, len(regions))
+	errs := make(RegionErrors)
+	for _, region := range regions {
+		rq := new(QueryOptions)
+		if q != nil {
+			*rq = *q
+		}
+		rq.Region = region
+
+		wg.Add(1)
+		go func(region string, rq *QueryOptions) {
+			defer wg.Done()
+			jobs, _, err := j.List(rq)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs[region] = err
+				return
+			}
+			results[region] = jobs
+		}(region, rq)
+	}
+	wg.Wait()
+
+	if len(errs) != 0 {
+		return results, errs
+	}
+	return results, nil
+}
+
+// RegionErrors holds the errors of the regions that failed during a query
+// fanned out to all regions.
+type RegionErrors map[string]error
+
+func (r RegionErrors) Error() string {
+	regions := make([]string, 0, len(r))
+	for region := range r {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	msgs := make([]string, 0, len(regions))
+	for _, region := range regions {
+		msgs = append(msgs, fmt.Sprintf("region %q: %v", region, r[region]))
+	}
+	return fmt.Sprintf("failed querying %d region(s): %s", len(r), strings.Join(msgs, "; "))
+}
+
 // PrefixList is used to list all existing jobs that match the prefix.
 func (j *Jobs) PrefixList(prefix string) ([]*JobListStub, *QueryMeta, error) {
 	return j.List(&QueryOptions{Prefix: prefix})
 }
 
+// Children is used to list the child jobs launched by a periodic job or
+// dispatched from a parameterized job. The IDs of child jobs are prefixed
+// by the ID of their parent, so the listing is narrowed by prefix and
+// filtered on the parent by the servers. The ParentID of the listed jobs is
+// still matched, as servers that don't support the parent filter ignore it.
+func (j *Jobs) Children(jobID string, q *QueryOptions) ([]*JobListStub, *QueryMeta, error) {
+	cq := new(QueryOptions)
+	if q != nil {
+		*cq = *q
+	}
+	cq.Prefix = jobID + "/"
+
+	jobs, qm, err := j.ListWithOpts(&JobListOptions{ParentID: jobID}, cq)
+	if err != nil {
+		return nil, qm, err
+	}
+	children := make([]*JobListStub, 0, len(jobs))
+	for _, job := range jobs {
+		if job.ParentID == jobID {
+			children = append(children, job)
+		}
+	}
+	return children, qm, nil
+}
+
 // Info is used to retrieve information about a particular
 // job given its unique ID.
 func (j *Jobs) Info(jobID string, q *QueryOptions) (*Job, *QueryMeta, error) {
@@ -85,9 +596,31 @@ func (j *Jobs) Info(jobID string, q *QueryOptions) (*Job, *QueryMeta, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+
+	// Tokens are write-only and should never be returned
+	resp.VaultToken = ""
+	resp.ConsulToken = ""
 	return &resp, qm, nil
 }
 
+// InfoWithSummary is used to retrieve a job along with the summary of its
+// allocations in a single request.
+func (j *Jobs) InfoWithSummary(jobID string, q *QueryOptions) (*Job, *JobSummary, *QueryMeta, error) {
+	var resp jobWithSummaryResponse
+	qm, err := j.client.query("/v1/job/"+jobID+"?summary=true", &resp, q)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if resp.Job == nil {
+		return nil, nil, nil, fmt.Errorf("job %q not found", jobID)
+	}
+
+	// Tokens are write-only and should never be returned
+	resp.Job.VaultToken = ""
+	resp.Job.ConsulToken = ""
+	return resp.Job, resp.Summary, qm, nil
+}
+
 // Allocations is used to return the allocs for a given job ID.
 func (j *Jobs) Allocations(jobID string, q *QueryOptions) ([]*AllocationListStub, *QueryMeta, error) {
 	var resp []*AllocationListStub
@@ -99,6 +632,66 @@ func (j *Jobs) Allocations(jobID string, q *QueryOptions) ([]*AllocationListStub
 	return resp, qm, nil
 }
 
+// Restart is used to restart every task of every running allocation of a
+// task group of a job, such as after rotating a secret all instances must
+// pick up. The IDs of the restarted allocations are returned. Allocations
+// that fail to restart don't stop the others from being restarted; their
+// errors are returned along with the allocations that were restarted.
+func (j *Jobs) Restart(jobID, group string, q *WriteOptions) ([]string, error) {
+	var aq *QueryOptions
+	if q != nil {
+		aq = &QueryOptions{Region: q.Region, Namespace: q.Namespace, AuthToken: q.AuthToken}
+	}
+	stubs, _, err := j.Allocations(jobID, aq)
+	if err != nil {
+		return nil, err
+	}
+
+	var restarted []string
+	var mErr multierror.Error
+	found := false
+	for _, stub := range stubs {
+		if stub.TaskGroup != group || stub.DesiredStatus != AllocDesiredStatusRun ||
+			stub.ClientStatus != AllocClientStatusRunning {
+			continue
+		}
+		found = true
+
+		alloc := &Allocation{ID: stub.ID, NodeID: stub.NodeID}
+		if err := j.client.Allocations().Restart(alloc, "", q); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("alloc %q: %v", stub.ID, err))
+			continue
+		}
+		restarted = append(restarted, stub.ID)
+	}
+	if !found {
+		return nil, fmt.Errorf("job %q has no running allocations of group %q", jobID, group)
+	}
+	return restarted, mErr.ErrorOrNil()
+}
+
+// Deployments is used to query the deployments of the given job ID, both
+// active and finished, newest first. Each deployment carries its final
+// status and the version of the job it rolled out. If all is set, the
+// deployments of earlier jobs registered under the same ID, which were
+// purged and registered again, are included. Agents that predate
+// deployments aren't sent the request, which fails with an
+// EndpointUnsupportedError.
+func (j *Jobs) Deployments(jobID string, all bool, q *QueryOptions) ([]*Deployment, *QueryMeta, error) {
+	endpoint := "/v1/job/" + jobID + "/deployments"
+	if all {
+		endpoint += "?all=true"
+	}
+
+	var resp []*Deployment
+	qm, err := j.client.query(endpoint, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(DeploymentIndexSort(resp))
+	return resp, qm, nil
+}
+
 // Evaluations is used to query the evaluations associated with
 // the given job ID.
 func (j *Jobs) Evaluations(jobID string, q *QueryOptions) ([]*Evaluation, *QueryMeta, error) {
@@ -111,20 +704,197 @@ func (j *Jobs) Evaluations(jobID string, q *QueryOptions) ([]*Evaluation, *Query
 	return resp, qm, nil
 }
 
+// PlacementFailures returns why the job can't currently be placed: the
+// metrics of the failed placements of each task group, keyed by task group,
+// from the latest blocked evaluation of the job. Blocked evaluations wait
+// for capacity on behalf of the evaluation that failed to place the job,
+// which holds the metrics if the blocked evaluation does not. No failures
+// are returned if the job has no blocked evaluation.
+func (j *Jobs) PlacementFailures(jobID string, q *QueryOptions) (map[string]*AllocationMetric, error) {
+	evals, _, err := j.Evaluations(jobID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	failures := make(map[string]*AllocationMetric)
+	for _, eval := range evals {
+		if eval.Status != EvalStatusBlocked {
+			continue
+		}
+		metrics := eval.FailedTGAllocs
+		if len(metrics) == 0 {
+			for _, prev := range evals {
+				if prev.ID == eval.PreviousEval {
+					metrics = prev.FailedTGAllocs
+					break
+				}
+			}
+		}
+		for group, metric := range metrics {
+			failures[group] = metric
+		}
+		break
+	}
+	return failures, nil
+}
+
 // Deregister is used to remove an existing job.
 func (j *Jobs) Deregister(jobID string, q *WriteOptions) (string, *WriteMeta, error) {
+	return j.DeregisterOpts(jobID, nil, q)
+}
+
+// DeregisterOptions is used to control how a job is deregistered.
+type DeregisterOptions struct {
+	// EnforceIndex only deregisters the job if its job modify index matches
+	// ModifyIndex.
+	EnforceIndex bool
+	ModifyIndex  uint64
+
+	// Purge removes the job from the servers immediately rather than
+	// stopping it. Servers that do not support stopping a job without
+	// purging it ignore it.
+	Purge bool
+
+	// EvalPriority, if set, is the priority of the evaluation created for
+	// the deregistration, such as a low priority to keep mass teardowns from
+	// delaying other work.
+	EvalPriority int
+
+	// NoShutdownDelay stops the tasks of the job without waiting for their
+	// shutdown delays, such as to kill a job quickly during an incident.
+	NoShutdownDelay bool
+
+	// Global stops a multiregion job in every region it is deployed to
+	// rather than only in the targeted region. It is rejected for jobs that
+	// are not multiregion.
+	Global bool
+}
+
+// DeregisterOpts is used to remove an existing job with the given options,
+// returning the ID of the created evaluation. If the job modify index is
+// enforced and doesn't match, a *JobModifyIndexError is returned.
+func (j *Jobs) DeregisterOpts(jobID string, opts *DeregisterOptions, q *WriteOptions) (string, *WriteMeta, error) {
+	endpoint := "/v1/job/" + jobID
+	var override int
+	if opts != nil {
+		override = opts.EvalPriority
+	}
+	priority, err := evalPriority(override, q)
+	if err != nil {
+		return "", nil, err
+	}
+
+	v := url.Values{}
+	if priority != 0 {
+		v.Set("eval_priority", strconv.Itoa(priority))
+	}
+	if opts != nil {
+		if opts.Global {
+			multiregion, err := j.isMultiregion(jobID, q)
+			if err != nil {
+				return "", nil, err
+			}
+			if !multiregion {
+				return "", nil, fmt.Errorf("job %q is not multiregion, it can't be stopped globally", jobID)
+			}
+		}
+		if opts.Purge {
+			v.Set("purge", "true")
+		}
+		if opts.EnforceIndex {
+			v.Set("enforce_index", "true")
+			v.Set("job_modify_index", strconv.FormatUint(opts.ModifyIndex, 10))
+		}
+		if opts.NoShutdownDelay {
+			v.Set("no_shutdown_delay", "true")
+		}
+		if opts.Global {
+			v.Set("global", "true")
+		}
+	}
+	if len(v) != 0 {
+		endpoint += "?" + v.Encode()
+	}
+
 	var resp deregisterJobResponse
-	wm, err := j.client.delete("/v1/job/"+jobID, &resp, q)
+	wm, err := j.client.delete(endpoint, nil, &resp, q)
 	if err != nil {
+		if opts != nil && opts.EnforceIndex {
+			err = parseJobModifyIndexError(jobID, err)
+		}
 		return "", nil, err
 	}
 	return resp.EvalID, wm, nil
 }
 
-// ForceEvaluate is used to force-evaluate an existing job.
+// isMultiregion returns whether the job with the given ID is a multiregion
+// job.
+func (j *Jobs) isMultiregion(jobID string, q *WriteOptions) (bool, error) {
+	var iq *QueryOptions
+	if q != nil {
+		iq = &QueryOptions{Region: q.Region, Namespace: q.Namespace, AuthToken: q.AuthToken}
+	}
+	job, _, err := j.Info(jobID, iq)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up job %q: %v", jobID, err)
+	}
+	return job.IsMultiregion(), nil
+}
+
+// EnforceDeregister is used to remove an existing job only if its job modify
+// index matches the given index. If the job was modified in the meantime, a
+// *JobModifyIndexError holding the current index is returned. Servers that
+// do not support stopping a job without purging it ignore purge.
+func (j *Jobs) EnforceDeregister(jobID string, purge bool, modifyIndex uint64, q *WriteOptions) (string, *WriteMeta, error) {
+	return j.DeregisterOpts(jobID, &DeregisterOptions{
+		EnforceIndex: true,
+		ModifyIndex:  modifyIndex,
+		Purge:        purge,
+	}, q)
+}
+
+// ForceEvaluate is used to force-evaluate an existing job. The created
+// evaluation records DefaultForceEvaluateReason as its trigger reason.
 func (j *Jobs) ForceEvaluate(jobID string, q *WriteOptions) (string, *WriteMeta, error) {
-	var resp registerJobResponse
-	wm, err := j.client.write("/v1/job/"+jobID+"/evaluate", nil, &resp, q)
+	return j.forceEvaluate(jobID, EvalOptions{}, q)
+}
+
+// EvaluateWithOpts is used to force-evaluate an existing job, recording the
+// reason and priority given in the options on the created evaluation. The
+// created evaluation is returned.
+func (j *Jobs) EvaluateWithOpts(jobID string, opts EvalOptions, q *WriteOptions) (*Evaluation, *WriteMeta, error) {
+	evalID, wm, err := j.forceEvaluate(jobID, opts, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var qo *QueryOptions
+	if q != nil {
+		qo = &QueryOptions{Region: q.Region, Namespace: q.Namespace, AuthToken: q.AuthToken}
+	}
+	eval, _, err := j.client.Evaluations().Info(evalID, qo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return eval, wm, nil
+}
+
+// forceEvaluate issues the evaluate request and returns the created eval ID.
+func (j *Jobs) forceEvaluate(jobID string, opts EvalOptions, q *WriteOptions) (string, *WriteMeta, error) {
+	if opts.Reason == "" {
+		opts.Reason = DefaultForceEvaluateReason
+	}
+	var err error
+	if opts.Priority, err = evalPriority(opts.Priority, q); err != nil {
+		return "", nil, err
+	}
+	req := &JobEvaluateRequest{
+		JobID:       jobID,
+		EvalOptions: opts,
+	}
+
+	var resp JobRegisterResponse
+	wm, err := j.client.write("/v1/job/"+jobID+"/evaluate", req, &resp, q)
 	if err != nil {
 		return "", nil, err
 	}
@@ -141,17 +911,58 @@ func (j *Jobs) PeriodicForce(jobID string, q *WriteOptions) (string, *WriteMeta,
 	return resp.EvalID, wm, nil
 }
 
+// Dispatch is used to dispatch a new instance of a parameterized job. The
+// optional payload is made available to tasks declaring a DispatchPayload.
+// Agents that predate dispatches aren't sent the request, which fails with
+// an EndpointUnsupportedError.
+func (j *Jobs) Dispatch(jobID string, meta map[string]string,
+	payload []byte, q *WriteOptions) (*JobDispatchResponse, *WriteMeta, error) {
+	priority, err := evalPriority(0, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	var resp JobDispatchResponse
+	req := &JobDispatchRequest{
+		JobID:        jobID,
+		Meta:         meta,
+		Payload:      payload,
+		EvalPriority: priority,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/dispatch", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 func (j *Jobs) Plan(job *Job, diff bool, q *WriteOptions) (*JobPlanResponse, *WriteMeta, error) {
 	if job == nil {
 		return nil, nil, fmt.Errorf("must pass non-nil job")
 	}
+	q, err := job.writeOptions(q, j.client.config.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts, err := j.currentCounts(job, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	submitted, err := job.prepareSubmit(counts)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	return j.plan(submitted, diff, q)
+}
+
+// plan plans the prepared job to submit.
+func (j *Jobs) plan(submitted *Job, diff bool, q *WriteOptions) (*JobPlanResponse, *WriteMeta, error) {
 	var resp JobPlanResponse
 	req := &JobPlanRequest{
-		Job:  job,
+		Job:  submitted,
 		Diff: diff,
 	}
-	wm, err := j.client.write("/v1/job/"+job.ID+"/plan", req, &resp, q)
+	wm, err := j.client.write("/v1/job/"+submitted.ID+"/plan", req, &resp, q)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -168,6 +979,111 @@ func (j *Jobs) Summary(jobID string, q *QueryOptions) (*JobSummary, *QueryMeta,
 	return &resp, qm, nil
 }
 
+// ScaleStatus is used to retrieve the scaling status of the task groups of
+// a job, along with their recent scaling events. Agents that predate
+// scaling aren't sent the request, which fails with an
+// EndpointUnsupportedError.
+func (j *Jobs) ScaleStatus(jobID string, q *QueryOptions) (*JobScaleStatusResponse, *QueryMeta, error) {
+	var resp JobScaleStatusResponse
+	qm, err := j.client.query("/v1/job/"+jobID+"/scale", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Scale is used to set the count of a task group of a job, recording the
+// message, error flag and meta as a scaling event of the group. A nil count
+// only records the event, such as an autoscaler reporting why it didn't
+// scale. Agents that predate scaling aren't sent the request, which fails
+// with an EndpointUnsupportedError.
+func (j *Jobs) Scale(jobID, group string, count *int, message string, isError bool,
+	meta map[string]interface{}, q *WriteOptions) (*JobRegisterResponse, *WriteMeta, error) {
+
+	if jobID == "" || group == "" {
+		return nil, nil, fmt.Errorf("job ID and task group must be specified")
+	}
+	req := &ScalingRequest{
+		Target: map[string]string{
+			ScalingTargetJob:   jobID,
+			ScalingTargetGroup: group,
+		},
+		Message: message,
+		Error:   isError,
+		Meta:    meta,
+	}
+	if count != nil {
+		if *count < 0 {
+			return nil, nil, fmt.Errorf("count can't be negative")
+		}
+		c := int64(*count)
+		req.Count = &c
+	} else if message == "" && !isError {
+		return nil, nil, fmt.Errorf("a count, message or error must be specified")
+	}
+	var err error
+	if req.EvalPriority, err = evalPriority(0, q); err != nil {
+		return nil, nil, err
+	}
+
+	var resp JobRegisterResponse
+	wm, err := j.client.write("/v1/job/"+jobID+"/scale", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Revert is used to revert a job to an earlier version. If
+// enforcePriorVersion is set, the job is only reverted if its current
+// version matches it. The Vault token of the write options is submitted for
+// the reverted job. Agents that predate reverts aren't sent the request,
+// which fails with an EndpointUnsupportedError.
+func (j *Jobs) Revert(jobID string, version uint64, enforcePriorVersion *uint64,
+	q *WriteOptions) (*JobRegisterResponse, *WriteMeta, error) {
+
+	if jobID == "" {
+		return nil, nil, fmt.Errorf("missing job ID")
+	}
+	req := &JobRevertRequest{
+		JobID:               jobID,
+		JobVersion:          version,
+		EnforcePriorVersion: enforcePriorVersion,
+	}
+	if q != nil {
+		req.VaultToken = q.VaultToken
+	}
+	var err error
+	if req.EvalPriority, err = evalPriority(0, q); err != nil {
+		return nil, nil, err
+	}
+
+	var resp JobRegisterResponse
+	wm, err := j.client.write("/v1/job/"+jobID+"/revert", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Submission is used to retrieve the source that a version of a job was
+// submitted from. The servers only store the source of versions registered
+// with a Submission in their RegisterOptions, so an error is returned for
+// other versions. Agents that predate submissions aren't sent the request,
+// which fails with an EndpointUnsupportedError.
+func (j *Jobs) Submission(jobID string, version uint64, q *QueryOptions) (*JobSubmission, *QueryMeta, error) {
+	if jobID == "" {
+		return nil, nil, fmt.Errorf("missing job ID")
+	}
+	var resp JobSubmission
+	endpoint := fmt.Sprintf("/v1/job/%s/submission?version=%d", jobID, version)
+	qm, err := j.client.query(endpoint, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
 // periodicForceResponse is used to deserialize a force response
 type periodicForceResponse struct {
 	EvalID string
@@ -187,27 +1103,51 @@ type PeriodicConfig struct {
 	ProhibitOverlap bool
 }
 
+// ParameterizedJobConfig is used to configure the parameterized job.
+type ParameterizedJobConfig struct {
+	Payload      string
+	MetaRequired []string
+	MetaOptional []string
+}
+
 // Job is used to serialize a job.
 type Job struct {
-	Region            string
-	ID                string
-	ParentID          string
-	Name              string
-	Type              string
-	Priority          int
-	AllAtOnce         bool
-	Datacenters       []string
-	Constraints       []*Constraint
-	TaskGroups        []*TaskGroup
-	Update            *UpdateStrategy
-	Periodic          *PeriodicConfig
-	Meta              map[string]string
-	VaultToken        string
-	Status            string
-	StatusDescription string
-	CreateIndex       uint64
-	ModifyIndex       uint64
-	JobModifyIndex    uint64
+	Region           string `json:",omitempty"`
+	Namespace        string `json:",omitempty"`
+	ID               string
+	ParentID         string `json:",omitempty"`
+	Name             string
+	Type             string
+	Priority         int                     `json:",omitempty"`
+	AllAtOnce        bool                    `json:",omitempty"`
+	Datacenters      []string                `json:",omitempty"`
+	Constraints      []*Constraint           `json:",omitempty"`
+	TaskGroups       []*TaskGroup            `json:",omitempty"`
+	Update           *UpdateStrategy         `json:",omitempty"`
+	Periodic         *PeriodicConfig         `json:",omitempty"`
+	ParameterizedJob *ParameterizedJobConfig `json:",omitempty"`
+	Meta             map[string]string       `json:",omitempty"`
+
+	// Multiregion, if set, deploys the job to each of its regions.
+	Multiregion *Multiregion `json:",omitempty"`
+
+	// VaultToken is the Vault token of the submitter, used to validate the
+	// Vault policies of the job's tasks. It is write-only: it is never
+	// returned by reads, is not carried over by Copy and is redacted from
+	// String and GoString.
+	VaultToken string `json:",omitempty"`
+
+	// ConsulToken is the Consul token of the submitter, used to register
+	// the job's services with an ACL enabled Consul. It is write-only and
+	// handled like the VaultToken. The servers don't support Consul ACLs
+	// yet and ignore the token.
+	ConsulToken string `json:",omitempty"`
+
+	Status            string `json:",omitempty"`
+	StatusDescription string `json:",omitempty"`
+	CreateIndex       uint64 `json:",omitempty"`
+	ModifyIndex       uint64 `json:",omitempty"`
+	JobModifyIndex    uint64 `json:",omitempty"`
 }
 
 // JobSummary summarizes the state of the allocations of a job
@@ -231,6 +1171,80 @@ type TaskGroupSummary struct {
 	Lost     int
 }
 
+// JobScaleStatusResponse is the scaling status of a job
+type JobScaleStatusResponse struct {
+	JobID          string
+	JobCreateIndex uint64
+	JobModifyIndex uint64
+	JobStopped     bool
+	TaskGroups     map[string]TaskGroupScaleStatus
+}
+
+const (
+	// JobSubmissionFormatHCL2 and JobSubmissionFormatJSON are the formats of
+	// the sources of job submissions.
+	JobSubmissionFormatHCL2 = "hcl2"
+	JobSubmissionFormatJSON = "json"
+)
+
+// JobSubmission is the source a version of a job was submitted from.
+type JobSubmission struct {
+	// Source is the job specification as it was submitted.
+	Source string
+
+	// Format is the format of the source, "hcl2" or "json".
+	Format string
+
+	// VariableFlags are the HCL variables set on the command line and
+	// Variables the contents of the variable files, if any.
+	VariableFlags map[string]string `json:",omitempty"`
+	Variables     string            `json:",omitempty"`
+}
+
+// Validate is used to sanity check a job submission.
+func (s *JobSubmission) Validate() error {
+	var mErr multierror.Error
+	if s.Source == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing submission source"))
+	}
+	switch s.Format {
+	case JobSubmissionFormatHCL2, JobSubmissionFormatJSON:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid submission format %q", s.Format))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// TaskGroupScaleStatus is the scaling status of a task group. Desired is
+// the count of the group while the other counts are of its allocations.
+type TaskGroupScaleStatus struct {
+	Desired   int
+	Placed    int
+	Running   int
+	Healthy   int
+	Unhealthy int
+	Events    []*ScalingEvent
+}
+
+// ScalingEvent records a scaling action, or the failure of one, on a task
+// group.
+type ScalingEvent struct {
+	// Time is the Unix nanosecond timestamp of the event
+	Time uint64
+
+	// Count is the count the group was scaled to, if the event changed it
+	Count         *int64
+	PreviousCount int64
+
+	Message string
+	Error   bool
+	Meta    map[string]interface{}
+
+	// EvalID is the evaluation created by the scaling action, if any
+	EvalID      *string
+	CreateIndex uint64
+}
+
 // JobListStub is used to return a subset of information about
 // jobs during list operations.
 type JobListStub struct {
@@ -239,6 +1253,7 @@ type JobListStub struct {
 	Name              string
 	Type              string
 	Priority          int
+	Datacenters       []string
 	Status            string
 	StatusDescription string
 	JobSummary        *JobSummary
@@ -262,6 +1277,32 @@ func (j JobIDSort) Swap(a, b int) {
 	j[a], j[b] = j[b], j[a]
 }
 
+// JobIndexSort reverse sorts jobs by CreateIndex.
+type JobIndexSort []*JobListStub
+
+func (j JobIndexSort) Len() int {
+	return len(j)
+}
+
+func (j JobIndexSort) Less(a, b int) bool {
+	return j[a].CreateIndex > j[b].CreateIndex
+}
+
+func (j JobIndexSort) Swap(a, b int) {
+	j[a], j[b] = j[b], j[a]
+}
+
+// sortJobs sorts listed jobs by ID, or newest first if the query options
+// request the reverse order. Only the jobs of one response are sorted, so
+// the order doesn't hold across responses.
+func sortJobs(jobs []*JobListStub, q *QueryOptions) {
+	if q != nil && q.Reverse {
+		sort.Stable(JobIndexSort(jobs))
+		return
+	}
+	sort.Sort(JobIDSort(jobs))
+}
+
 // NewServiceJob creates and returns a new service-style job
 // for long-lived processes using the provided name, ID, and
 // relative job priority.
@@ -276,6 +1317,42 @@ func NewBatchJob(id, name, region string, pri int) *Job {
 	return newJob(id, name, region, JobTypeBatch, pri)
 }
 
+// NewSystemJob creates and returns a new system job in the "global" region
+// with the default priority. System jobs run one allocation of each of their
+// task groups on every eligible node, so the counts of their task groups are
+// not used.
+func NewSystemJob(id, name string) *Job {
+	return newJob(id, name, "global", JobTypeSystem, JobDefaultPriority)
+}
+
+// NewExecJob creates and returns a complete service job that runs a single
+// long-lived command with the exec driver in the "global" region and "dc1"
+// datacenter. The job is schedulable as is and is meant as a starting point
+// to customize.
+func NewExecJob(id, name string) *Job {
+	task := NewTask(name, "exec").
+		SetConfig("command", "/bin/sleep").
+		SetConfig("args", []string{"3600"}).
+		Require(&Resources{
+			CPU:      100,
+			MemoryMB: 128,
+		}).
+		SetLogConfig(&LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		})
+
+	group := NewTaskGroup(name, 1).
+		AddTask(task).
+		RequireDisk(&EphemeralDisk{
+			SizeMB: 300,
+		})
+
+	return NewServiceJob(id, name, "global", JobDefaultPriority).
+		AddDatacenter("dc1").
+		AddTaskGroup(group)
+}
+
 // newJob is used to create a new Job struct.
 func newJob(id, name, region, typ string, pri int) *Job {
 	return &Job{
@@ -287,6 +1364,22 @@ func newJob(id, name, region, typ string, pri int) *Job {
 	}
 }
 
+// SetType sets the type of the job. An error is returned, leaving the type
+// unchanged, if the type is not one of the known job types.
+func (j *Job) SetType(typ string) error {
+	if !validJobType(typ) {
+		return fmt.Errorf("unknown job type %q", typ)
+	}
+	j.Type = typ
+	return nil
+}
+
+// IsSystem returns whether the job places its task groups on every eligible
+// node rather than by count.
+func (j *Job) IsSystem() bool {
+	return j.Type == JobTypeSystem
+}
+
 // SetMeta is used to set arbitrary k/v pairs of metadata on a job.
 func (j *Job) SetMeta(key, val string) *Job {
 	if j.Meta == nil {
@@ -302,9 +1395,10 @@ func (j *Job) AddDatacenter(dc string) *Job {
 	return j
 }
 
-// Constrain is used to add a constraint to a job.
+// Constrain is used to add a constraint to a job. Constraints identical to
+// an existing constraint of the job are skipped.
 func (j *Job) Constrain(c *Constraint) *Job {
-	j.Constraints = append(j.Constraints, c)
+	j.Constraints = addConstraint(j.Constraints, c)
 	return j
 }
 
@@ -320,16 +1414,386 @@ func (j *Job) AddPeriodicConfig(cfg *PeriodicConfig) *Job {
 	return j
 }
 
+// IsPeriodic returns whether the job is an enabled periodic job.
+func (j *Job) IsPeriodic() bool {
+	return j.Periodic != nil && j.Periodic.Enabled
+}
+
+// UsesDriver returns whether any task of the job uses the given driver.
+func (j *Job) UsesDriver(driver string) bool {
+	for _, tg := range j.TaskGroups {
+		for _, task := range tg.Tasks {
+			if task.Driver == driver {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsParameterized returns whether the job is a parameterized job.
+func (j *Job) IsParameterized() bool {
+	return j.ParameterizedJob != nil
+}
+
+// IsMultiregion returns whether the job is deployed to multiple regions.
+func (j *Job) IsMultiregion() bool {
+	return j.Multiregion != nil && len(j.Multiregion.Regions) != 0
+}
+
+// SetMultiregion is used to deploy the job to the regions of the
+// multiregion block.
+func (j *Job) SetMultiregion(m *Multiregion) *Job {
+	j.Multiregion = m
+	return j
+}
+
+// Copy returns a deep copy of the job. Write-only tokens are not copied.
+func (j *Job) Copy() *Job {
+	if j == nil {
+		return nil
+	}
+	c, err := copystructure.Copy(j)
+	if err != nil {
+		panic(err)
+	}
+	nj := c.(*Job)
+	nj.VaultToken = ""
+	nj.ConsulToken = ""
+	return nj
+}
+
+// redacted returns a shallow copy of the job with its tokens redacted.
+func (j *Job) redacted() *Job {
+	rj := *j
+	if rj.VaultToken != "" {
+		rj.VaultToken = "<redacted>"
+	}
+	if rj.ConsulToken != "" {
+		rj.ConsulToken = "<redacted>"
+	}
+	return &rj
+}
+
+// jobFormat is used to format a job without its String and GoString methods.
+type jobFormat Job
+
+// String returns the job formatted as %+v with its tokens redacted.
+func (j *Job) String() string {
+	if j == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%+v", (*jobFormat)(j.redacted()))
+}
+
+// GoString returns the job formatted as %#v with its tokens redacted.
+func (j *Job) GoString() string {
+	if j == nil {
+		return "(*api.Job)(nil)"
+	}
+	return fmt.Sprintf("%#v", (*jobFormat)(j.redacted()))
+}
+
+// Warnings returns the problems with the job that do not prevent it from
+// being run but are likely mistakes, one per line. It is meant to be called
+// after Canonicalize and is independent of Validate: a job may have warnings
+// without being invalid and the other way around. Suspicious values, such
+// as task groups with a count of 0 or a job without datacenters, and
+// suspicious constraints are reported without contacting the servers.
+func (j *Job) Warnings() []string {
+	var warnings []string
+	if len(j.Datacenters) == 0 && !j.multiregionDatacenters() {
+		warnings = append(warnings, "Job has no datacenters, so it can't be placed")
+	}
+	for _, warning := range constraintWarnings(j.Constraints) {
+		warnings = append(warnings, "Job: "+warning)
+	}
+
+	for _, tg := range j.TaskGroups {
+		if tg.Count != nil && *tg.Count == 0 {
+			warnings = append(warnings, fmt.Sprintf("Task group %s has a count of 0, so it won't run any allocations", tg.Name))
+		}
+		for _, warning := range constraintWarnings(tg.Constraints) {
+			warnings = append(warnings, fmt.Sprintf("Task group %s: %s", tg.Name, warning))
+		}
+
+		for _, task := range tg.Tasks {
+			var taskWarnings []string
+			if err := task.Warnings(); err != nil {
+				for _, warning := range err.(*multierror.Error).Errors {
+					taskWarnings = append(taskWarnings, warning.Error())
+				}
+			}
+			taskWarnings = append(taskWarnings, constraintWarnings(task.Constraints)...)
+			for _, warning := range taskWarnings {
+				warnings = append(warnings, fmt.Sprintf("Task %s in group %s: %s", task.Name, tg.Name, warning))
+			}
+		}
+	}
+	return warnings
+}
+
+// multiregionDatacenters returns whether every region of a multiregion job
+// sets its own datacenters.
+func (j *Job) multiregionDatacenters() bool {
+	if !j.IsMultiregion() {
+		return false
+	}
+	for _, region := range j.Multiregion.Regions {
+		if region == nil || len(region.Datacenters) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeWarnings joins the local warnings of a job with the warnings returned
+// by the servers, one per line.
+func mergeWarnings(local []string, remote string) string {
+	lines := append([]string(nil), local...)
+	if remote != "" {
+		lines = append(lines, remote)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withTokens returns the job to submit for a write, overriding its Vault
+// token with that of the write options. The job itself is left untouched.
+func (j *Job) withTokens(q *WriteOptions) *Job {
+	if q == nil || q.VaultToken == "" {
+		return j
+	}
+	sj := *j
+	sj.VaultToken = q.VaultToken
+	return &sj
+}
+
+// evalPriority returns the priority of the evaluation created by a job write:
+// the override set in the options of the write, or else the priority of the
+// write options. A zero priority is unset.
+func evalPriority(override int, q *WriteOptions) (int, error) {
+	priority := override
+	if priority == 0 && q != nil {
+		priority = q.EvalPriority
+	}
+	if err := validateEvalPriority(priority); err != nil {
+		return 0, err
+	}
+	return priority, nil
+}
+
+// validateEvalPriority validates the priority requested for the evaluation
+// created by a job write. A zero priority is unset.
+func validateEvalPriority(priority int) error {
+	if priority == 0 {
+		return nil
+	}
+	if priority < JobMinPriority || priority > JobMaxPriority {
+		return fmt.Errorf("eval priority must be between [%d, %d]", JobMinPriority, JobMaxPriority)
+	}
+	return nil
+}
+
+// feasibleNode returns whether the node satisfies the constraints of the
+// job and those of at least one of its task groups and their tasks.
+func (j *Job) feasibleNode(node *Node) bool {
+	for _, c := range j.Constraints {
+		if !c.matchesNode(node) {
+			return false
+		}
+	}
+	if len(j.TaskGroups) == 0 {
+		return true
+	}
+
+GROUPS:
+	for _, tg := range j.TaskGroups {
+		for _, c := range tg.Constraints {
+			if !c.matchesNode(node) {
+				continue GROUPS
+			}
+		}
+		for _, task := range tg.Tasks {
+			for _, c := range task.Constraints {
+				if !c.matchesNode(node) {
+					continue GROUPS
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// writeOptions returns the write options to submit the job with, targeting
+// the namespace of the job. It is an error for the job to name a namespace
+// other than that of the write options or, if they don't name one, the
+// namespace configured for the client.
+func (j *Job) writeOptions(q *WriteOptions, configNamespace string) (*WriteOptions, error) {
+	if j.Namespace == "" {
+		return q, nil
+	}
+	namespace := configNamespace
+	if q != nil && q.Namespace != "" {
+		namespace = q.Namespace
+	}
+	if namespace != "" && namespace != j.Namespace {
+		return nil, fmt.Errorf("job namespace %q does not match the write namespace %q", j.Namespace, namespace)
+	}
+
+	wq := new(WriteOptions)
+	if q != nil {
+		*wq = *q
+	}
+	wq.Namespace = j.Namespace
+	return wq, nil
+}
+
+// Canonicalize fills in the defaults of unset fields of the job.
+func (j *Job) Canonicalize() {
+	if j.Priority == 0 {
+		j.Priority = JobDefaultPriority
+	}
+	for _, tg := range j.TaskGroups {
+		tg.Canonicalize()
+	}
+}
+
+// prepareSubmit returns the canonicalized copy of the job to submit to the
+// servers, after validating it. The unset counts of its task groups are
+// filled in from the given counts by group name first. The job itself is
+// left untouched.
+func (j *Job) prepareSubmit(counts map[string]int) (*Job, error) {
+	if j == nil {
+		return nil, fmt.Errorf("must pass non-nil job")
+	}
+	submitted := j.Copy()
+	submitted.VaultToken = j.VaultToken
+	submitted.ConsulToken = j.ConsulToken
+	for _, tg := range submitted.TaskGroups {
+		if count, ok := counts[tg.Name]; ok && tg.Count == nil {
+			tg.SetCount(count)
+		}
+	}
+	submitted.Canonicalize()
+	if err := submitted.Validate(); err != nil {
+		return nil, err
+	}
+	return submitted, nil
+}
+
+// Validate is used to sanity check a job before it is submitted. It only
+// checks the parts of the job that can be verified without the server.
+func (j *Job) Validate() error {
+	var mErr multierror.Error
+	if j.Type != "" && !validJobType(j.Type) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Unknown job type %q", j.Type))
+	}
+	if j.Priority < JobMinPriority || j.Priority > JobMaxPriority {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Job priority must be between [%d, %d]", JobMinPriority, JobMaxPriority))
+	}
+	for idx, constr := range j.Constraints {
+		if err := constr.Validate(); err != nil {
+			outer := fmt.Errorf("Constraint %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
+	if j.Multiregion != nil {
+		if err := j.Multiregion.Validate(); err != nil {
+			outer := fmt.Errorf("Multiregion validation failed: %s", err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
+	for _, tg := range j.TaskGroups {
+		// The counts of the task groups of system jobs are not used
+		validated := tg
+		if j.IsSystem() && tg.Count != nil {
+			copied := *tg
+			copied.Count = nil
+			validated = &copied
+		}
+		if err := validated.Validate(); err != nil {
+			outer := fmt.Errorf("Task group %s validation failed: %s", tg.Name, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+
+		// Validate the max run duration is only set on batch jobs.
+		if tg.Timeout < 0 {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Task group %s timeout must not be negative", tg.Name))
+		} else if tg.Timeout > 0 && j.Type != JobTypeBatch {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Task group %s timeout is only allowed for %q jobs", tg.Name, JobTypeBatch))
+		}
+	}
+
+	// Validate the dispatch payload of the tasks of parameterized jobs.
+	if j.IsParameterized() {
+		for _, tg := range j.TaskGroups {
+			for _, task := range tg.Tasks {
+				if task.DispatchPayload != nil && task.DispatchPayload.File == "" {
+					mErr.Errors = append(mErr.Errors,
+						fmt.Errorf("Task %s validation failed: Dispatch payload must specify a file", task.Name))
+				}
+			}
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// ScalingRequest is used to serialize a scaling action on a task group.
+type ScalingRequest struct {
+	// Count is the count to scale the group to, or nil to only record the
+	// event
+	Count   *int64 `json:",omitempty"`
+	Target  map[string]string
+	Message string
+	Error   bool
+	Meta    map[string]interface{} `json:",omitempty"`
+
+	EvalPriority int `json:",omitempty"`
+}
+
+// JobRevertRequest is used to serialize a job revert.
+type JobRevertRequest struct {
+	JobID      string
+	JobVersion uint64
+
+	// EnforcePriorVersion only reverts the job if its current version
+	// matches.
+	EnforcePriorVersion *uint64 `json:",omitempty"`
+
+	VaultToken   string `json:",omitempty"`
+	EvalPriority int    `json:",omitempty"`
+}
+
 // RegisterJobRequest is used to serialize a job registration
 type RegisterJobRequest struct {
 	Job            *Job
 	EnforceIndex   bool   `json:",omitempty"`
 	JobModifyIndex uint64 `json:",omitempty"`
+	EvalPriority   int    `json:",omitempty"`
+
+	// Submission is the source of the job, if it was registered with one.
+	Submission *JobSubmission `json:",omitempty"`
 }
 
-// registerJobResponse is used to deserialize a job response
-type registerJobResponse struct {
-	EvalID string
+// JobRegisterResponse is used to respond to a job registration
+type JobRegisterResponse struct {
+	EvalID          string
+	EvalCreateIndex uint64
+	JobModifyIndex  uint64
+
+	// Warnings are the non-fatal issues found with the job, one per line
+	Warnings string
+}
+
+// jobWithSummaryResponse is used to decode a job queried with its summary
+type jobWithSummaryResponse struct {
+	Job     *Job
+	Summary *JobSummary
 }
 
 // deregisterJobResponse is used to decode a deregister response
@@ -337,6 +1801,43 @@ type deregisterJobResponse struct {
 	EvalID string
 }
 
+// EvalOptions is used to annotate the evaluation created by a force
+// evaluate with the context in which it was triggered.
+type EvalOptions struct {
+	// Reason is a human readable explanation of why the evaluation was
+	// triggered. It is recorded as the TriggerReason of the evaluation.
+	Reason string
+
+	// Priority overrides the priority of the created evaluation. If unset,
+	// the job's priority is used.
+	Priority int `json:",omitempty"`
+}
+
+// JobEvaluateRequest is used to force-evaluate a job
+type JobEvaluateRequest struct {
+	JobID       string
+	EvalOptions EvalOptions
+}
+
+// JobDispatchRequest is used to dispatch a parameterized job
+type JobDispatchRequest struct {
+	JobID   string
+	Payload []byte
+	Meta    map[string]string
+
+	// EvalPriority, if set, is the priority of the evaluation created for
+	// the dispatched job.
+	EvalPriority int `json:",omitempty"`
+}
+
+// JobDispatchResponse is used to decode a dispatch response
+type JobDispatchResponse struct {
+	DispatchedJobID string
+	EvalID          string
+	EvalCreateIndex uint64
+	JobCreateIndex  uint64
+}
+
 type JobPlanRequest struct {
 	Job  *Job
 	Diff bool
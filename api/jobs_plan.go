@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// DiffTypeNone, DiffTypeAdded, DiffTypeDeleted and DiffTypeEdited are
+	// the types of the diffs of a plan.
+	DiffTypeNone    = "None"
+	DiffTypeAdded   = "Added"
+	DiffTypeDeleted = "Deleted"
+	DiffTypeEdited  = "Edited"
+)
+
+// ANSI escape codes used to color diffs.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiGreen  = "\x1b[32m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[93m"
+)
+
+// FormatDiff renders the diff of the plan as plain text suitable for terminal
+// output. Added, deleted and edited fields and objects are marked with "+",
+// "-" and "+/-" and nested by indentation, while unchanged ones are omitted.
+// Task groups are annotated with the updates the plan would make to their
+// allocations. An empty string is returned if the plan was made without a
+// diff.
+func (r *JobPlanResponse) FormatDiff() string {
+	return r.formatDiff(false)
+}
+
+// FormatDiffColor renders the diff of the plan like FormatDiff, coloring the
+// markers with ANSI escape codes.
+func (r *JobPlanResponse) FormatDiffColor() string {
+	return r.formatDiff(true)
+}
+
+// WarnOnDestructive returns whether applying the plan would stop existing
+// allocations, whether by stopping them, replacing them with a destructive
+// update or migrating them to other nodes.
+func (r *JobPlanResponse) WarnOnDestructive() bool {
+	if r.Annotations == nil {
+		return false
+	}
+	for _, updates := range r.Annotations.DesiredTGUpdates {
+		if updates == nil {
+			continue
+		}
+		if updates.Stop > 0 || updates.DestructiveUpdate > 0 || updates.Migrate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// diffFormatter accumulates the lines of a rendered diff.
+type diffFormatter struct {
+	buf   bytes.Buffer
+	color bool
+}
+
+func (r *JobPlanResponse) formatDiff(color bool) string {
+	if r.Diff == nil {
+		return ""
+	}
+	f := &diffFormatter{color: color}
+
+	job := r.Diff
+	f.line(0, job.Type, fmt.Sprintf("Job: %q", job.ID))
+	f.fieldsAndObjects(1, job.Fields, job.Objects)
+	for _, tg := range job.TaskGroups {
+		var updates *DesiredUpdates
+		if r.Annotations != nil {
+			updates = r.Annotations.DesiredTGUpdates[tg.Name]
+		}
+		f.taskGroup(1, tg, updates)
+	}
+	return f.buf.String()
+}
+
+func (f *diffFormatter) taskGroup(depth int, tg *TaskGroupDiff, updates *DesiredUpdates) {
+	// Unchanged task groups are only listed if the plan updates their
+	// allocations
+	if tg.Type == DiffTypeNone && !taskGroupChanged(tg) && !updatesAllocs(tg.Updates, updates) {
+		return
+	}
+
+	header := fmt.Sprintf("Task Group: %q", tg.Name)
+	if summary := formatUpdates(tg.Updates); summary != "" {
+		header += " (" + summary + ")"
+	}
+	f.line(depth, tg.Type, header)
+	f.fieldsAndObjects(depth+1, tg.Fields, tg.Objects)
+	for _, task := range tg.Tasks {
+		if task.Type == DiffTypeNone {
+			continue
+		}
+		header := fmt.Sprintf("Task: %q", task.Name)
+		if len(task.Annotations) != 0 {
+			header += " (" + f.annotations(task.Annotations) + ")"
+		}
+		f.line(depth+1, task.Type, header)
+		f.fieldsAndObjects(depth+2, task.Fields, task.Objects)
+	}
+}
+
+// updatesAllocs returns whether the plan changes the allocations of a task
+// group, given its update counts and desired updates.
+func updatesAllocs(counts map[string]uint64, updates *DesiredUpdates) bool {
+	if updates != nil && (updates.Stop > 0 || updates.Place > 0 || updates.Migrate > 0 ||
+		updates.InPlaceUpdate > 0 || updates.DestructiveUpdate > 0) {
+		return true
+	}
+	for update, count := range counts {
+		if update != "ignore" && count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// taskGroupChanged returns whether the task group or any of its tasks
+// changed.
+func taskGroupChanged(tg *TaskGroupDiff) bool {
+	for _, field := range tg.Fields {
+		if field.Type != DiffTypeNone {
+			return true
+		}
+	}
+	for _, object := range tg.Objects {
+		if object.Type != DiffTypeNone {
+			return true
+		}
+	}
+	for _, task := range tg.Tasks {
+		if task.Type != DiffTypeNone {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *diffFormatter) fieldsAndObjects(depth int, fields []*FieldDiff, objects []*ObjectDiff) {
+	for _, field := range fields {
+		if field.Type == DiffTypeNone {
+			continue
+		}
+		var value string
+		switch field.Type {
+		case DiffTypeAdded:
+			value = fmt.Sprintf("%q", field.New)
+		case DiffTypeDeleted:
+			value = fmt.Sprintf("%q", field.Old)
+		default:
+			value = fmt.Sprintf("%q => %q", field.Old, field.New)
+		}
+		text := fmt.Sprintf("%s: %s", field.Name, value)
+		if len(field.Annotations) != 0 {
+			text += " (" + f.annotations(field.Annotations) + ")"
+		}
+		f.line(depth, field.Type, text)
+	}
+	for _, object := range objects {
+		if object.Type == DiffTypeNone {
+			continue
+		}
+		f.line(depth, object.Type, object.Name+" {")
+		f.fieldsAndObjects(depth+1, object.Fields, object.Objects)
+		f.line(depth, DiffTypeNone, "}")
+	}
+}
+
+// line writes a line of the diff indented by its depth and prefixed by the
+// marker of its type.
+func (f *diffFormatter) line(depth int, diffType, text string) {
+	f.buf.WriteString(strings.Repeat("  ", depth))
+	var marker, color string
+	switch diffType {
+	case DiffTypeAdded:
+		marker, color = "+", ansiGreen
+	case DiffTypeDeleted:
+		marker, color = "-", ansiRed
+	case DiffTypeEdited:
+		marker, color = "+/-", ansiYellow
+	}
+	if marker != "" {
+		if f.color {
+			marker = color + marker + ansiReset
+		}
+		f.buf.WriteString(marker + " ")
+	}
+	f.buf.WriteString(text)
+	f.buf.WriteString("\n")
+}
+
+// annotations joins the annotations of a diff, coloring those that force
+// allocations to be replaced.
+func (f *diffFormatter) annotations(annotations []string) string {
+	out := make([]string, len(annotations))
+	for i, annotation := range annotations {
+		if f.color && strings.Contains(annotation, "create/destroy") {
+			annotation = ansiRed + annotation + ansiReset
+		}
+		out[i] = annotation
+	}
+	return strings.Join(out, ", ")
+}
+
+// formatUpdates summarizes the updates of a task group, such as "1 create,
+// 2 ignore", in a stable order.
+func formatUpdates(updates map[string]uint64) string {
+	keys := make([]string, 0, len(updates))
+	for update, count := range updates {
+		if count > 0 {
+			keys = append(keys, update)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, update := range keys {
+		parts[i] = fmt.Sprintf("%d %s", updates[update], update)
+	}
+	return strings.Join(parts, ", ")
+}
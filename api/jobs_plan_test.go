@@ -0,0 +1,101 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func testPlanResponse() *JobPlanResponse {
+	return &JobPlanResponse{
+		Diff: &JobDiff{
+			Type: DiffTypeEdited,
+			ID:   "example",
+			Fields: []*FieldDiff{
+				{Type: DiffTypeEdited, Name: "Priority", Old: "50", New: "60"},
+				{Type: DiffTypeNone, Name: "Type", Old: "service", New: "service"},
+			},
+			TaskGroups: []*TaskGroupDiff{
+				{
+					Type:    DiffTypeEdited,
+					Name:    "cache",
+					Updates: map[string]uint64{"create/destroy update": 1, "ignore": 2},
+					Fields: []*FieldDiff{
+						{Type: DiffTypeAdded, Name: "Meta[tier]", New: "gold"},
+					},
+					Tasks: []*TaskDiff{
+						{
+							Type:        DiffTypeEdited,
+							Name:        "redis",
+							Annotations: []string{"forces create/destroy update"},
+							Objects: []*ObjectDiff{{
+								Type: DiffTypeEdited,
+								Name: "Resources",
+								Fields: []*FieldDiff{
+									{Type: DiffTypeEdited, Name: "CPU", Old: "500", New: "1000"},
+									{Type: DiffTypeDeleted, Name: "IOPS", Old: "10"},
+								},
+							}},
+						},
+						{Type: DiffTypeNone, Name: "sidecar"},
+					},
+				},
+				{Type: DiffTypeNone, Name: "idle", Updates: map[string]uint64{"ignore": 3}},
+			},
+		},
+		Annotations: &PlanAnnotations{
+			DesiredTGUpdates: map[string]*DesiredUpdates{
+				"cache": {DestructiveUpdate: 1, Ignore: 2},
+				"idle":  {Ignore: 3},
+			},
+		},
+	}
+}
+
+func TestJobPlanResponse_FormatDiff(t *testing.T) {
+	resp := testPlanResponse()
+	expected := `+/- Job: "example"
+  +/- Priority: "50" => "60"
+  +/- Task Group: "cache" (1 create/destroy update, 2 ignore)
+    + Meta[tier]: "gold"
+    +/- Task: "redis" (forces create/destroy update)
+      +/- Resources {
+        +/- CPU: "500" => "1000"
+        - IOPS: "10"
+      }
+`
+	if out := resp.FormatDiff(); out != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	colored := resp.FormatDiffColor()
+	if !strings.Contains(colored, ansiYellow+"+/-"+ansiReset+" Job") ||
+		!strings.Contains(colored, ansiRed+"-"+ansiReset+" IOPS") ||
+		!strings.Contains(colored, ansiRed+"forces create/destroy update"+ansiReset) {
+		t.Fatalf("bad colored diff:\n%s", colored)
+	}
+
+	if out := (&JobPlanResponse{}).FormatDiff(); out != "" {
+		t.Fatalf("expected empty diff, got: %q", out)
+	}
+}
+
+func TestJobPlanResponse_WarnOnDestructive(t *testing.T) {
+	resp := testPlanResponse()
+	if !resp.WarnOnDestructive() {
+		t.Fatalf("expected destructive plan")
+	}
+
+	resp.Annotations.DesiredTGUpdates["cache"] = &DesiredUpdates{InPlaceUpdate: 1, Place: 2}
+	if resp.WarnOnDestructive() {
+		t.Fatalf("unexpected destructive plan")
+	}
+
+	resp.Annotations.DesiredTGUpdates["idle"].Stop = 1
+	if !resp.WarnOnDestructive() {
+		t.Fatalf("expected destructive plan")
+	}
+
+	if (&JobPlanResponse{}).WarnOnDestructive() {
+		t.Fatalf("unexpected destructive plan")
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// JobMetaSignatureKey is the reserved meta key the signature of a signed job
+// is stored under.
+const JobMetaSignatureKey = "nomad.signature"
+
+var (
+	// ErrJobNotSigned is returned when verifying a job without a signature.
+	ErrJobNotSigned = errors.New("job is not signed")
+
+	// ErrJobSignatureMismatch is returned when verifying a job whose
+	// signature doesn't match its contents.
+	ErrJobSignatureMismatch = errors.New("job signature does not match")
+)
+
+// Sign signs the job with the given key, storing the HMAC-SHA256 signature
+// of its canonical form in its meta under JobMetaSignatureKey. The job is
+// signed as canonicalized, so filling in defaults doesn't break the
+// signature, while the status and indexes maintained by the servers are not
+// signed. Defaults the servers fill in on registration that Canonicalize
+// doesn't are signed as unset, so jobs that are verified when read back
+// should set them explicitly.
+func (j *Job) Sign(key []byte) error {
+	sig, err := j.signature(key)
+	if err != nil {
+		return err
+	}
+	if j.Meta == nil {
+		j.Meta = make(map[string]string)
+	}
+	j.Meta[JobMetaSignatureKey] = sig
+	return nil
+}
+
+// Verify verifies the signature of a job signed with Sign against the given
+// key. ErrJobNotSigned is returned if the job isn't signed and
+// ErrJobSignatureMismatch if it was modified since it was signed or was
+// signed with another key.
+func (j *Job) Verify(key []byte) error {
+	sig, ok := j.Meta[JobMetaSignatureKey]
+	if !ok {
+		return ErrJobNotSigned
+	}
+	expected, err := j.signature(key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return ErrJobSignatureMismatch
+	}
+	return nil
+}
+
+// signature returns the encoded signature of the job with the given key.
+func (j *Job) signature(key []byte) (string, error) {
+	if len(key) == 0 {
+		return "", fmt.Errorf("signing key must not be empty")
+	}
+	payload, err := j.signingPayload()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// signingPayload returns the signed form of the job: the JSON encoding of
+// the canonicalized job without its signature, its tokens and the fields
+// maintained by the servers. Map keys are encoded sorted, so the payload is
+// stable.
+func (j *Job) signingPayload() ([]byte, error) {
+	sj := j.Copy()
+	sj.Canonicalize()
+	if sj.Namespace == "" {
+		sj.Namespace = DefaultNamespace
+	}
+	if _, ok := sj.Meta[JobMetaSignatureKey]; ok {
+		delete(sj.Meta, JobMetaSignatureKey)
+		if len(sj.Meta) == 0 {
+			sj.Meta = nil
+		}
+	}
+	sj.Status = ""
+	sj.StatusDescription = ""
+	sj.CreateIndex = 0
+	sj.ModifyIndex = 0
+	sj.JobModifyIndex = 0
+	return json.Marshal(sj)
+}
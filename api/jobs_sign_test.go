@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJob_SignVerify(t *testing.T) {
+	key := []byte("secret")
+	job := testJob()
+	job.SetMeta("owner", "payments")
+	if err := job.Verify(key); err != ErrJobNotSigned {
+		t.Fatalf("expected ErrJobNotSigned, got: %v", err)
+	}
+	if err := job.Sign(nil); err == nil {
+		t.Fatalf("expected empty key error")
+	}
+	if err := job.Sign(key); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if job.Meta[JobMetaSignatureKey] == "" {
+		t.Fatalf("signature not stored: %v", job.Meta)
+	}
+	if err := job.Verify(key); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reading the job back, canonicalized and with the fields maintained by
+	// the servers populated, keeps the signature valid
+	buf, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var read Job
+	if err := json.Unmarshal(buf, &read); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	read.Canonicalize()
+	read.Namespace = DefaultNamespace
+	read.Status = "running"
+	read.CreateIndex, read.ModifyIndex, read.JobModifyIndex = 10, 12, 12
+	if err := read.Verify(key); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Signing an unset priority signs the canonical default
+	unset := testJob()
+	unset.Priority = 0
+	if err := unset.Sign(key); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	unset.Priority = JobDefaultPriority
+	if err := unset.Verify(key); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Modifications and other keys are detected
+	if err := read.Verify([]byte("other")); err != ErrJobSignatureMismatch {
+		t.Fatalf("expected ErrJobSignatureMismatch, got: %v", err)
+	}
+	read.TaskGroups[0].SetCount(5)
+	if err := read.Verify(key); err != ErrJobSignatureMismatch {
+		t.Fatalf("expected ErrJobSignatureMismatch, got: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"sync"
+)
+
+const (
+	// jobStatsParallelism bounds the number of allocations whose stats are
+	// fetched concurrently by Jobs.Stats.
+	jobStatsParallelism = 16
+)
+
+// JobStats is the resource usage of the running allocations of a job.
+type JobStats struct {
+	JobID string
+
+	// TaskGroups sums the usage of the allocations of each task group.
+	TaskGroups map[string]*TaskGroupStats
+
+	// Allocations is the usage of each running allocation, keyed by its ID.
+	Allocations map[string]*JobAllocStats
+}
+
+// TaskGroupStats sums the resource usage of the running allocations of a
+// task group.
+type TaskGroupStats struct {
+	// CPUPercent and CPUTotalTicks sum the CPU usage of the allocations,
+	// and MemoryRSS and MemoryCache their memory usage in bytes.
+	CPUPercent    float64
+	CPUTotalTicks float64
+	MemoryRSS     uint64
+	MemoryCache   uint64
+
+	// Allocations is the number of running allocations summed and
+	// Unavailable the number of those whose stats couldn't be fetched,
+	// which are left out of the sums.
+	Allocations int
+	Unavailable int
+}
+
+// JobAllocStats is the resource usage of a running allocation of a job.
+type JobAllocStats struct {
+	AllocID   string
+	NodeID    string
+	TaskGroup string
+
+	// Usage is the resource usage of the allocation. It is nil if the
+	// stats are unavailable, such as when the node running the allocation
+	// is unreachable, in which case Err holds the reason.
+	Usage *AllocResourceUsage
+	Err   error
+}
+
+// Unavailable returns whether the stats of the allocation couldn't be
+// fetched.
+func (s *JobAllocStats) Unavailable() bool {
+	return s.Usage == nil
+}
+
+// Stats is used to aggregate the resource usage of the running allocations
+// of a job, summed per task group. The stats are fetched concurrently from
+// the client nodes running the allocations. Allocations whose stats can't be
+// fetched, such as those on unreachable nodes, are marked unavailable rather
+// than failing the call. If allAllocs is set, the allocations of previous
+// jobs registered with the same ID are included.
+func (j *Jobs) Stats(jobID string, allAllocs bool, q *QueryOptions) (*JobStats, error) {
+	aq := new(QueryOptions)
+	if q != nil {
+		*aq = *q
+	}
+	if allAllocs {
+		params := make(map[string]string, len(aq.Params)+1)
+		for k, v := range aq.Params {
+			params[k] = v
+		}
+		params["all"] = "true"
+		aq.Params = params
+	}
+	allocs, _, err := j.Allocations(jobID, aq)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &JobStats{
+		JobID:       jobID,
+		TaskGroups:  make(map[string]*TaskGroupStats),
+		Allocations: make(map[string]*JobAllocStats),
+	}
+	for _, alloc := range allocs {
+		if alloc.ClientStatus != AllocClientStatusRunning {
+			continue
+		}
+		stats.Allocations[alloc.ID] = &JobAllocStats{
+			AllocID:   alloc.ID,
+			NodeID:    alloc.NodeID,
+			TaskGroup: alloc.TaskGroup,
+		}
+	}
+
+	var sq *QueryOptions
+	if q != nil {
+		sq = &QueryOptions{Region: q.Region, Namespace: q.Namespace, AllowStale: q.AllowStale, AuthToken: q.AuthToken}
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, jobStatsParallelism)
+	for _, allocStats := range stats.Allocations {
+		wg.Add(1)
+		go func(allocStats *JobAllocStats) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			alloc := &Allocation{ID: allocStats.AllocID, NodeID: allocStats.NodeID}
+			usage, err := j.client.Allocations().Stats(alloc, sq)
+			if err != nil {
+				allocStats.Err = err
+				return
+			}
+			allocStats.Usage = usage
+		}(allocStats)
+	}
+	wg.Wait()
+
+	for _, allocStats := range stats.Allocations {
+		group, ok := stats.TaskGroups[allocStats.TaskGroup]
+		if !ok {
+			group = &TaskGroupStats{}
+			stats.TaskGroups[allocStats.TaskGroup] = group
+		}
+		group.Allocations++
+		if allocStats.Unavailable() {
+			group.Unavailable++
+			continue
+		}
+		group.add(allocStats.Usage.ResourceUsage)
+	}
+	return stats, nil
+}
+
+// add sums the resource usage of an allocation into the stats of its group.
+func (s *TaskGroupStats) add(usage *ResourceUsage) {
+	if usage == nil {
+		return
+	}
+	if cpu := usage.CpuStats; cpu != nil {
+		s.CPUPercent += cpu.Percent
+		s.CPUTotalTicks += cpu.TotalTicks
+	}
+	if mem := usage.MemoryStats; mem != nil {
+		s.MemoryRSS += mem.RSS
+		s.MemoryCache += mem.Cache
+	}
+}
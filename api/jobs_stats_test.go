@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJobs_Stats(t *testing.T) {
+	var all string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		switch r.URL.Path {
+		case "/v1/job/web/allocations":
+			all = r.URL.Query().Get("all")
+			fmt.Fprint(w, `[
+				{"ID": "alloc1", "NodeID": "node1", "TaskGroup": "web", "ClientStatus": "running"},
+				{"ID": "alloc2", "NodeID": "node1", "TaskGroup": "web", "ClientStatus": "running"},
+				{"ID": "alloc3", "NodeID": "node1", "TaskGroup": "cache", "ClientStatus": "running"},
+				{"ID": "alloc4", "NodeID": "node2", "TaskGroup": "web", "ClientStatus": "running"},
+				{"ID": "alloc5", "NodeID": "node1", "TaskGroup": "web", "ClientStatus": "complete"}
+			]`)
+		case "/v1/node/node1":
+			fmt.Fprintf(w, `{"ID": "node1", "HTTPAddr": %q}`, strings.TrimPrefix(srv.URL, "http://"))
+		case "/v1/node/node2":
+			// The node is unreachable
+			fmt.Fprint(w, `{"ID": "node2", "HTTPAddr": "127.0.0.1:1"}`)
+		case "/v1/client/allocation/alloc1/stats", "/v1/client/allocation/alloc2/stats":
+			fmt.Fprint(w, `{"ResourceUsage": {
+				"CpuStats": {"Percent": 12.5, "TotalTicks": 250},
+				"MemoryStats": {"RSS": 1048576, "Cache": 1024}}}`)
+		case "/v1/client/allocation/alloc3/stats":
+			fmt.Fprint(w, `{"ResourceUsage": {"MemoryStats": {"RSS": 2048}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	stats, err := c.Jobs().Stats("web", true, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if all != "true" {
+		t.Fatalf("allocations of previous jobs not requested")
+	}
+
+	// Only running allocations are summed
+	if len(stats.Allocations) != 4 {
+		t.Fatalf("bad allocations: %#v", stats.Allocations)
+	}
+	web := stats.TaskGroups["web"]
+	if web == nil || web.Allocations != 3 || web.Unavailable != 1 {
+		t.Fatalf("bad web stats: %#v", web)
+	}
+	if web.CPUPercent != 25 || web.CPUTotalTicks != 500 || web.MemoryRSS != 2097152 || web.MemoryCache != 2048 {
+		t.Fatalf("bad web usage: %#v", web)
+	}
+	if cache := stats.TaskGroups["cache"]; cache == nil || cache.MemoryRSS != 2048 || cache.Unavailable != 0 {
+		t.Fatalf("bad cache stats: %#v", cache)
+	}
+
+	// The allocation on the unreachable node is marked unavailable
+	unreachable := stats.Allocations["alloc4"]
+	if !unreachable.Unavailable() || unreachable.Err == nil {
+		t.Fatalf("expected unavailable stats: %#v", unreachable)
+	}
+	if stats.Allocations["alloc1"].Unavailable() {
+		t.Fatalf("expected available stats: %#v", stats.Allocations["alloc1"])
+	}
+
+	if _, err := c.Jobs().Stats("web", false, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if all != "" {
+		t.Fatalf("unexpected all param: %q", all)
+	}
+}
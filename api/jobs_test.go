@@ -1,11 +1,21 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/testutil"
 )
 
@@ -368,6 +378,125 @@ func TestJobs_ForceEvaluate(t *testing.T) {
 	t.Fatalf("evaluation %q missing", evalID)
 }
 
+func TestJobs_EnforceDeregister(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Register a new job
+	job := testJob()
+	_, wm, err := jobs.Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	info, _, err := jobs.Info(job.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Attempting to deregister at the wrong index fails
+	_, _, err = jobs.EnforceDeregister(job.ID, true, info.JobModifyIndex+1, nil)
+	indexErr, ok := err.(*JobModifyIndexError)
+	if !ok {
+		t.Fatalf("expected job modify index error, got: %#v", err)
+	}
+	if indexErr.Actual != info.JobModifyIndex || !indexErr.Exists {
+		t.Fatalf("bad: %#v", indexErr)
+	}
+
+	// Deregistering at the current index works
+	evalID, wm, err := jobs.EnforceDeregister(job.ID, true, info.JobModifyIndex, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if evalID == "" {
+		t.Fatalf("missing eval id")
+	}
+	assertWriteMeta(t, wm)
+}
+
+func TestJobs_ParseJobModifyIndexError(t *testing.T) {
+	cases := []struct {
+		Err      string
+		Expected uint64
+		Actual   uint64
+		Exists   bool
+	}{
+		{
+			Err:      "Unexpected response code: 500 (Enforcing job modify index 5: job exists with conflicting job modify index: 7)",
+			Expected: 5,
+			Actual:   7,
+			Exists:   true,
+		},
+		{
+			Err:    "Unexpected response code: 500 (Enforcing job modify index 0: job already exists)",
+			Exists: true,
+		},
+		{
+			Err:      "Unexpected response code: 500 (Enforcing job modify index 3: job does not exist)",
+			Expected: 3,
+		},
+	}
+
+	for _, c := range cases {
+		err := parseJobModifyIndexError("job1", errors.New(c.Err))
+		indexErr, ok := err.(*JobModifyIndexError)
+		if !ok {
+			t.Fatalf("expected job modify index error, got: %#v", err)
+		}
+		if indexErr.Expected != c.Expected || indexErr.Actual != c.Actual || indexErr.Exists != c.Exists {
+			t.Fatalf("case %q: bad: %#v", c.Err, indexErr)
+		}
+		if indexErr.Error() != c.Err {
+			t.Fatalf("bad error message: %q", indexErr.Error())
+		}
+	}
+
+	// Other errors are left as is
+	other := errors.New("Unexpected response code: 500 (permission denied)")
+	if err := parseJobModifyIndexError("job1", other); err != other {
+		t.Fatalf("bad: %#v", err)
+	}
+}
+
+func TestJobs_EvaluateWithOpts(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Create a new job
+	_, wm, err := jobs.Register(testJob(), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Force-eval with a reason returns the created evaluation
+	opts := EvalOptions{Reason: "config rotated", Priority: 80}
+	eval, wm, err := jobs.EvaluateWithOpts("job1", opts, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if eval == nil || eval.ID == "" || eval.JobID != "job1" {
+		t.Fatalf("bad: %#v", eval)
+	}
+	if eval.TriggerReason != "config rotated" || eval.Priority != 80 {
+		t.Fatalf("eval options not applied: %#v", eval)
+	}
+
+	// Without options the default reason and the job priority are used
+	eval, _, err = jobs.EvaluateWithOpts("job1", EvalOptions{}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if eval.TriggerReason != DefaultForceEvaluateReason || eval.Priority != testJob().Priority {
+		t.Fatalf("bad: %#v", eval)
+	}
+}
+
 func TestJobs_PeriodicForce(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -541,6 +670,41 @@ func TestJobs_NewBatchJob(t *testing.T) {
 	}
 }
 
+func TestJobs_NewSystemJob(t *testing.T) {
+	job := NewSystemJob("job1", "myjob")
+	expect := &Job{
+		Region:   "global",
+		ID:       "job1",
+		Name:     "myjob",
+		Type:     JobTypeSystem,
+		Priority: JobDefaultPriority,
+	}
+	if !reflect.DeepEqual(job, expect) {
+		t.Fatalf("expect: %#v, got: %#v", expect, job)
+	}
+}
+
+func TestJobs_SetType(t *testing.T) {
+	job := testJob()
+	for _, typ := range []string{JobTypeService, JobTypeBatch, JobTypeSystem} {
+		if err := job.SetType(typ); err != nil {
+			t.Fatalf("type %q: err: %s", typ, err)
+		}
+		if job.Type != typ {
+			t.Fatalf("expected type %q, got %q", typ, job.Type)
+		}
+	}
+
+	for _, typ := range []string{"cron", "sysbatch"} {
+		if err := job.SetType(typ); err == nil || !strings.Contains(err.Error(), "unknown job type") {
+			t.Fatalf("type %q: expected type error, got: %v", typ, err)
+		}
+	}
+	if job.Type != JobTypeSystem {
+		t.Fatalf("type changed to %q", job.Type)
+	}
+}
+
 func TestJobs_NewServiceJob(t *testing.T) {
 	job := NewServiceJob("job1", "myjob", "region1", 5)
 	expect := &Job{
@@ -555,6 +719,25 @@ func TestJobs_NewServiceJob(t *testing.T) {
 	}
 }
 
+func TestJobs_NewExecJob(t *testing.T) {
+	job := NewExecJob("job1", "myjob")
+	if job.ID != "job1" || job.Name != "myjob" || job.Type != JobTypeService {
+		t.Fatalf("bad: %#v", job)
+	}
+	if len(job.Datacenters) == 0 || job.Region == "" {
+		t.Fatalf("expected region and datacenters: %#v", job)
+	}
+	if len(job.TaskGroups) != 1 || len(job.TaskGroups[0].Tasks) != 1 {
+		t.Fatalf("expected a single task group with a single task: %#v", job.TaskGroups)
+	}
+	if task := job.TaskGroups[0].Tasks[0]; task.Resources == nil || task.Driver != "exec" {
+		t.Fatalf("bad task: %#v", task)
+	}
+	if err := job.Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
 func TestJobs_SetMeta(t *testing.T) {
 	job := &Job{Meta: nil}
 
@@ -627,3 +810,1736 @@ func TestJobs_Sort(t *testing.T) {
 		t.Fatalf("\n\n%#v\n\n%#v", jobs, expect)
 	}
 }
+
+func TestJobs_Validate_DispatchPayload(t *testing.T) {
+	job := testJob()
+	job.TaskGroups[0].Tasks[0].DispatchPayload = &DispatchPayload{}
+
+	// A payload without a file is fine for non-parameterized jobs
+	if err := job.Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Parameterized jobs must specify the file, which is reported once even
+	// with several groups
+	job.AddTaskGroup(NewTaskGroup("group2", 1).AddTask(NewTask("task2", "exec")))
+	job.ParameterizedJob = &ParameterizedJobConfig{Payload: "required"}
+	err := job.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Dispatch payload must specify a file") {
+		t.Fatalf("expected dispatch payload error, got: %v", err)
+	}
+	if n := len(err.(*multierror.Error).Errors); n != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", n, err)
+	}
+
+	job.TaskGroups[0].Tasks[0].DispatchPayload.File = "input.txt"
+	if err := job.Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestJobs_Validate_GroupTimeout(t *testing.T) {
+	job := testJob()
+	job.TaskGroups[0].SetTimeout(10 * time.Minute)
+	if err := job.Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	job.TaskGroups[0].Timeout = -1 * time.Second
+	if err := job.Validate(); err == nil || !strings.Contains(err.Error(), "must not be negative") {
+		t.Fatalf("expected negative timeout error, got: %v", err)
+	}
+
+	job.Type = JobTypeService
+	job.TaskGroups[0].Timeout = 10 * time.Minute
+	if err := job.Validate(); err == nil || !strings.Contains(err.Error(), "only allowed") {
+		t.Fatalf("expected batch only error, got: %v", err)
+	}
+}
+
+func TestJobs_IsPeriodic(t *testing.T) {
+	job := testJob()
+	if job.IsPeriodic() {
+		t.Fatalf("job without periodic stanza should not be periodic")
+	}
+
+	job.Periodic = &PeriodicConfig{}
+	if job.IsPeriodic() {
+		t.Fatalf("disabled periodic job should not be periodic")
+	}
+
+	if !testPeriodicJob().IsPeriodic() {
+		t.Fatalf("expected periodic job")
+	}
+}
+
+func TestJobs_IsParameterized(t *testing.T) {
+	job := testJob()
+	if job.IsParameterized() {
+		t.Fatalf("job without parameterized stanza should not be parameterized")
+	}
+
+	job.ParameterizedJob = &ParameterizedJobConfig{}
+	if !job.IsParameterized() {
+		t.Fatalf("expected parameterized job")
+	}
+}
+
+func TestJobs_ListAllRegions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		region := r.URL.Query().Get("region")
+		switch {
+		case r.URL.Path == "/v1/regions":
+			fmt.Fprint(w, `["east", "west", "broken"]`)
+		case r.URL.Path == "/v1/jobs" && region == "broken":
+			http.Error(w, "no path to region", http.StatusInternalServerError)
+		case r.URL.Path == "/v1/jobs":
+			w.Header().Set("X-Nomad-Index", "1")
+			fmt.Fprintf(w, `[{"ID": "%s-job"}]`, region)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	results, err := c.Jobs().ListAllRegions(nil)
+	regionErrs, ok := err.(RegionErrors)
+	if !ok {
+		t.Fatalf("expected region errors, got: %#v", err)
+	}
+	if len(regionErrs) != 1 || regionErrs["broken"] == nil {
+		t.Fatalf("bad: %#v", regionErrs)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("bad: %#v", results)
+	}
+	for _, region := range []string{"east", "west"} {
+		jobs := results[region]
+		if len(jobs) != 1 || jobs[0].ID != region+"-job" {
+			t.Fatalf("bad jobs for region %q: %#v", region, jobs)
+		}
+	}
+}
+
+func TestJobs_JSON_OmitEmpty(t *testing.T) {
+	job := NewServiceJob("job1", "myjob", "", 0).
+		AddTaskGroup(NewTaskGroup("grp1", 0).
+			AddTask(NewTask("task1", "exec").
+				Require(&Resources{CPU: 100})))
+
+	out, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Unset job fields are omitted
+	for _, field := range []string{"Region", "Priority", "Datacenters",
+		"Update", "Periodic", "Meta", "VaultToken", "ModifyIndex"} {
+		if _, ok := raw[field]; ok {
+			t.Fatalf("expected %q to be omitted: %s", field, out)
+		}
+	}
+
+	// A zero count is meaningful and is kept
+	grp := raw["TaskGroups"].([]interface{})[0].(map[string]interface{})
+	if count, ok := grp["Count"]; !ok || count.(float64) != 0 {
+		t.Fatalf("expected count to be serialized: %s", out)
+	}
+	for _, field := range []string{"Constraints", "RestartPolicy", "EphemeralDisk", "Meta"} {
+		if _, ok := grp[field]; ok {
+			t.Fatalf("expected group %q to be omitted: %s", field, out)
+		}
+	}
+
+	task := grp["Tasks"].([]interface{})[0].(map[string]interface{})
+	for _, field := range []string{"User", "Config", "Env", "KillTimeout", "LogConfig"} {
+		if _, ok := task[field]; ok {
+			t.Fatalf("expected task %q to be omitted: %s", field, out)
+		}
+	}
+	resources := task["Resources"].(map[string]interface{})
+	if len(resources) != 1 || resources["CPU"].(float64) != 100 {
+		t.Fatalf("expected only the set resources: %s", out)
+	}
+
+	// The job round-trips
+	var decoded Job
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(&decoded, job) {
+		t.Fatalf("expect: %#v, got: %#v", job, &decoded)
+	}
+}
+
+func TestJobs_Canonicalize_Priority(t *testing.T) {
+	job := NewServiceJob("job1", "myjob", "global", 0)
+	job.Canonicalize()
+	if job.Priority != JobDefaultPriority {
+		t.Fatalf("expected default priority, got: %d", job.Priority)
+	}
+
+	// Set priorities are kept
+	job.Priority = 70
+	job.Canonicalize()
+	if job.Priority != 70 {
+		t.Fatalf("bad priority: %d", job.Priority)
+	}
+}
+
+func TestJobs_Validate_Priority(t *testing.T) {
+	job := testJob()
+	for _, pri := range []int{JobMinPriority, JobDefaultPriority, JobMaxPriority} {
+		job.Priority = pri
+		if err := job.Validate(); err != nil {
+			t.Fatalf("priority %d: err: %s", pri, err)
+		}
+	}
+
+	for _, pri := range []int{-1, 0, JobMaxPriority + 1, 1000000} {
+		job.Priority = pri
+		if err := job.Validate(); err == nil || !strings.Contains(err.Error(), "priority must be between") {
+			t.Fatalf("priority %d: expected priority error, got: %v", pri, err)
+		}
+	}
+}
+
+func TestJobs_Validate_Type(t *testing.T) {
+	job := testJob()
+	job.Type = "cron"
+	if err := job.Validate(); err == nil || !strings.Contains(err.Error(), "Unknown job type") {
+		t.Fatalf("expected type error, got: %v", err)
+	}
+
+	// The counts of system jobs are not validated
+	job.TaskGroups[0].SetCount(-1)
+	job.Type = JobTypeService
+	if err := job.Validate(); err == nil || !strings.Contains(err.Error(), "count can't be negative") {
+		t.Fatalf("expected count error, got: %v", err)
+	}
+	job.Type = JobTypeSystem
+	if err := job.Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if job.TaskGroups[0].GetCount() != -1 {
+		t.Fatalf("validation modified the task group")
+	}
+}
+
+func TestJobs_Register_Invalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("invalid job should not be submitted")
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job := testJob()
+	job.Priority = 1000000
+	if _, _, err := c.Jobs().Register(job, nil); err == nil || !strings.Contains(err.Error(), "priority") {
+		t.Fatalf("expected priority error, got: %v", err)
+	}
+}
+
+func TestJobs_Register_ValidationError(t *testing.T) {
+	body := "3 error(s) occurred:\n\n* Missing job region\n" +
+		"* Task group web validation failed: 2 error(s) occurred:\n\n" +
+		"* Task group count can't be negative\n* group \"web\" -> task \"task1\" -> config: missing image"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job := testJob()
+	task := job.TaskGroups[0].Tasks[0]
+	task.KillTimeout = time.Second
+	task.ShutdownDelay = time.Minute
+
+	_, _, err = c.Jobs().Register(job, nil)
+	vErr, ok := err.(*JobValidationError)
+	if !ok {
+		t.Fatalf("expected validation error, got: %#v", err)
+	}
+	expected := []string{
+		"Missing job region",
+		"Task group web validation failed",
+		"Task group count can't be negative",
+		`group "web" -> task "task1" -> config: missing image`,
+	}
+	if vErr.JobID != job.ID || !reflect.DeepEqual(vErr.Errors, expected) {
+		t.Fatalf("bad validation error: %#v", vErr)
+	}
+	if len(vErr.Warnings) != 1 || !strings.Contains(vErr.Warnings[0], "shutdown delay") {
+		t.Fatalf("bad warnings: %#v", vErr.Warnings)
+	}
+	if !strings.Contains(vErr.Error(), "Missing job region") {
+		t.Fatalf("bad error message: %v", vErr)
+	}
+
+	// Other errors are returned as is
+	if err := parseJobValidationError(job, fmt.Errorf("Unexpected response code: 500 (no cluster leader)")); err == nil {
+		t.Fatalf("expected error")
+	} else if _, ok := err.(*JobValidationError); ok {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+}
+
+func TestJobs_Copy(t *testing.T) {
+	job := testJob().SetMeta("foo", "bar")
+	job.VaultToken = "secret"
+	job.ConsulToken = "consul-secret"
+
+	c := job.Copy()
+	if c.VaultToken != "" || c.ConsulToken != "" {
+		t.Fatalf("tokens should not be copied")
+	}
+	c.VaultToken = job.VaultToken
+	c.ConsulToken = job.ConsulToken
+	if !reflect.DeepEqual(c, job) {
+		t.Fatalf("expect: %#v, got: %#v", job, c)
+	}
+
+	// The copy is deep
+	c.SetMeta("foo", "baz")
+	c.TaskGroups[0].Tasks[0].Resources.CPU = 1
+	if job.Meta["foo"] != "bar" || job.TaskGroups[0].Tasks[0].Resources.CPU == 1 {
+		t.Fatalf("copy shares state with the original")
+	}
+}
+
+func TestJobs_Tokens_Redacted(t *testing.T) {
+	job := testJob()
+	job.VaultToken = "secret"
+	job.ConsulToken = "secret"
+
+	for _, out := range []string{
+		job.String(),
+		job.GoString(),
+		fmt.Sprintf("%v", job),
+		fmt.Sprintf("%#v", job),
+	} {
+		if strings.Contains(out, "secret") {
+			t.Fatalf("vault token leaked: %s", out)
+		}
+		if !strings.Contains(out, "redacted") {
+			t.Fatalf("expected redacted token: %s", out)
+		}
+	}
+}
+
+func TestJobs_Register_VaultTokenOverride(t *testing.T) {
+	var submitted RegisterJobRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&submitted); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		w.Header().Set("X-Nomad-Index", "1")
+		fmt.Fprint(w, `{"EvalID": "eval1"}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job := testJob()
+	if _, _, err := c.Jobs().Register(job, &WriteOptions{VaultToken: "secret"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if submitted.Job.VaultToken != "secret" {
+		t.Fatalf("expected the vault token to be submitted: %#v", submitted.Job)
+	}
+	if job.VaultToken != "" {
+		t.Fatalf("vault token should not be persisted on the job")
+	}
+}
+
+func TestJobs_Register_JobUntouched(t *testing.T) {
+	var submitted RegisterJobRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&submitted); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		w.Header().Set("X-Nomad-Index", "1")
+		fmt.Fprint(w, `{"EvalID": "eval1"}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	jobs := c.Jobs()
+
+	// The canonicalized copy is submitted, not the job itself
+	job := testJob()
+	job.Priority = 0
+	job.VaultToken = "secret"
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if submitted.Job.Priority != JobDefaultPriority || submitted.Job.VaultToken != "secret" {
+		t.Fatalf("bad submitted job: %#v", submitted.Job)
+	}
+	if job.Priority != 0 {
+		t.Fatalf("job was canonicalized in place: %#v", job)
+	}
+
+	// Nil jobs are rejected rather than panicking
+	if _, _, err := jobs.Register(nil, nil); err == nil {
+		t.Fatalf("expected error for a nil job")
+	}
+	if _, _, err := jobs.RegisterOpts(nil, nil, nil); err == nil {
+		t.Fatalf("expected error for a nil job")
+	}
+	if _, _, err := jobs.EnforceRegister(nil, 1, nil); err == nil {
+		t.Fatalf("expected error for a nil job")
+	}
+	if _, _, err := jobs.Plan(nil, false, nil); err == nil {
+		t.Fatalf("expected error for a nil job")
+	}
+}
+
+func TestJobs_Info_ConsulTokenElided(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		fmt.Fprint(w, `{"ID": "job1", "ConsulToken": "consul-secret", "VaultToken": "vault-secret"}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job, _, err := c.Jobs().Info("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if strings.Contains(string(out), "secret") {
+		t.Fatalf("token returned by read: %s", out)
+	}
+}
+
+func TestJobs_Children(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if prefix := r.URL.Query().Get("prefix"); prefix != "job1/" {
+			t.Fatalf("bad prefix: %q", prefix)
+		}
+		if parent := r.URL.Query().Get("parent"); parent != "job1" {
+			t.Fatalf("bad parent: %q", parent)
+		}
+		w.Header().Set("X-Nomad-Index", "1")
+		fmt.Fprint(w, `[
+			{"ID": "job1/periodic-1", "ParentID": "job1", "Status": "dead"},
+			{"ID": "job1/periodic-2", "ParentID": "job1", "Status": "running"},
+			{"ID": "job1/other", "ParentID": "", "Status": "running"}
+		]`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	children, _, err := c.Jobs().Children("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(children) != 2 {
+		t.Fatalf("expected 2 children, got: %#v", children)
+	}
+	if children[0].ID != "job1/periodic-1" || children[0].Status != "dead" {
+		t.Fatalf("bad child: %#v", children[0])
+	}
+	if children[1].ID != "job1/periodic-2" || children[1].ParentID != "job1" {
+		t.Fatalf("bad child: %#v", children[1])
+	}
+}
+
+func TestJobs_ListWithOpts_ParentID(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Register a periodic job and force a launch of it
+	job := testPeriodicJob()
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, _, err := jobs.PeriodicForce(job.ID, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The launched child is listed under its parent
+	testutil.WaitForResult(func() (bool, error) {
+		children, qm, err := jobs.ListWithOpts(&JobListOptions{ParentID: job.ID}, nil)
+		if err != nil {
+			return false, err
+		}
+		assertQueryMeta(t, qm)
+		if len(children) != 1 {
+			return false, fmt.Errorf("expected 1 child, got: %d", len(children))
+		}
+		if children[0].ParentID != job.ID {
+			return false, fmt.Errorf("bad parent: %q", children[0].ParentID)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	// The parent itself is not a child of anything
+	children, _, err := jobs.ListWithOpts(&JobListOptions{ParentID: "nope"}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(children) != 0 {
+		t.Fatalf("expected no children, got: %#v", children)
+	}
+}
+
+func TestJobs_Apply(t *testing.T) {
+	diffType := DiffTypeNone
+	var registered []RegisterJobRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		switch r.URL.Path {
+		case "/v1/job/job1/plan":
+			var req JobPlanRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if !req.Diff {
+				t.Fatalf("plan requested without a diff")
+			}
+			fmt.Fprintf(w, `{"JobModifyIndex": 7, "Diff": {"Type": %q, "ID": "job1"}}`, diffType)
+		case "/v1/jobs":
+			var req RegisterJobRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			registered = append(registered, req)
+			fmt.Fprint(w, `{"EvalID": "eval1", "EvalCreateIndex": 10, "JobModifyIndex": 10}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Unchanged jobs are left alone
+	result, err := c.Jobs().Apply(testJob(), nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if result.Changed || result.EvalID != "" || result.JobModifyIndex != 7 || len(registered) != 0 {
+		t.Fatalf("unchanged job was registered: %#v", result)
+	}
+
+	// Changed jobs are registered, conditioned on the planned index
+	diffType = DiffTypeEdited
+	result, err = c.Jobs().Apply(testJob(), nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !result.Changed || result.EvalID != "eval1" || result.JobModifyIndex != 10 || result.Diff.Type != DiffTypeEdited {
+		t.Fatalf("bad result: %#v", result)
+	}
+	if len(registered) != 1 || !registered[0].EnforceIndex || registered[0].JobModifyIndex != 7 {
+		t.Fatalf("bad registration: %#v", registered)
+	}
+}
+
+func TestJobs_Deployments(t *testing.T) {
+	var all []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/job/job1/deployments" {
+			http.NotFound(w, r)
+			return
+		}
+		all = append(all, r.URL.Query().Get("all"))
+		w.Header().Set("X-Nomad-Index", "10")
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		fmt.Fprint(w, `[
+			{"ID": "d1", "JobID": "job1", "JobVersion": 0, "Status": "successful", "CreateIndex": 5},
+			{"ID": "d3", "JobID": "job1", "JobVersion": 2, "Status": "running", "CreateIndex": 9},
+			{"ID": "d2", "JobID": "job1", "JobVersion": 1, "Status": "failed", "CreateIndex": 7}
+		]`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	deployments, qm, err := c.Jobs().Deployments("job1", false, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertQueryMeta(t, qm)
+
+	// Deployments are returned newest first, finished ones included
+	var versions []uint64
+	var active []bool
+	for _, d := range deployments {
+		versions = append(versions, d.JobVersion)
+		active = append(active, d.Active())
+	}
+	if !reflect.DeepEqual(versions, []uint64{2, 1, 0}) || !reflect.DeepEqual(active, []bool{true, false, false}) {
+		t.Fatalf("bad deployments: %#v", deployments)
+	}
+
+	if _, _, err := c.Jobs().Deployments("job1", true, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(all, []string{"", "true"}) {
+		t.Fatalf("bad all params: %v", all)
+	}
+}
+
+func TestJobs_WriteOptions(t *testing.T) {
+	type sent struct {
+		method, path, region, namespace, token string
+		evalPriority                           int
+	}
+	var requests []sent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/agent/self" {
+			// The version of the agent is unknown, so requests aren't gated
+			http.NotFound(w, r)
+			return
+		}
+		req := sent{
+			method:    r.Method,
+			path:      r.URL.Path,
+			region:    r.URL.Query().Get("region"),
+			namespace: r.URL.Query().Get("namespace"),
+			token:     r.Header.Get("X-Nomad-Token"),
+		}
+		if priority := r.URL.Query().Get("eval_priority"); priority != "" {
+			req.evalPriority, _ = strconv.Atoi(priority)
+		}
+		var body struct {
+			EvalPriority int
+			EvalOptions  EvalOptions
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil && body.EvalPriority != 0 {
+			req.evalPriority = body.EvalPriority
+		} else if err == nil && body.EvalOptions.Priority != 0 {
+			req.evalPriority = body.EvalOptions.Priority
+		}
+		requests = append(requests, req)
+		w.Header().Set("X-Nomad-Index", "10")
+		fmt.Fprint(w, `{"EvalID": "eval1"}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	jobs := c.Jobs()
+
+	writes := []struct {
+		name   string
+		method string
+		path   string
+		write  func(q *WriteOptions) error
+	}{
+		{"register", "PUT", "/v1/jobs", func(q *WriteOptions) error {
+			_, _, err := jobs.Register(testJob(), q)
+			return err
+		}},
+		{"deregister", "DELETE", "/v1/job/job1", func(q *WriteOptions) error {
+			_, _, err := jobs.Deregister("job1", q)
+			return err
+		}},
+		{"scale", "PUT", "/v1/job/job1/scale", func(q *WriteOptions) error {
+			_, _, err := jobs.Scale("job1", "group1", intToPtr(3), "", false, nil, q)
+			return err
+		}},
+		{"revert", "PUT", "/v1/job/job1/revert", func(q *WriteOptions) error {
+			_, _, err := jobs.Revert("job1", 1, nil, q)
+			return err
+		}},
+		{"evaluate", "PUT", "/v1/job/job1/evaluate", func(q *WriteOptions) error {
+			_, _, err := jobs.ForceEvaluate("job1", q)
+			return err
+		}},
+		{"dispatch", "PUT", "/v1/job/job1/dispatch", func(q *WriteOptions) error {
+			_, _, err := jobs.Dispatch("job1", nil, nil, q)
+			return err
+		}},
+	}
+	for _, write := range writes {
+		requests = nil
+		q := &WriteOptions{Region: "region1", Namespace: "ns1", AuthToken: "secret1", EvalPriority: 70}
+		if err := write.write(q); err != nil {
+			t.Fatalf("%s: err: %v", write.name, err)
+		}
+		expect := sent{write.method, write.path, "region1", "ns1", "secret1", 70}
+		if len(requests) != 1 || requests[0] != expect {
+			t.Fatalf("%s: expected %+v, got %+v", write.name, expect, requests)
+		}
+
+		// Invalid priorities are rejected before the write is sent
+		requests = nil
+		q.EvalPriority = JobMaxPriority + 1
+		if err := write.write(q); err == nil || !strings.Contains(err.Error(), "eval priority must be between") {
+			t.Fatalf("%s: expected priority error, got: %v", write.name, err)
+		}
+		if len(requests) != 0 {
+			t.Fatalf("%s: invalid write was sent", write.name)
+		}
+	}
+
+	// The priority of the options of a write takes precedence
+	requests = nil
+	q := &WriteOptions{EvalPriority: 70}
+	if _, _, err := jobs.RegisterOpts(testJob(), &RegisterOptions{EvalPriority: 90}, q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := jobs.DeregisterOpts("job1", &DeregisterOptions{EvalPriority: 90}, q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := jobs.EvaluateWithOpts("job1", EvalOptions{Priority: 90}, q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(requests) != 4 || requests[0].evalPriority != 90 || requests[1].evalPriority != 90 || requests[2].evalPriority != 90 {
+		t.Fatalf("bad requests: %+v", requests)
+	}
+	if _, _, err := jobs.EvaluateWithOpts("job1", EvalOptions{Priority: JobMaxPriority + 1}, q); err == nil || !strings.Contains(err.Error(), "eval priority must be between") {
+		t.Fatalf("expected priority error, got: %v", err)
+	}
+}
+
+func TestJobs_Scale_Invalid(t *testing.T) {
+	c, err := NewClient(DefaultConfig())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	jobs := c.Jobs()
+	if _, _, err := jobs.Scale("job1", "", intToPtr(1), "", false, nil, nil); err == nil {
+		t.Fatalf("expected error without a task group")
+	}
+	if _, _, err := jobs.Scale("job1", "group1", intToPtr(-1), "", false, nil, nil); err == nil || !strings.Contains(err.Error(), "negative") {
+		t.Fatalf("expected count error, got: %v", err)
+	}
+	if _, _, err := jobs.Scale("job1", "group1", nil, "", false, nil, nil); err == nil || !strings.Contains(err.Error(), "must be specified") {
+		t.Fatalf("expected error without a count or event, got: %v", err)
+	}
+}
+
+func TestJobs_PlacementFailures(t *testing.T) {
+	evals := `[]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/job/job1/evaluations" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Nomad-Index", "10")
+		fmt.Fprint(w, evals)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Jobs without blocked evaluations have no failures
+	failures, err := c.Jobs().PlacementFailures("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("expected no failures, got: %#v", failures)
+	}
+
+	// The metrics are taken from the evaluation that created the latest
+	// blocked evaluation
+	evals = `[
+		{"ID": "eval1", "Status": "complete", "CreateIndex": 1,
+		 "FailedTGAllocs": {"group1": {"NodesEvaluated": 1}}},
+		{"ID": "eval2", "Status": "blocked", "PreviousEval": "eval1", "CreateIndex": 2},
+		{"ID": "eval3", "Status": "complete", "CreateIndex": 3,
+		 "FailedTGAllocs": {"group1": {"NodesEvaluated": 3, "DimensionExhausted": {"memory": 3}}}},
+		{"ID": "eval4", "Status": "blocked", "PreviousEval": "eval3", "CreateIndex": 4}
+	]`
+	failures, err = c.Jobs().PlacementFailures("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(failures) != 1 || failures["group1"].NodesEvaluated != 3 || failures["group1"].DimensionExhausted["memory"] != 3 {
+		t.Fatalf("bad failures: %#v", failures)
+	}
+
+	// Metrics held by the blocked evaluation itself are preferred
+	evals = `[
+		{"ID": "eval3", "Status": "complete", "CreateIndex": 3,
+		 "FailedTGAllocs": {"group1": {"NodesEvaluated": 3}}},
+		{"ID": "eval4", "Status": "blocked", "PreviousEval": "eval3", "CreateIndex": 4,
+		 "FailedTGAllocs": {"group2": {"NodesEvaluated": 4}}}
+	]`
+	failures, err = c.Jobs().PlacementFailures("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(failures) != 1 || failures["group2"].NodesEvaluated != 4 {
+		t.Fatalf("bad failures: %#v", failures)
+	}
+}
+
+func TestJobs_List_Reverse(t *testing.T) {
+	var queries []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("X-Nomad-Index", "9")
+		fmt.Fprint(w, `[{"ID": "api", "CreateIndex": 5}, {"ID": "web", "CreateIndex": 9}, {"ID": "batch", "CreateIndex": 2}]`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ids := func(jobs []*JobListStub) []string {
+		var out []string
+		for _, job := range jobs {
+			out = append(out, job.ID)
+		}
+		return out
+	}
+
+	// Jobs are listed by ID by default and newest first in reverse
+	jobs, _, err := c.Jobs().List(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got := ids(jobs); !reflect.DeepEqual(got, []string{"api", "batch", "web"}) {
+		t.Fatalf("bad order: %v", got)
+	}
+
+	q := &QueryOptions{Reverse: true, Prefix: "a", Filter: `Status == "running"`}
+	jobs, _, err = c.Jobs().List(q)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got := ids(jobs); !reflect.DeepEqual(got, []string{"web", "api", "batch"}) {
+		t.Fatalf("bad reverse order: %v", got)
+	}
+	if _, _, err := c.Jobs().ListWithOpts(&JobListOptions{ParentID: "periodic"}, q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if queries[0].Get("reverse") != "" {
+		t.Fatalf("unexpected reverse: %v", queries[0])
+	}
+	for _, query := range queries[1:] {
+		if query.Get("reverse") != "true" || query.Get("prefix") != "a" || query.Get("filter") != `Status == "running"` {
+			t.Fatalf("bad query: %v", query)
+		}
+	}
+	if queries[2].Get("parent") != "periodic" {
+		t.Fatalf("bad query: %v", queries[2])
+	}
+}
+
+func TestJobs_Submission(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/job/job1/submission" || r.URL.Query().Get("version") != "2" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Nomad-Index", "12")
+		fmt.Fprint(w, `{
+			"Source": "job \"job1\" {}",
+			"Format": "hcl2",
+			"VariableFlags": {"image": "redis:7"},
+			"Variables": "region = \"global\""
+		}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	sub, qm, err := c.Jobs().Submission("job1", 2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if qm.LastIndex != 12 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	expected := &JobSubmission{
+		Source:        `job "job1" {}`,
+		Format:        JobSubmissionFormatHCL2,
+		VariableFlags: map[string]string{"image": "redis:7"},
+		Variables:     `region = "global"`,
+	}
+	if !reflect.DeepEqual(sub, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, sub)
+	}
+
+	// Versions submitted without their source are not found
+	if _, _, err := c.Jobs().Submission("job1", 1, nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected not found error, got: %v", err)
+	}
+}
+
+func TestJobs_Register_Submission(t *testing.T) {
+	var req RegisterJobRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		json.NewEncoder(w).Encode(&JobRegisterResponse{EvalID: "eval1"})
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The source is sent along with the job
+	submission := &JobSubmission{Source: `{"ID": "job1"}`, Format: JobSubmissionFormatJSON}
+	opts := &RegisterOptions{Submission: submission}
+	if _, _, err := c.Jobs().RegisterOpts(testJob(), opts, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(req.Submission, submission) {
+		t.Fatalf("bad submission: %#v", req.Submission)
+	}
+
+	// Invalid submissions are rejected before being sent
+	opts.Submission = &JobSubmission{Format: "yaml"}
+	_, _, err = c.Jobs().RegisterOpts(testJob(), opts, nil)
+	if err == nil || !strings.Contains(err.Error(), "Missing submission source") || !strings.Contains(err.Error(), `format "yaml"`) {
+		t.Fatalf("expected submission errors, got: %v", err)
+	}
+}
+
+func TestJobs_ScaleStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/job/job1/scale" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Nomad-Index", "12")
+		fmt.Fprint(w, `{
+			"JobID": "job1",
+			"JobModifyIndex": 11,
+			"TaskGroups": {
+				"web": {
+					"Desired": 3, "Placed": 3, "Running": 2, "Healthy": 2, "Unhealthy": 1,
+					"Events": [
+						{"Time": 1500000000000000000, "Count": 3, "PreviousCount": 2,
+						 "Message": "scaled up by autoscaler", "EvalID": "eval1"},
+						{"Time": 1400000000000000000, "PreviousCount": 2,
+						 "Message": "failed to query metrics", "Error": true}
+					]
+				}
+			}
+		}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	status, qm, err := c.Jobs().ScaleStatus("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if qm.LastIndex != 12 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	web, ok := status.TaskGroups["web"]
+	if !ok {
+		t.Fatalf("missing group: %#v", status)
+	}
+	if web.Desired != 3 || web.Running != 2 || web.Unhealthy != 1 {
+		t.Fatalf("bad group status: %#v", web)
+	}
+	if len(web.Events) != 2 {
+		t.Fatalf("bad events: %#v", web.Events)
+	}
+	scaled := web.Events[0]
+	if scaled.Count == nil || *scaled.Count != 3 || scaled.EvalID == nil || *scaled.EvalID != "eval1" {
+		t.Fatalf("bad event: %#v", scaled)
+	}
+	if failed := web.Events[1]; !failed.Error || failed.Count != nil || failed.EvalID != nil {
+		t.Fatalf("bad event: %#v", failed)
+	}
+}
+
+func TestJobs_RegisterOpts_Warnings(t *testing.T) {
+	var submitted RegisterJobRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		submitted = RegisterJobRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&submitted); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		w.Header().Set("X-Nomad-Index", "4")
+		fmt.Fprint(w, `{"EvalID": "eval1", "EvalCreateIndex": 4, "JobModifyIndex": 3,
+			"Warnings": "group web has no update stanza"}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job := testJob()
+	task := job.TaskGroups[0].Tasks[0]
+	task.KillTimeout = time.Second
+	task.ShutdownDelay = time.Minute
+
+	resp, wm, err := c.Jobs().RegisterOpts(job, &RegisterOptions{EnforceIndex: true, ModifyIndex: 2}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertWriteMeta(t, wm)
+	if resp.EvalID != "eval1" || resp.EvalCreateIndex != 4 || resp.JobModifyIndex != 3 {
+		t.Fatalf("bad response: %#v", resp)
+	}
+	if !submitted.EnforceIndex || submitted.JobModifyIndex != 2 {
+		t.Fatalf("index not enforced: %#v", submitted)
+	}
+
+	lines := strings.Split(resp.Warnings, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("bad warnings: %q", resp.Warnings)
+	}
+	if !strings.Contains(lines[0], "Task task1 in group group1: shutdown delay") {
+		t.Fatalf("bad local warning: %q", lines[0])
+	}
+	if lines[1] != "group web has no update stanza" {
+		t.Fatalf("bad server warning: %q", lines[1])
+	}
+
+	// The deprecated wrapper still returns the eval ID
+	evalID, _, err := c.Jobs().Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if evalID != "eval1" || submitted.EnforceIndex {
+		t.Fatalf("bad register: %q %#v", evalID, submitted)
+	}
+}
+
+func TestJobs_Restart(t *testing.T) {
+	var restartReqs []string
+	var listToken string
+	c, srv := makeNodeClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/job/job1/allocations":
+			listToken = r.Header.Get("X-Nomad-Token")
+			w.Header().Set("X-Nomad-Index", "1")
+			fmt.Fprint(w, `[
+				{"ID": "alloc1", "NodeID": "node1", "TaskGroup": "web", "DesiredStatus": "run", "ClientStatus": "running", "CreateIndex": 4},
+				{"ID": "alloc2", "NodeID": "node1", "TaskGroup": "web", "DesiredStatus": "run", "ClientStatus": "running", "CreateIndex": 3},
+				{"ID": "alloc3", "NodeID": "node1", "TaskGroup": "cache", "DesiredStatus": "run", "ClientStatus": "running", "CreateIndex": 2},
+				{"ID": "alloc4", "NodeID": "node1", "TaskGroup": "web", "DesiredStatus": "stop", "ClientStatus": "complete", "CreateIndex": 1}
+			]`)
+		case "/v1/client/allocation/alloc1/restart", "/v1/client/allocation/alloc2/restart":
+			var req AllocRestartRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if req.TaskName != "" {
+				t.Fatalf("expected all tasks to be restarted: %q", req.TaskName)
+			}
+			restartReqs = append(restartReqs, r.URL.Path)
+			if strings.Contains(r.URL.Path, "alloc2") {
+				http.Error(w, "task not running", http.StatusInternalServerError)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+
+	restarted, err := c.Jobs().Restart("job1", "web", &WriteOptions{AuthToken: "secret1"})
+	if listToken != "secret1" {
+		t.Fatalf("expected the allocations to be listed with the token of the options, got %q", listToken)
+	}
+	if len(restarted) != 1 || restarted[0] != "alloc1" {
+		t.Fatalf("bad restarted allocs: %v", restarted)
+	}
+	if err == nil || !strings.Contains(err.Error(), `alloc "alloc2"`) {
+		t.Fatalf("expected alloc2 error, got: %v", err)
+	}
+	if len(restartReqs) != 2 {
+		t.Fatalf("bad restart requests: %v", restartReqs)
+	}
+
+	if _, err := c.Jobs().Restart("job1", "db", nil); err == nil || !strings.Contains(err.Error(), "no running allocations") {
+		t.Fatalf("expected no allocations error, got: %v", err)
+	}
+}
+
+func TestJobs_InfoWithSummary(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/job/job1" || r.URL.Query().Get("summary") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Nomad-Index", "6")
+		fmt.Fprint(w, `{
+			"Job": {"ID": "job1", "VaultToken": "secret"},
+			"Summary": {"JobID": "job1", "Summary": {"web": {"Running": 2, "Failed": 1}}}
+		}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job, summary, qm, err := c.Jobs().InfoWithSummary("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if qm.LastIndex != 6 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	if job.ID != "job1" || job.VaultToken != "" {
+		t.Fatalf("bad job: %#v", job)
+	}
+	if web := summary.Summary["web"]; web.Running != 2 || web.Failed != 1 {
+		t.Fatalf("bad summary: %#v", summary)
+	}
+}
+
+func TestJobs_Namespaces(t *testing.T) {
+	stored := make(map[string]*Job)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		namespace := r.URL.Query().Get("namespace")
+		if namespace == "" {
+			namespace = DefaultNamespace
+		}
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/v1/jobs":
+			var req RegisterJobRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			// Store the job without its namespace, as servers that
+			// predate namespaces would
+			req.Job.Namespace = ""
+			stored[namespace+"/"+req.Job.ID] = req.Job
+			json.NewEncoder(w).Encode(&JobRegisterResponse{EvalID: "eval-" + namespace})
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/job/"):
+			job, ok := stored[namespace+"/"+strings.TrimPrefix(r.URL.Path, "/v1/job/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(job)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	jobs := c.Jobs()
+
+	// Register the same job ID in two namespaces, once through the write
+	// options and once through the namespace of the job
+	dev := testJob()
+	dev.Name = "dev-job"
+	if _, _, err := jobs.RegisterOpts(dev, nil, &WriteOptions{Namespace: "dev"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	prod := testJob()
+	prod.Name = "prod-job"
+	prod.Namespace = "prod"
+	resp, _, err := jobs.RegisterOpts(prod, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.EvalID != "eval-prod" {
+		t.Fatalf("job registered in the wrong namespace: %s", resp.EvalID)
+	}
+
+	// The namespace isn't filled in when the servers don't return one
+	for namespace, name := range map[string]string{"dev": "dev-job", "prod": "prod-job"} {
+		job, _, err := jobs.Info("job1", &QueryOptions{Namespace: namespace})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if job.Name != name || job.Namespace != "" {
+			t.Fatalf("bad job in namespace %q: %#v", namespace, job)
+		}
+	}
+
+	// The job isn't registered in the default namespace
+	if _, _, err := jobs.Info("job1", nil); err == nil {
+		t.Fatalf("expected job to be missing from the default namespace")
+	}
+
+	// A job can't be written to a namespace other than its own
+	_, _, err = jobs.RegisterOpts(prod, nil, &WriteOptions{Namespace: "dev"})
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected namespace mismatch error, got: %v", err)
+	}
+	if stored["dev/job1"].Name != "dev-job" {
+		t.Fatalf("dev job overwritten: %#v", stored["dev/job1"])
+	}
+
+	// Nor to a namespace other than the one configured for the client
+	conf.Namespace = "dev"
+	c, err = NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, _, err = c.Jobs().RegisterOpts(prod, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), `write namespace "dev"`) {
+		t.Fatalf("expected namespace mismatch error, got: %v", err)
+	}
+	if _, _, err := c.Jobs().RegisterOpts(prod, nil, &WriteOptions{Namespace: "prod"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestJobs_EvalPriority(t *testing.T) {
+	var registerPriority int
+	var deregisterQuery url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/v1/jobs":
+			var req RegisterJobRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			registerPriority = req.EvalPriority
+			json.NewEncoder(w).Encode(&JobRegisterResponse{EvalID: "eval1"})
+		case r.Method == "DELETE" && r.URL.Path == "/v1/job/job1":
+			deregisterQuery = r.URL.Query()
+			json.NewEncoder(w).Encode(&deregisterJobResponse{EvalID: "eval2"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	jobs := c.Jobs()
+
+	if _, _, err := jobs.RegisterOpts(testJob(), &RegisterOptions{EvalPriority: 80}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if registerPriority != 80 {
+		t.Fatalf("bad register eval priority: %d", registerPriority)
+	}
+
+	evalID, _, err := jobs.DeregisterOpts("job1", &DeregisterOptions{EvalPriority: 10}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if evalID != "eval2" || deregisterQuery.Get("eval_priority") != "10" {
+		t.Fatalf("bad deregister: %s %v", evalID, deregisterQuery)
+	}
+	if deregisterQuery.Get("enforce_index") != "" {
+		t.Fatalf("unexpected enforce index: %v", deregisterQuery)
+	}
+
+	// Out of range priorities are rejected before being submitted
+	registerPriority, deregisterQuery = 0, nil
+	for _, priority := range []int{-1, JobMaxPriority + 1} {
+		_, _, err := jobs.RegisterOpts(testJob(), &RegisterOptions{EvalPriority: priority}, nil)
+		if err == nil || !strings.Contains(err.Error(), "eval priority") {
+			t.Fatalf("expected eval priority error, got: %v", err)
+		}
+		_, _, err = jobs.DeregisterOpts("job1", &DeregisterOptions{EvalPriority: priority}, nil)
+		if err == nil || !strings.Contains(err.Error(), "eval priority") {
+			t.Fatalf("expected eval priority error, got: %v", err)
+		}
+	}
+	if registerPriority != 0 || deregisterQuery != nil {
+		t.Fatalf("invalid eval priority submitted")
+	}
+}
+
+func TestJobs_DeregisterOpts_Stop(t *testing.T) {
+	var deregisterQuery url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/job/single":
+			fmt.Fprint(w, `{"ID": "single", "Multiregion": null}`)
+		case r.Method == "GET" && r.URL.Path == "/v1/job/multi":
+			fmt.Fprint(w, `{"ID": "multi", "Multiregion": {"Regions": [{"Name": "east"}, {"Name": "west"}]}}`)
+		case r.Method == "DELETE":
+			deregisterQuery = r.URL.Query()
+			json.NewEncoder(w).Encode(&deregisterJobResponse{EvalID: "eval1"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	jobs := c.Jobs()
+
+	opts := &DeregisterOptions{NoShutdownDelay: true}
+	if _, _, err := jobs.DeregisterOpts("single", opts, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if deregisterQuery.Get("no_shutdown_delay") != "true" || deregisterQuery.Get("global") != "" {
+		t.Fatalf("bad deregister query: %v", deregisterQuery)
+	}
+
+	// Only multiregion jobs can be stopped globally
+	opts = &DeregisterOptions{NoShutdownDelay: true, Global: true}
+	deregisterQuery = nil
+	if _, _, err := jobs.DeregisterOpts("single", opts, nil); err == nil || !strings.Contains(err.Error(), "not multiregion") {
+		t.Fatalf("expected multiregion error, got: %v", err)
+	}
+	if deregisterQuery != nil {
+		t.Fatalf("global stop of a single region job submitted")
+	}
+	if _, _, err := jobs.DeregisterOpts("missing", opts, nil); err == nil {
+		t.Fatalf("expected missing job error")
+	}
+
+	if _, _, err := jobs.DeregisterOpts("multi", opts, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if deregisterQuery.Get("no_shutdown_delay") != "true" || deregisterQuery.Get("global") != "true" {
+		t.Fatalf("bad deregister query: %v", deregisterQuery)
+	}
+}
+
+func TestJobs_ListByDriver(t *testing.T) {
+	jobs := map[string]*Job{
+		"docker-web": {ID: "docker-web", TaskGroups: []*TaskGroup{
+			NewTaskGroup("web", 1).AddTask(NewTask("nginx", "docker")),
+		}},
+		"exec-batch": {ID: "exec-batch", TaskGroups: []*TaskGroup{
+			NewTaskGroup("batch", 1).AddTask(NewTask("cron", "exec")),
+		}},
+		"mixed": {ID: "mixed", TaskGroups: []*TaskGroup{
+			NewTaskGroup("app", 1).AddTask(NewTask("app", "exec")),
+			NewTaskGroup("proxy", 1).AddTask(NewTask("envoy", "docker")),
+		}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		if r.URL.Path == "/v1/jobs" {
+			// The deleted job is listed but can't be fetched anymore
+			stubs := []*JobListStub{{ID: "deleted"}}
+			for id := range jobs {
+				stubs = append(stubs, &JobListStub{ID: id})
+			}
+			json.NewEncoder(w).Encode(stubs)
+			return
+		}
+		job, ok := jobs[strings.TrimPrefix(r.URL.Path, "/v1/job/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(job)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !jobs["mixed"].UsesDriver("docker") || jobs["exec-batch"].UsesDriver("docker") {
+		t.Fatalf("bad UsesDriver")
+	}
+
+	out, qm, err := c.Jobs().ListByDriver("docker", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if qm.LastIndex != 10 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	var ids []string
+	for _, job := range out {
+		ids = append(ids, job.ID)
+	}
+	if expected := []string{"docker-web", "mixed"}; !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected %v, got %v", expected, ids)
+	}
+
+	out, _, err = c.Jobs().ListByDriver("rkt", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out) != 0 {
+		t.Fatalf("expected no jobs, got %v", out)
+	}
+}
+
+func TestJobs_RegisterAndWait(t *testing.T) {
+	var lock sync.Mutex
+	var waitIndexes []string
+	terminal := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/v1/jobs":
+			w.Header().Set("X-Nomad-Index", "7")
+			json.NewEncoder(w).Encode(&JobRegisterResponse{EvalID: "eval1", EvalCreateIndex: 7})
+		case r.Method == "GET" && r.URL.Path == "/v1/evaluation/eval1":
+			index := r.URL.Query().Get("index")
+			waitIndexes = append(waitIndexes, index)
+			status := EvalStatusPending
+			if index == "8" && terminal {
+				status = EvalStatusComplete
+			} else {
+				time.Sleep(5 * time.Millisecond)
+			}
+			w.Header().Set("X-Nomad-Index", "8")
+			json.NewEncoder(w).Encode(&Evaluation{ID: "eval1", Status: status})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval, wm, err := c.Jobs().RegisterAndWait(testJob(), 5*time.Second, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if wm == nil || wm.LastIndex != 7 {
+		t.Fatalf("bad write meta: %#v", wm)
+	}
+	if eval.ID != "eval1" || eval.Status != EvalStatusComplete {
+		t.Fatalf("bad eval: %#v", eval)
+	}
+
+	// The first query waits on the index the evaluation was created at
+	if !reflect.DeepEqual(waitIndexes, []string{"7", "8"}) {
+		t.Fatalf("bad wait indexes: %v", waitIndexes)
+	}
+
+	// Evaluations that don't finish in time return an error
+	lock.Lock()
+	terminal = false
+	lock.Unlock()
+	_, _, err = c.Jobs().RegisterAndWait(testJob(), 50*time.Millisecond, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got: %v", err)
+	}
+
+	// A timeout of zero waits without a limit
+	lock.Lock()
+	terminal = true
+	lock.Unlock()
+	eval, _, err = c.Jobs().RegisterAndWait(testJob(), 0, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval.Status != EvalStatusComplete {
+		t.Fatalf("bad eval: %#v", eval)
+	}
+}
+
+func TestJob_Warnings(t *testing.T) {
+	job := testJob()
+	job.Canonicalize()
+	if warnings := job.Warnings(); len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+
+	job.Datacenters = nil
+	job.Constrain(NewConstraint("${attr.kernel.name}", "=", "linux"))
+	job.Constrain(NewConstraint("${attr.kernel.name}", "==", "windows"))
+	job.Constrain(NewConstraint("${attr.arch}", "~=", "amd64"))
+	group := job.TaskGroups[0]
+	group.SetCount(0)
+	group.Constrain(NewConstraint("kernel.name", "=", "linux"))
+	task := group.Tasks[0]
+	task.KillTimeout = time.Second
+	task.ShutdownDelay = time.Minute
+	task.Constraints = []*Constraint{
+		NewConstraint("${meta.rack}", "!=", "r1"),
+		NewConstraint("${meta.rack}", "!=", "r1"),
+	}
+
+	// Warnings don't make the job invalid
+	if err := job.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := []string{
+		"Job has no datacenters",
+		`Job: Constraint 2 requires ${attr.kernel.name} to be both "linux" and "windows"`,
+		`Job: Constraint 3 has unknown operand "~="`,
+		"Task group group1 has a count of 0",
+		`Task group group1: Constraint 1 compares "kernel.name" to "linux" without interpolating a node attribute, did you mean ${attr.kernel.name}?`,
+		"Task task1 in group group1: shutdown delay",
+		"Task task1 in group group1: Constraint 2 is a duplicate",
+	}
+	warnings := job.Warnings()
+	if len(warnings) != len(expected) {
+		t.Fatalf("bad warnings: %q", warnings)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(warnings[i], prefix) {
+			t.Fatalf("warning %d: expected prefix %q, got %q", i, prefix, warnings[i])
+		}
+	}
+
+	// Multiregion jobs may set their datacenters per region
+	job = testJob()
+	job.Datacenters = nil
+	job.SetMultiregion(&Multiregion{Regions: []*MultiregionRegion{
+		{Name: "east", Datacenters: []string{"east-1"}},
+		{Name: "west", Datacenters: []string{"west-1"}},
+	}})
+	if warnings := job.Warnings(); len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	job.Multiregion.Regions[1].Datacenters = nil
+	if warnings := job.Warnings(); len(warnings) != 1 {
+		t.Fatalf("expected missing datacenters warning, got: %v", warnings)
+	}
+}
+
+func TestJobs_Register_PreservesCounts(t *testing.T) {
+	var registered []*Job
+	var planned *Job
+	lookups := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/job/job1":
+			lookups++
+			// The registered job was scaled to zero and up to 7
+			fmt.Fprint(w, `{"ID": "job1", "TaskGroups": [{"Name": "web", "Count": 0}, {"Name": "api", "Count": 7}]}`)
+		case r.Method == "PUT" && r.URL.Path == "/v1/jobs":
+			var req RegisterJobRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			registered = append(registered, req.Job)
+			json.NewEncoder(w).Encode(&JobRegisterResponse{EvalID: "eval1"})
+		case r.Method == "PUT" && strings.HasSuffix(r.URL.Path, "/plan"):
+			var req JobPlanRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			planned = req.Job
+			json.NewEncoder(w).Encode(&JobPlanResponse{})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	newJob := func(id string) *Job {
+		job := NewServiceJob(id, id, "global", 50).AddDatacenter("dc1")
+		for _, name := range []string{"web", "api", "worker"} {
+			job.AddTaskGroup(&TaskGroup{Name: name, Tasks: []*Task{NewTask("task", "exec")}})
+		}
+		job.TaskGroups[2].SetScaling(&ScalingPolicy{Min: 2, Max: 4})
+		return job
+	}
+	counts := func(job *Job) []int {
+		var out []int
+		for _, tg := range job.TaskGroups {
+			if tg.Count == nil {
+				t.Fatalf("group %s submitted without a count", tg.Name)
+			}
+			out = append(out, *tg.Count)
+		}
+		return out
+	}
+
+	// A partial update keeps the registered counts, including zero, and
+	// defaults the counts of new groups, leaving the job untouched
+	partial := newJob("job1")
+	if _, _, err := c.Jobs().Register(partial, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out := counts(registered[0]); !reflect.DeepEqual(out, []int{0, 7, 2}) {
+		t.Fatalf("bad registered counts: %v", out)
+	}
+	for _, tg := range partial.TaskGroups {
+		if tg.Count != nil {
+			t.Fatalf("group %s of the job was given a count", tg.Name)
+		}
+	}
+	if lookups != 1 {
+		t.Fatalf("expected 1 lookup, got %d", lookups)
+	}
+	if _, _, err := c.Jobs().Plan(newJob("job1"), true, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out := counts(planned); !reflect.DeepEqual(out, []int{0, 7, 2}) {
+		t.Fatalf("bad planned counts: %v", out)
+	}
+
+	// Dry runs look up the counts once for the plan
+	lookups = 0
+	conf.DryRun = true
+	dry, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	planned = nil
+	if _, _, err := dry.Jobs().Register(newJob("job1"), nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out := counts(planned); !reflect.DeepEqual(out, []int{0, 7, 2}) || lookups != 1 {
+		t.Fatalf("bad dry run: counts %v, %d lookups", out, lookups)
+	}
+
+	// Set counts are submitted as is
+	job := newJob("job1")
+	job.TaskGroups[1].SetCount(3)
+	job.TaskGroups[0].SetCount(0)
+	if _, _, err := c.Jobs().Register(job, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out := counts(registered[1]); !reflect.DeepEqual(out, []int{0, 3, 2}) {
+		t.Fatalf("bad registered counts: %v", out)
+	}
+
+	// New jobs default their counts
+	if _, _, err := c.Jobs().Register(newJob("job2"), nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out := counts(registered[2]); !reflect.DeepEqual(out, []int{1, 1, 2}) {
+		t.Fatalf("bad registered counts: %v", out)
+	}
+}
+
+func TestJobs_MergeWarnings(t *testing.T) {
+	local := make([]string, 1, 2)
+	local[0] = "local"
+	if out := mergeWarnings(local, "remote"); out != "local\nremote" {
+		t.Fatalf("bad warnings: %q", out)
+	}
+	if out := mergeWarnings(local[:1], ""); out != "local" {
+		t.Fatalf("bad warnings: %q", out)
+	}
+
+	// The local warnings are left untouched
+	if extra := local[:2][1]; extra != "" {
+		t.Fatalf("local warnings modified: %q", extra)
+	}
+}
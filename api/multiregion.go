@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// MultiregionOnFailureFailAll and MultiregionOnFailureFailLocal are the
+	// ways a multiregion deployment reacts to the failure of the deployment
+	// of a region. Failing all fails the deployments of every region, while
+	// failing locally only fails the deployment of the failed region. If
+	// unset, the deployments of the other regions are paused.
+	MultiregionOnFailureFailAll   = "fail_all"
+	MultiregionOnFailureFailLocal = "fail_local"
+)
+
+// Multiregion is the multiregion block of a job, federating its deployment
+// across several regions.
+type Multiregion struct {
+	Strategy *MultiregionStrategy `json:",omitempty"`
+	Regions  []*MultiregionRegion `json:",omitempty"`
+}
+
+// MultiregionStrategy governs the order in which the regions of a
+// multiregion job are deployed.
+type MultiregionStrategy struct {
+	// MaxParallel is the number of regions deployed at once. Zero deploys
+	// every region at once.
+	MaxParallel int `json:",omitempty"`
+
+	// OnFailure is one of MultiregionOnFailureFailAll or
+	// MultiregionOnFailureFailLocal.
+	OnFailure string `json:",omitempty"`
+}
+
+// MultiregionRegion is a region a multiregion job is deployed to.
+type MultiregionRegion struct {
+	Name string
+
+	// Count, if set, overrides the count of the task groups of the job in
+	// the region.
+	Count int `json:",omitempty"`
+
+	// Datacenters, if set, overrides the datacenters of the job in the
+	// region.
+	Datacenters []string `json:",omitempty"`
+
+	// Meta is merged into the meta of the job in the region.
+	Meta map[string]string `json:",omitempty"`
+}
+
+// RegionNames returns the names of the regions, in deployment order.
+func (m *Multiregion) RegionNames() []string {
+	names := make([]string, 0, len(m.Regions))
+	for _, region := range m.Regions {
+		if region != nil {
+			names = append(names, region.Name)
+		}
+	}
+	return names
+}
+
+// Validate is used to sanity check the multiregion block of a job.
+func (m *Multiregion) Validate() error {
+	var mErr multierror.Error
+	if len(m.Regions) == 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Multiregion must have at least one region"))
+	}
+	seen := make(map[string]struct{}, len(m.Regions))
+	for idx, region := range m.Regions {
+		if region == nil || region.Name == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Region %d must have a name", idx+1))
+			continue
+		}
+		if _, ok := seen[region.Name]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Region %q is defined more than once", region.Name))
+		}
+		seen[region.Name] = struct{}{}
+		if region.Count < 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Region %q count must be greater than or equal to 0 but found %d", region.Name, region.Count))
+		}
+	}
+	if s := m.Strategy; s != nil {
+		if s.MaxParallel < 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Strategy max parallel must be greater than or equal to 0 but found %d", s.MaxParallel))
+		}
+		switch s.OnFailure {
+		case "", MultiregionOnFailureFailAll, MultiregionOnFailureFailLocal:
+		default:
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Strategy on failure must be %q or %q but found %q",
+				MultiregionOnFailureFailAll, MultiregionOnFailureFailLocal, s.OnFailure))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
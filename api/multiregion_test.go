@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+func testMultiregion() *Multiregion {
+	return &Multiregion{
+		Strategy: &MultiregionStrategy{
+			MaxParallel: 1,
+			OnFailure:   MultiregionOnFailureFailAll,
+		},
+		Regions: []*MultiregionRegion{
+			{
+				Name:        "east",
+				Count:       2,
+				Datacenters: []string{"east-1", "east-2"},
+				Meta:        map[string]string{"tier": "primary"},
+			},
+			{
+				Name:        "west",
+				Count:       1,
+				Datacenters: []string{"west-1"},
+			},
+		},
+	}
+}
+
+func TestMultiregion_Validate(t *testing.T) {
+	m := testMultiregion()
+	if err := m.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if names := m.RegionNames(); !reflect.DeepEqual(names, []string{"east", "west"}) {
+		t.Fatalf("bad region names: %v", names)
+	}
+
+	// Region names must be unique
+	m.Regions = append(m.Regions, &MultiregionRegion{Name: "east"})
+	if err := m.Validate(); err == nil || !strings.Contains(err.Error(), `Region "east" is defined more than once`) {
+		t.Fatalf("expected duplicate region error, got: %v", err)
+	}
+
+	m = &Multiregion{
+		Strategy: &MultiregionStrategy{MaxParallel: -1, OnFailure: "retry"},
+		Regions:  []*MultiregionRegion{{Name: ""}, {Name: "east", Count: -1}},
+	}
+	err := m.Validate()
+	if mErr, ok := err.(*multierror.Error); !ok || len(mErr.Errors) != 4 {
+		t.Fatalf("expected 4 errors, got: %v", err)
+	}
+
+	if err := (&Multiregion{}).Validate(); err == nil {
+		t.Fatalf("expected missing regions error")
+	}
+}
+
+func TestJob_Multiregion(t *testing.T) {
+	job := testJob()
+	if job.IsMultiregion() {
+		t.Fatalf("job without a multiregion block is multiregion")
+	}
+
+	job.SetMultiregion(testMultiregion())
+	if !job.IsMultiregion() {
+		t.Fatalf("expected job to be multiregion")
+	}
+	if err := job.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The block survives encoding and copying
+	buf, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var decoded Job
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Multiregion, job.Multiregion) {
+		t.Fatalf("bad decoded multiregion: %#v", decoded.Multiregion)
+	}
+	copied := job.Copy()
+	copied.Multiregion.Regions[0].Name = "north"
+	if job.Multiregion.Regions[0].Name != "east" {
+		t.Fatalf("copy shares the multiregion block")
+	}
+
+	job.Multiregion.Regions[1].Name = "east"
+	if err := job.Validate(); err == nil || !strings.Contains(err.Error(), "Multiregion validation failed") {
+		t.Fatalf("expected multiregion validation error, got: %v", err)
+	}
+}
@@ -1,11 +1,12 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strconv"
-
-	"github.com/hashicorp/go-cleanhttp"
+	"strings"
+	"time"
 )
 
 // Nodes is used to query node-related API endpoints
@@ -29,6 +30,116 @@ func (n *Nodes) List(q *QueryOptions) ([]*NodeListStub, *QueryMeta, error) {
 	return resp, qm, nil
 }
 
+// NodeFilterPath is where the filter of a node list was applied.
+type NodeFilterPath string
+
+const (
+	// NodeFilterPathNone is used when no filter was given.
+	NodeFilterPathNone NodeFilterPath = ""
+
+	// NodeFilterPathServer is used when the servers filtered the nodes,
+	// costing a single list query.
+	NodeFilterPathServer NodeFilterPath = "server"
+
+	// NodeFilterPathClient is used when the servers don't support the
+	// filter and the nodes were filtered by the client, costing a query of
+	// every listed node.
+	NodeFilterPathClient NodeFilterPath = "client"
+)
+
+// NodeListOptions are the options of a node list.
+type NodeListOptions struct {
+	// Attributes, if set, restricts the listed nodes to those whose
+	// attributes have all of the given values, such as
+	// {"driver.docker": "1"}.
+	Attributes map[string]string
+}
+
+// NodeListResponse is the result of a node list with options.
+type NodeListResponse struct {
+	Nodes []*NodeListStub
+
+	// FilterPath is where the filter of the options was applied.
+	FilterPath NodeFilterPath
+}
+
+// ListOpts is used to list the nodes matching the options. The attribute
+// filter is pushed to the servers, which confirm applying it with the
+// X-Nomad-Node-Filter header. Servers that don't support the filter return
+// every node, in which case the attributes of each node are fetched and
+// matched by the client. The FilterPath of the response tells which path
+// was taken.
+func (n *Nodes) ListOpts(opts *NodeListOptions, q *QueryOptions) (*NodeListResponse, *QueryMeta, error) {
+	if opts == nil || len(opts.Attributes) == 0 {
+		nodes, qm, err := n.List(q)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &NodeListResponse{Nodes: nodes}, qm, nil
+	}
+
+	keys := make([]string, 0, len(opts.Attributes))
+	for k := range opts.Attributes {
+		if k == "" {
+			return nil, nil, fmt.Errorf("attribute filter keys must not be empty")
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	r := n.client.newRequest("GET", "/v1/nodes")
+	r.setQueryOptions(q)
+	for _, k := range keys {
+		r.params.Add("attribute", k+"="+opts.Attributes[k])
+	}
+	rtt, resp, err := requireOK(n.client.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var nodes NodeIndexSort
+	if err := decodeBody(resp, &nodes); err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(nodes)
+	if resp.Header.Get("X-Nomad-Node-Filter") == "attributes" {
+		return &NodeListResponse{Nodes: nodes, FilterPath: NodeFilterPathServer}, qm, nil
+	}
+
+	// Fall back to matching the attributes of every node
+	var iq *QueryOptions
+	if q != nil {
+		iq = &QueryOptions{Region: q.Region, Namespace: q.Namespace, AllowStale: q.AllowStale, AuthToken: q.AuthToken}
+	}
+	matched := make([]*NodeListStub, 0, len(nodes))
+	for _, stub := range nodes {
+		node, _, err := n.Info(stub.ID, iq)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to look up node %q: %v", stub.ID, err)
+		}
+		if node.hasAttributes(opts.Attributes) {
+			matched = append(matched, stub)
+		}
+	}
+	return &NodeListResponse{Nodes: matched, FilterPath: NodeFilterPathClient}, qm, nil
+}
+
+// hasAttributes returns whether the node has all of the given attribute
+// values.
+func (n *Node) hasAttributes(attrs map[string]string) bool {
+	for k, v := range attrs {
+		if actual, ok := n.Attributes[k]; !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
 func (n *Nodes) PrefixList(prefix string) ([]*NodeListStub, *QueryMeta, error) {
 	return n.List(&QueryOptions{Prefix: prefix})
 }
@@ -40,6 +151,12 @@ func (n *Nodes) Info(nodeID string, q *QueryOptions) (*Node, *QueryMeta, error)
 	if err != nil {
 		return nil, nil, err
 	}
+
+	// Servers that predate structured reserved resources only return the
+	// flat Reserved block, so derive the structured form from it.
+	if resp.ReservedResources == nil && resp.Reserved != nil {
+		resp.ReservedResources = newNodeReservedResources(resp.Reserved)
+	}
 	return &resp, qm, nil
 }
 
@@ -53,6 +170,200 @@ func (n *Nodes) ToggleDrain(nodeID string, drain bool, q *WriteOptions) (*WriteM
 	return wm, nil
 }
 
+// DrainSpec describes how a node is drained.
+type DrainSpec struct {
+	// Deadline is the duration after which the remaining allocations of
+	// the node are forcibly stopped. Zero means no deadline.
+	Deadline time.Duration
+
+	// IgnoreSystemJobs leaves the allocations of system jobs running on the
+	// node while it drains.
+	IgnoreSystemJobs bool
+}
+
+// NodeUpdateDrainRequest is used to update the drain of a node.
+type NodeUpdateDrainRequest struct {
+	NodeID    string
+	DrainSpec *DrainSpec
+}
+
+// NodeDrainUpdateResponse is used to respond to a node drain update.
+type NodeDrainUpdateResponse struct {
+	EvalIDs         []string
+	EvalCreateIndex uint64
+	NodeModifyIndex uint64
+}
+
+// UpdateDrain is used to update the drain of a node. A nil spec disables
+// draining. The IDs of the evaluations created to migrate the allocations
+// of the node are returned. Servers that do not support drain specs treat
+// a non-nil spec as enabling the drain.
+func (n *Nodes) UpdateDrain(nodeID string, spec *DrainSpec, q *WriteOptions) (*NodeDrainUpdateResponse, *WriteMeta, error) {
+	if spec != nil && spec.Deadline < 0 {
+		return nil, nil, fmt.Errorf("drain deadline must be greater than or equal to 0")
+	}
+
+	req := &NodeUpdateDrainRequest{
+		NodeID:    nodeID,
+		DrainSpec: spec,
+	}
+	drainArg := strconv.FormatBool(spec != nil)
+
+	var resp NodeDrainUpdateResponse
+	wm, err := n.client.write("/v1/node/"+nodeID+"/drain?enable="+drainArg, req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// MonitorMsgLevel is the severity of a monitor message.
+type MonitorMsgLevel int
+
+const (
+	MonitorMsgLevelNormal MonitorMsgLevel = 0
+	MonitorMsgLevelInfo   MonitorMsgLevel = 1
+	MonitorMsgLevelWarn   MonitorMsgLevel = 2
+	MonitorMsgLevelError  MonitorMsgLevel = 3
+)
+
+// MonitorMessage is a progress message emitted while monitoring a
+// long-running operation.
+type MonitorMessage struct {
+	Level   MonitorMsgLevel
+	Message string
+}
+
+func (m *MonitorMessage) String() string {
+	return m.Message
+}
+
+// MonitorDrain streams the progress of draining a node, starting at the
+// given index, until all of its allocations have stopped, the drain is
+// disabled or the context is cancelled. Allocations of system jobs are
+// not waited on if ignoreSys is set. The returned channel is closed once
+// monitoring ends; errors are reported on it as error level messages.
+func (n *Nodes) MonitorDrain(ctx context.Context, nodeID string, index uint64, ignoreSys bool) <-chan *MonitorMessage {
+	outCh := make(chan *MonitorMessage, 8)
+	go n.monitorDrain(ctx, nodeID, index, ignoreSys, outCh)
+	return outCh
+}
+
+func (n *Nodes) monitorDrain(ctx context.Context, nodeID string, index uint64,
+	ignoreSys bool, outCh chan<- *MonitorMessage) {
+	defer close(outCh)
+
+	send := func(level MonitorMsgLevel, format string, args ...interface{}) bool {
+		msg := &MonitorMessage{Level: level, Message: fmt.Sprintf(format, args...)}
+		select {
+		case outCh <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// Track the allocations that were seen stopping so each transition is
+	// only reported once
+	migrating := make(map[string]struct{})
+	stopped := make(map[string]struct{})
+	for {
+		q := (&QueryOptions{WaitIndex: index}).WithContext(ctx)
+		allocs, qm, err := n.Allocations(nodeID, q)
+		if err != nil {
+			if ctx.Err() == nil {
+				send(MonitorMsgLevelError, "Error monitoring allocations of node %q: %v", nodeID, err)
+			}
+			return
+		}
+		index = nextWaitIndex(index, qm.LastIndex)
+
+		remaining := 0
+		for _, alloc := range allocs {
+			if ignoreSys && alloc.Job != nil && alloc.Job.Type == JobTypeSystem {
+				continue
+			}
+
+			if alloc.DesiredStatus == AllocDesiredStatusStop {
+				if _, ok := migrating[alloc.ID]; !ok {
+					migrating[alloc.ID] = struct{}{}
+					if !send(MonitorMsgLevelNormal, "Alloc %q marked for migration", alloc.ID) {
+						return
+					}
+				}
+			}
+
+			if !alloc.ClientTerminalStatus() {
+				remaining++
+				continue
+			}
+			if _, ok := migrating[alloc.ID]; !ok {
+				continue
+			}
+			if _, ok := stopped[alloc.ID]; !ok {
+				stopped[alloc.ID] = struct{}{}
+				if !send(MonitorMsgLevelNormal, "Alloc %q stopped", alloc.ID) {
+					return
+				}
+			}
+		}
+
+		node, _, err := n.Info(nodeID, (&QueryOptions{}).WithContext(ctx))
+		if err != nil {
+			if ctx.Err() == nil {
+				send(MonitorMsgLevelError, "Error monitoring node %q: %v", nodeID, err)
+			}
+			return
+		}
+		if !node.Drain {
+			send(MonitorMsgLevelInfo, "Drain of node %q disabled", nodeID)
+			return
+		}
+		if remaining == 0 {
+			send(MonitorMsgLevelInfo, "All allocations on node %q have stopped", nodeID)
+			return
+		}
+	}
+}
+
+// EligibleCount returns how many nodes the job could be placed on: ready
+// nodes that are not draining, in one of the datacenters of the job, that
+// satisfy the constraints of the job and those of at least one of its task
+// groups and their tasks. It is a quick check that a job can be scheduled at
+// all before registering it; resources are not taken into account.
+func (n *Nodes) EligibleCount(job *Job, q *QueryOptions) (int, error) {
+	stubs, _, err := n.List(q)
+	if err != nil {
+		return 0, err
+	}
+
+	datacenters := make(map[string]struct{}, len(job.Datacenters))
+	for _, dc := range job.Datacenters {
+		datacenters[dc] = struct{}{}
+	}
+
+	eligible := 0
+	for _, stub := range stubs {
+		if stub.Status != NodeStatusReady || stub.Drain {
+			continue
+		}
+		if _, ok := datacenters[stub.Datacenter]; !ok {
+			continue
+		}
+
+		// Constraints are evaluated against the attributes and meta of the
+		// node, which are only returned by its full info
+		node, _, err := n.Info(stub.ID, q)
+		if err != nil {
+			return 0, err
+		}
+		if job.feasibleNode(node) {
+			eligible++
+		}
+	}
+	return eligible, nil
+}
+
 // Allocations is used to return the allocations associated with a node.
 func (n *Nodes) Allocations(nodeID string, q *QueryOptions) ([]*Allocation, *QueryMeta, error) {
 	var resp []*Allocation
@@ -82,10 +393,7 @@ func (n *Nodes) Stats(nodeID string, q *QueryOptions) (*HostStats, error) {
 	if node.HTTPAddr == "" {
 		return nil, fmt.Errorf("http addr of the node %q is running is not advertised", nodeID)
 	}
-	client, err := NewClient(&Config{
-		Address:    fmt.Sprintf("http://%s", node.HTTPAddr),
-		HttpClient: cleanhttp.DefaultClient(),
-	})
+	client, err := NewClient(n.client.config.nodeConfig(fmt.Sprintf("http://%s", node.HTTPAddr)))
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +404,14 @@ func (n *Nodes) Stats(nodeID string, q *QueryOptions) (*HostStats, error) {
 	return &resp, nil
 }
 
+const (
+	// NodeStatusInit, NodeStatusReady and NodeStatusDown are the statuses of
+	// a node. Only ready nodes are scheduled on.
+	NodeStatusInit  = "initializing"
+	NodeStatusReady = "ready"
+	NodeStatusDown  = "down"
+)
+
 // Node is used to deserialize a node entry.
 type Node struct {
 	ID                string
@@ -114,6 +430,111 @@ type Node struct {
 	StatusUpdatedAt   int64
 	CreateIndex       uint64
 	ModifyIndex       uint64
+
+	// ReservedResources are the resources of the node withheld from
+	// scheduling for the operating system and other processes.
+	ReservedResources *NodeReservedResources `json:",omitempty"`
+
+	// Events are the most recent events of the node, oldest first. The
+	// servers only retain a bounded number of them.
+	Events []*NodeEvent `json:",omitempty"`
+}
+
+// RecentEvents returns up to num of the most recent events of the node, oldest
+// first.
+func (n *Node) RecentEvents(num int) []*NodeEvent {
+	if num <= 0 {
+		return nil
+	}
+	if num > len(n.Events) {
+		num = len(n.Events)
+	}
+	return n.Events[len(n.Events)-num:]
+}
+
+// AttributeConstraints returns equality constraints on the given attributes,
+// such as "kernel.name" or "driver.docker", pinned to their current values on
+// the node, so jobs can be scheduled on nodes like it. Attributes the node
+// doesn't have are skipped, as no constraint could match them.
+func (n *Node) AttributeConstraints(keys ...string) []*Constraint {
+	var constraints []*Constraint
+	for _, key := range keys {
+		val, ok := n.Attributes[key]
+		if !ok {
+			continue
+		}
+		constraints = addConstraint(constraints, NewConstraint(fmt.Sprintf("${attr.%s}", key), "=", val))
+	}
+	return constraints
+}
+
+// NodeReservedResources is the set of resources reserved on a node.
+type NodeReservedResources struct {
+	Cpu      NodeReservedCpuResources
+	Memory   NodeReservedMemoryResources
+	Disk     NodeReservedDiskResources
+	Networks NodeReservedNetworkResources
+}
+
+// NodeReservedCpuResources is the CPU reserved on a node.
+type NodeReservedCpuResources struct {
+	CpuShares int
+}
+
+// NodeReservedMemoryResources is the memory reserved on a node.
+type NodeReservedMemoryResources struct {
+	MemoryMB int
+}
+
+// NodeReservedDiskResources is the disk reserved on a node.
+type NodeReservedDiskResources struct {
+	DiskMB int
+}
+
+// NodeReservedNetworkResources is the set of host ports reserved on a node.
+type NodeReservedNetworkResources struct {
+	// ReservedHostPorts is a comma separated list of ports and port ranges,
+	// such as "22,80,8000-8080".
+	ReservedHostPorts string
+}
+
+// newNodeReservedResources converts a flat reserved resources block into its
+// structured form.
+func newNodeReservedResources(r *Resources) *NodeReservedResources {
+	out := &NodeReservedResources{
+		Cpu:    NodeReservedCpuResources{CpuShares: r.CPU},
+		Memory: NodeReservedMemoryResources{MemoryMB: r.MemoryMB},
+		Disk:   NodeReservedDiskResources{DiskMB: r.DiskMB},
+	}
+
+	var ports []string
+	for _, network := range r.Networks {
+		for _, port := range network.ReservedPorts {
+			ports = append(ports, strconv.Itoa(port.Value))
+		}
+	}
+	out.Networks.ReservedHostPorts = strings.Join(ports, ",")
+	return out
+}
+
+const (
+	// NodeEventSubsystemDrain, NodeEventSubsystemDriver, NodeEventSubsystemHeartbeat
+	// and NodeEventSubsystemCluster are the subsystems a node event may be
+	// emitted by.
+	NodeEventSubsystemDrain     = "Drain"
+	NodeEventSubsystemDriver    = "Driver"
+	NodeEventSubsystemHeartbeat = "Heartbeat"
+	NodeEventSubsystemCluster   = "Cluster"
+)
+
+// NodeEvent is a single event in the lifecycle of a node, such as it
+// missing heartbeats or a driver becoming unhealthy.
+type NodeEvent struct {
+	Message     string
+	Subsystem   string
+	Details     map[string]string `json:",omitempty"`
+	Timestamp   time.Time
+	CreateIndex uint64
 }
 
 // HostStats represents resource usage stats of the host running a Nomad client
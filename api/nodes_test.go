@@ -1,9 +1,14 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -268,3 +273,397 @@ func TestNodes_Sort(t *testing.T) {
 		t.Fatalf("\n\n%#v\n\n%#v", nodes, expect)
 	}
 }
+
+func TestNodes_Info_ReservedResourcesAndEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		fmt.Fprint(w, `{
+			"ID": "node1",
+			"Reserved": {
+				"CPU": 100,
+				"MemoryMB": 256,
+				"DiskMB": 1024,
+				"Networks": [{"ReservedPorts": [{"Label": "ssh", "Value": 22}, {"Label": "http", "Value": 80}]}]
+			},
+			"Events": [
+				{"Message": "Node registered", "Subsystem": "Cluster", "Timestamp": "2017-01-01T00:00:00Z"},
+				{"Message": "Heartbeat missed", "Subsystem": "Heartbeat", "Timestamp": "2017-01-01T00:01:00Z"},
+				{"Message": "Driver docker unhealthy", "Subsystem": "Driver",
+				 "Details": {"driver": "docker"}, "Timestamp": "2017-01-01T00:02:00Z"}
+			]
+		}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	node, _, err := c.Nodes().Info("node1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := &NodeReservedResources{
+		Cpu:      NodeReservedCpuResources{CpuShares: 100},
+		Memory:   NodeReservedMemoryResources{MemoryMB: 256},
+		Disk:     NodeReservedDiskResources{DiskMB: 1024},
+		Networks: NodeReservedNetworkResources{ReservedHostPorts: "22,80"},
+	}
+	if !reflect.DeepEqual(node.ReservedResources, expected) {
+		t.Fatalf("bad reserved resources: %#v", node.ReservedResources)
+	}
+
+	recent := node.RecentEvents(2)
+	if len(recent) != 2 {
+		t.Fatalf("expected 2 events, got: %d", len(recent))
+	}
+	if recent[0].Subsystem != NodeEventSubsystemHeartbeat || recent[1].Details["driver"] != "docker" {
+		t.Fatalf("bad events: %#v %#v", recent[0], recent[1])
+	}
+	if n := len(node.RecentEvents(10)); n != 3 {
+		t.Fatalf("expected all 3 events, got: %d", n)
+	}
+	if events := node.RecentEvents(0); events != nil {
+		t.Fatalf("expected no events, got: %#v", events)
+	}
+}
+
+func TestNodes_UpdateDrain(t *testing.T) {
+	var enable string
+	var submitted NodeUpdateDrainRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/node/node1/drain" {
+			http.NotFound(w, r)
+			return
+		}
+		enable = r.URL.Query().Get("enable")
+		submitted = NodeUpdateDrainRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&submitted); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		w.Header().Set("X-Nomad-Index", "10")
+		fmt.Fprint(w, `{"EvalIDs": ["eval1", "eval2"], "EvalCreateIndex": 10, "NodeModifyIndex": 9}`)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	nodes := c.Nodes()
+
+	spec := &DrainSpec{Deadline: time.Hour, IgnoreSystemJobs: true}
+	resp, wm, err := nodes.UpdateDrain("node1", spec, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if wm.LastIndex != 10 {
+		t.Fatalf("bad index: %d", wm.LastIndex)
+	}
+	if !reflect.DeepEqual(resp.EvalIDs, []string{"eval1", "eval2"}) {
+		t.Fatalf("bad eval ids: %v", resp.EvalIDs)
+	}
+	if enable != "true" || !reflect.DeepEqual(submitted.DrainSpec, spec) {
+		t.Fatalf("bad drain request: %q %#v", enable, submitted.DrainSpec)
+	}
+
+	// A nil spec disables the drain
+	if _, _, err := nodes.UpdateDrain("node1", nil, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if enable != "false" || submitted.DrainSpec != nil {
+		t.Fatalf("bad drain request: %q %#v", enable, submitted.DrainSpec)
+	}
+
+	// Negative deadlines are rejected
+	_, _, err = nodes.UpdateDrain("node1", &DrainSpec{Deadline: -1}, nil)
+	if err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Fatalf("expected deadline error, got: %v", err)
+	}
+}
+
+func TestNodes_MonitorDrain(t *testing.T) {
+	// Each allocation list moves the drain forward: the service alloc is
+	// marked for migration and then stops, while the system alloc keeps
+	// running and is ignored.
+	responses := []string{
+		`[{"ID": "alloc1", "DesiredStatus": "stop", "ClientStatus": "running", "Job": {"Type": "service"}},
+		  {"ID": "alloc2", "DesiredStatus": "run", "ClientStatus": "running", "Job": {"Type": "system"}}]`,
+		`[{"ID": "alloc1", "DesiredStatus": "stop", "ClientStatus": "complete", "Job": {"Type": "service"}},
+		  {"ID": "alloc2", "DesiredStatus": "run", "ClientStatus": "running", "Job": {"Type": "system"}}]`,
+	}
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/node/node1":
+			w.Header().Set("X-Nomad-Index", "1")
+			fmt.Fprint(w, `{"ID": "node1", "Drain": true}`)
+		case "/v1/node/node1/allocations":
+			if calls >= len(responses) {
+				t.Fatalf("unexpected allocation query")
+			}
+			w.Header().Set("X-Nomad-Index", strconv.Itoa(calls+10))
+			fmt.Fprint(w, responses[calls])
+			calls++
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var msgs []string
+	for msg := range c.Nodes().MonitorDrain(ctx, "node1", 0, true) {
+		if msg.Level == MonitorMsgLevelError {
+			t.Fatalf("unexpected error: %s", msg)
+		}
+		msgs = append(msgs, msg.String())
+	}
+
+	expected := []string{
+		`Alloc "alloc1" marked for migration`,
+		`Alloc "alloc1" stopped`,
+		`All allocations on node "node1" have stopped`,
+	}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Fatalf("bad messages: %#v", msgs)
+	}
+}
+
+func TestNodes_MonitorDrain_Cancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Block like a long-polling query until the client goes away
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	outCh := c.Nodes().MonitorDrain(ctx, "node1", 0, false)
+	cancel()
+
+	select {
+	case msg, ok := <-outCh:
+		if ok {
+			t.Fatalf("unexpected message: %s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("monitor did not stop after cancel")
+	}
+}
+
+func TestNodes_EligibleCount(t *testing.T) {
+	nodes := []*Node{
+		{ID: "node1", Datacenter: "dc1", Status: NodeStatusReady, Attributes: map[string]string{"kernel.name": "linux"}},
+		{ID: "node2", Datacenter: "dc1", Status: NodeStatusReady, Attributes: map[string]string{"kernel.name": "windows"}},
+		{ID: "node3", Datacenter: "dc2", Status: NodeStatusReady, Attributes: map[string]string{"kernel.name": "linux"},
+			Meta: map[string]string{"gpu": "true"}},
+		{ID: "node4", Datacenter: "dc3", Status: NodeStatusReady, Attributes: map[string]string{"kernel.name": "linux"}},
+		{ID: "node5", Datacenter: "dc1", Status: NodeStatusDown, Attributes: map[string]string{"kernel.name": "linux"}},
+		{ID: "node6", Datacenter: "dc1", Status: NodeStatusReady, Drain: true, Attributes: map[string]string{"kernel.name": "linux"}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		if r.URL.Path == "/v1/nodes" {
+			var stubs []*NodeListStub
+			for _, node := range nodes {
+				stubs = append(stubs, &NodeListStub{
+					ID:         node.ID,
+					Datacenter: node.Datacenter,
+					Drain:      node.Drain,
+					Status:     node.Status,
+				})
+			}
+			json.NewEncoder(w).Encode(stubs)
+			return
+		}
+		for _, node := range nodes {
+			if r.URL.Path == "/v1/node/"+node.ID {
+				json.NewEncoder(w).Encode(node)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job := testJob()
+	job.Datacenters = []string{"dc1", "dc2"}
+	job.Constrain(NewConstraint("${attr.kernel.name}", "=", "linux"))
+	count, err := c.Nodes().EligibleCount(job, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 eligible nodes, got %d", count)
+	}
+
+	// A node is eligible if any of the task groups can be placed on it
+	job.TaskGroups[0].Constrain(NewConstraint("${meta.gpu}", "=", "true"))
+	if count, _ := c.Nodes().EligibleCount(job, nil); count != 1 {
+		t.Fatalf("expected 1 eligible node, got %d", count)
+	}
+	job.AddTaskGroup(NewTaskGroup("cpu", 1).AddTask(NewTask("task", "exec")))
+	if count, _ := c.Nodes().EligibleCount(job, nil); count != 2 {
+		t.Fatalf("expected 2 eligible nodes, got %d", count)
+	}
+}
+
+func TestNodes_ListOpts_Attributes(t *testing.T) {
+	nodes := map[string]map[string]string{
+		"node1": {"gpu": "true", "kernel.name": "linux"},
+		"node2": {"gpu": "false", "kernel.name": "linux"},
+		"node3": {"gpu": "true", "kernel.name": "windows"},
+	}
+	serverSide := true
+	var infos int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		switch {
+		case r.URL.Path == "/v1/nodes":
+			filter := make(map[string]string)
+			for _, attr := range r.URL.Query()["attribute"] {
+				parts := strings.SplitN(attr, "=", 2)
+				filter[parts[0]] = parts[1]
+			}
+			var out []*NodeListStub
+			for id, attrs := range nodes {
+				node := &Node{ID: id, Attributes: attrs}
+				if serverSide && !node.hasAttributes(filter) {
+					continue
+				}
+				idx, _ := strconv.Atoi(strings.TrimPrefix(id, "node"))
+				out = append(out, &NodeListStub{ID: id, CreateIndex: uint64(idx)})
+			}
+			if serverSide && len(filter) != 0 {
+				w.Header().Set("X-Nomad-Node-Filter", "attributes")
+			}
+			json.NewEncoder(w).Encode(out)
+		case strings.HasPrefix(r.URL.Path, "/v1/node/"):
+			infos++
+			id := strings.TrimPrefix(r.URL.Path, "/v1/node/")
+			json.NewEncoder(w).Encode(&Node{ID: id, Attributes: nodes[id]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ids := func(resp *NodeListResponse) []string {
+		var out []string
+		for _, node := range resp.Nodes {
+			out = append(out, node.ID)
+		}
+		return out
+	}
+
+	opts := &NodeListOptions{Attributes: map[string]string{"gpu": "true"}}
+	resp, qm, err := c.Nodes().ListOpts(opts, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if qm.LastIndex != 10 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	if resp.FilterPath != NodeFilterPathServer || infos != 0 {
+		t.Fatalf("expected server side filtering, got %q with %d node queries", resp.FilterPath, infos)
+	}
+	if !reflect.DeepEqual(ids(resp), []string{"node3", "node1"}) {
+		t.Fatalf("bad nodes: %v", ids(resp))
+	}
+
+	// Servers ignoring the filter fall back to client side filtering
+	serverSide = false
+	opts.Attributes["kernel.name"] = "linux"
+	resp, _, err = c.Nodes().ListOpts(opts, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.FilterPath != NodeFilterPathClient || infos != 3 {
+		t.Fatalf("expected client side filtering, got %q with %d node queries", resp.FilterPath, infos)
+	}
+	if !reflect.DeepEqual(ids(resp), []string{"node1"}) {
+		t.Fatalf("bad nodes: %v", ids(resp))
+	}
+
+	// Without a filter the nodes are listed as is
+	resp, _, err = c.Nodes().ListOpts(nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.FilterPath != NodeFilterPathNone || len(resp.Nodes) != 3 {
+		t.Fatalf("bad response: %#v", resp)
+	}
+}
+
+func TestNode_AttributeConstraints(t *testing.T) {
+	node := &Node{
+		ID: "node1",
+		Attributes: map[string]string{
+			"kernel.name":     "linux",
+			"arch":            "amd64",
+			"driver.docker":   "1",
+			"unique.hostname": "host1",
+		},
+	}
+
+	// Missing attributes are skipped and duplicates collapsed
+	constraints := node.AttributeConstraints("kernel.name", "driver.docker", "driver.java", "kernel.name")
+	expect := []*Constraint{
+		NewConstraint("${attr.kernel.name}", "=", "linux"),
+		NewConstraint("${attr.driver.docker}", "=", "1"),
+	}
+	if !reflect.DeepEqual(constraints, expect) {
+		t.Fatalf("expect: %#v, got: %#v", expect, constraints)
+	}
+	if len(node.AttributeConstraints()) != 0 {
+		t.Fatalf("expected no constraints without keys")
+	}
+
+	// The constraints match the node and nodes like it
+	like := &Node{ID: "node2", Attributes: map[string]string{"kernel.name": "linux", "driver.docker": "1"}}
+	unlike := &Node{ID: "node3", Attributes: map[string]string{"kernel.name": "windows", "driver.docker": "1"}}
+	for _, c := range constraints {
+		if !c.matchesNode(node) || !c.matchesNode(like) {
+			t.Fatalf("expected %#v to match", c)
+		}
+	}
+	if constraints[0].matchesNode(unlike) {
+		t.Fatalf("expected node with another kernel not to match")
+	}
+}
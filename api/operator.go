@@ -0,0 +1,32 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Operator is used to perform low-level operator tasks on the servers.
+type Operator struct {
+	client *Client
+}
+
+// Operator returns a handle to the operator endpoints.
+func (c *Client) Operator() *Operator {
+	return &Operator{client: c}
+}
+
+// RaftRemovePeerByAddress is used to remove the server with the given
+// address, such as "10.0.0.1:4647", from the Raft peers of its region. It is
+// used to clean up dead servers that can't leave the cluster themselves.
+// Setting the Region of the options targets the servers of that region.
+func (op *Operator) RaftRemovePeerByAddress(address string, q *WriteOptions) error {
+	if address == "" {
+		return fmt.Errorf("missing peer address")
+	}
+	v := url.Values{}
+	v.Set("address", address)
+	if _, err := op.client.delete("/v1/operator/raft/peer?"+v.Encode(), nil, nil, q); err != nil {
+		return err
+	}
+	return nil
+}
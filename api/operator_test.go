@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestOperator_RaftRemovePeerByAddress(t *testing.T) {
+	var method string
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/operator/raft/peer" {
+			http.NotFound(w, r)
+			return
+		}
+		method, query = r.Method, r.URL.Query()
+		w.Header().Set("X-Nomad-Index", "1")
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := c.Operator().RaftRemovePeerByAddress("", nil); err == nil {
+		t.Fatalf("expected missing address error")
+	}
+
+	q := &WriteOptions{Region: "europe"}
+	if err := c.Operator().RaftRemovePeerByAddress("10.0.0.1:4647", q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if method != "DELETE" {
+		t.Fatalf("bad method: %s", method)
+	}
+	if query.Get("address") != "10.0.0.1:4647" || query.Get("region") != "europe" {
+		t.Fatalf("bad query: %v", query)
+	}
+}
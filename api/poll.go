@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// pollMaxBackoffFactor bounds the delay between the polls of Poll as a
+	// multiple of its initial interval.
+	pollMaxBackoffFactor = 16
+)
+
+// ErrPollTimeout is returned by Poll when the condition isn't met within
+// its timeout.
+var ErrPollTimeout = errors.New("timed out polling")
+
+// PollFunc checks the condition a poll waits for. It returns whether the
+// condition is met, or an error to stop polling.
+type PollFunc func() (done bool, err error)
+
+// Poll calls fn until it reports that it is done, returns an error, the
+// timeout elapses or the context is done. The delay between calls starts at
+// interval and doubles after each call, up to 16 times the interval, so that
+// slow conditions don't hammer the servers. A timeout of zero only bounds
+// polling by the context.
+//
+// The error of fn is returned as is. Once the timeout elapses
+// ErrPollTimeout is returned, while the error of the context is returned
+// once it is done.
+func Poll(ctx context.Context, interval, timeout time.Duration, fn PollFunc) error {
+	if interval <= 0 {
+		return fmt.Errorf("poll interval must be positive")
+	}
+	return poll(ctx, interval, interval*pollMaxBackoffFactor, timeout, fn)
+}
+
+// poll implements Poll with the given bound on the delay between calls.
+func poll(ctx context.Context, interval, maxInterval, timeout time.Duration, fn PollFunc) error {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	backoff := interval
+	for {
+		done, err := fn()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		wait := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			wait.Stop()
+			return ctx.Err()
+		case <-deadline:
+			wait.Stop()
+			return ErrPollTimeout
+		case <-wait.C:
+		}
+
+		backoff *= 2
+		if backoff > maxInterval {
+			backoff = maxInterval
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoll_ImmediateSuccess(t *testing.T) {
+	calls := 0
+	err := Poll(context.Background(), time.Hour, time.Hour, func() (bool, error) {
+		calls++
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestPoll_EventualSuccess(t *testing.T) {
+	var calls []time.Time
+	err := Poll(context.Background(), 10*time.Millisecond, time.Minute, func() (bool, error) {
+		calls = append(calls, time.Now())
+		return len(calls) == 4, nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("expected 4 calls, got %d", len(calls))
+	}
+
+	// The delay between calls backs off
+	if first, last := calls[1].Sub(calls[0]), calls[3].Sub(calls[2]); last < 2*first {
+		t.Fatalf("expected backoff, got delays %s and %s", first, last)
+	}
+}
+
+func TestPoll_Timeout(t *testing.T) {
+	start := time.Now()
+	err := Poll(context.Background(), 5*time.Millisecond, 50*time.Millisecond, func() (bool, error) {
+		return false, nil
+	})
+	if err != ErrPollTimeout {
+		t.Fatalf("expected timeout error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timed out after %s", elapsed)
+	}
+}
+
+func TestPoll_Errors(t *testing.T) {
+	// Errors of the condition stop polling
+	condErr := errors.New("job was purged")
+	calls := 0
+	err := Poll(context.Background(), time.Millisecond, time.Minute, func() (bool, error) {
+		calls++
+		if calls == 2 {
+			return false, condErr
+		}
+		return false, nil
+	})
+	if err != condErr || calls != 2 {
+		t.Fatalf("expected condition error after 2 calls, got %v after %d", err, calls)
+	}
+
+	// So does cancelling the context
+	ctx, cancel := context.WithCancel(context.Background())
+	err = Poll(ctx, time.Millisecond, 0, func() (bool, error) {
+		cancel()
+		return false, nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected context error, got: %v", err)
+	}
+
+	if err := Poll(context.Background(), 0, 0, nil); err == nil {
+		t.Fatalf("expected interval error")
+	}
+}
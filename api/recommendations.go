@@ -0,0 +1,185 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// RecommendationResourceCPU and RecommendationResourceMemoryMB are the
+	// task resources a recommendation may be made for.
+	RecommendationResourceCPU      = "CPU"
+	RecommendationResourceMemoryMB = "MemoryMB"
+)
+
+// Recommendations is used to query the recommendation endpoints.
+type Recommendations struct {
+	client *Client
+}
+
+// Recommendations returns a new handle on the recommendations.
+func (c *Client) Recommendations() *Recommendations {
+	return &Recommendations{client: c}
+}
+
+// List is used to dump all of the recommendations.
+func (r *Recommendations) List(q *QueryOptions) ([]*Recommendation, *QueryMeta, error) {
+	var resp []*Recommendation
+	qm, err := r.client.query("/v1/recommendations", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(RecommendationIndexSort(resp))
+	return resp, qm, nil
+}
+
+// Info is used to query a single recommendation by its ID.
+func (r *Recommendations) Info(id string, q *QueryOptions) (*Recommendation, *QueryMeta, error) {
+	var resp Recommendation
+	qm, err := r.client.query("/v1/recommendation/"+id, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Upsert is used to create or update a recommendation. The stored
+// recommendation is returned.
+func (r *Recommendations) Upsert(rec *Recommendation, q *WriteOptions) (*Recommendation, *WriteMeta, error) {
+	if err := rec.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if r.client.config.DryRun {
+		return nil, &WriteMeta{DryRun: &DryRunResult{Operation: fmt.Sprintf("upsert recommendation for job %q", rec.JobID), Validated: true}}, nil
+	}
+
+	var resp Recommendation
+	wm, err := r.client.write("/v1/recommendation", rec, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Delete is used to dismiss a set of recommendations without applying them.
+func (r *Recommendations) Delete(ids []string, q *WriteOptions) (*WriteMeta, error) {
+	req := &RecommendationDeleteRequest{IDs: ids}
+	wm, err := r.client.delete("/v1/recommendations", req, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Apply is used to apply a set of recommendations, updating the resources of
+// the tasks they were made for. Recommendations for the same job are applied
+// in a single job update.
+func (r *Recommendations) Apply(ids []string, q *WriteOptions) (*RecommendationApplyResponse, *WriteMeta, error) {
+	req := &RecommendationApplyRequest{Apply: ids}
+	var resp RecommendationApplyResponse
+	wm, err := r.client.write("/v1/recommendations/apply", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Recommendation is a suggested value for a resource of a task.
+type Recommendation struct {
+	ID       string
+	Region   string
+	JobID    string
+	Group    string
+	Task     string
+	Resource string
+
+	// Current is the value of the resource at the time the recommendation
+	// was made and Value the recommended one.
+	Current int
+	Value   int
+
+	Meta        map[string]interface{} `json:",omitempty"`
+	SubmitTime  int64
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Validate is used to sanity check a recommendation.
+func (r *Recommendation) Validate() error {
+	var mErr multierror.Error
+	if r.JobID == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing job ID"))
+	}
+	if r.Group == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing task group"))
+	}
+	if r.Task == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing task"))
+	}
+	switch r.Resource {
+	case RecommendationResourceCPU, RecommendationResourceMemoryMB:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("resource must be %q or %q",
+			RecommendationResourceCPU, RecommendationResourceMemoryMB))
+	}
+	if r.Value <= 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("value must be greater than 0 but found %d", r.Value))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// RecommendationDeleteRequest is used to dismiss recommendations.
+type RecommendationDeleteRequest struct {
+	IDs []string
+}
+
+// RecommendationApplyRequest is used to apply recommendations.
+type RecommendationApplyRequest struct {
+	Apply []string
+}
+
+// RecommendationApplyResponse is used to respond to applying
+// recommendations.
+type RecommendationApplyResponse struct {
+	// UpdatedJobs are the jobs updated by the applied recommendations.
+	UpdatedJobs []*RecommendationApplyResult
+
+	// Errors are the recommendations that could not be applied.
+	Errors []*RecommendationApplyError
+}
+
+// RecommendationApplyResult is the update of a single job by applying
+// recommendations.
+type RecommendationApplyResult struct {
+	JobID           string
+	JobModifyIndex  uint64
+	EvalID          string
+	EvalCreateIndex uint64
+	Recommendations []string
+}
+
+// RecommendationApplyError is the failure to apply recommendations to a
+// single job.
+type RecommendationApplyError struct {
+	JobID           string
+	Recommendations []string
+	Error           string
+}
+
+// RecommendationIndexSort is a wrapper to sort recommendations by
+// CreateIndex. We reverse the test so that we get the highest index first.
+type RecommendationIndexSort []*Recommendation
+
+func (r RecommendationIndexSort) Len() int {
+	return len(r)
+}
+
+func (r RecommendationIndexSort) Less(i, j int) bool {
+	return r[i].CreateIndex > r[j].CreateIndex
+}
+
+func (r RecommendationIndexSort) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+func TestRecommendation_Validate(t *testing.T) {
+	rec := &Recommendation{
+		JobID:    "job1",
+		Group:    "group1",
+		Task:     "task1",
+		Resource: RecommendationResourceMemoryMB,
+		Value:    512,
+	}
+	if err := rec.Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rec.Resource = "IOPS"
+	if err := rec.Validate(); err == nil || !strings.Contains(err.Error(), "resource must be") {
+		t.Fatalf("expected resource error, got: %v", err)
+	}
+
+	err := (&Recommendation{}).Validate()
+	mErr := err.(*multierror.Error)
+	if len(mErr.Errors) != 5 {
+		t.Fatalf("expected 5 errors, got: %v", err)
+	}
+}
+
+func TestRecommendations(t *testing.T) {
+	stored := make(map[string]*Recommendation)
+	var applied, deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		enc := json.NewEncoder(w)
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/v1/recommendation":
+			var rec Recommendation
+			if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			rec.ID = fmt.Sprintf("rec%d", len(stored)+1)
+			rec.CreateIndex = uint64(len(stored) + 1)
+			stored[rec.ID] = &rec
+			enc.Encode(&rec)
+		case r.Method == "GET" && r.URL.Path == "/v1/recommendations":
+			var out []*Recommendation
+			for _, rec := range stored {
+				out = append(out, rec)
+			}
+			enc.Encode(out)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/recommendation/"):
+			rec, ok := stored[strings.TrimPrefix(r.URL.Path, "/v1/recommendation/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			enc.Encode(rec)
+		case r.Method == "PUT" && r.URL.Path == "/v1/recommendations/apply":
+			var req RecommendationApplyRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			applied = req.Apply
+			enc.Encode(&RecommendationApplyResponse{
+				UpdatedJobs: []*RecommendationApplyResult{{
+					JobID:           "job1",
+					EvalID:          "eval1",
+					Recommendations: req.Apply,
+				}},
+			})
+		case r.Method == "DELETE" && r.URL.Path == "/v1/recommendations":
+			var req RecommendationDeleteRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			deleted = req.IDs
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recs := c.Recommendations()
+
+	// Invalid recommendations are rejected before being submitted
+	if _, _, err := recs.Upsert(&Recommendation{JobID: "job1"}, nil); err == nil {
+		t.Fatalf("expected validation error")
+	}
+	if len(stored) != 0 {
+		t.Fatalf("invalid recommendation submitted")
+	}
+
+	for _, resource := range []string{RecommendationResourceCPU, RecommendationResourceMemoryMB} {
+		rec := &Recommendation{
+			JobID:    "job1",
+			Group:    "group1",
+			Task:     "task1",
+			Resource: resource,
+			Current:  100,
+			Value:    200,
+		}
+		out, wm, err := recs.Upsert(rec, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		assertWriteMeta(t, wm)
+		if out.ID == "" || out.Resource != resource {
+			t.Fatalf("bad recommendation: %#v", out)
+		}
+	}
+
+	// Recommendations are listed newest first
+	list, qm, err := recs.List(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(list) != 2 || list[0].ID != "rec2" || list[1].ID != "rec1" {
+		t.Fatalf("bad recommendations: %#v", list)
+	}
+
+	rec, _, err := recs.Info("rec1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if rec.Resource != RecommendationResourceCPU || rec.Current != 100 || rec.Value != 200 {
+		t.Fatalf("bad recommendation: %#v", rec)
+	}
+
+	resp, _, err := recs.Apply([]string{"rec1"}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(applied, []string{"rec1"}) {
+		t.Fatalf("bad applied recommendations: %v", applied)
+	}
+	if len(resp.UpdatedJobs) != 1 || resp.UpdatedJobs[0].EvalID != "eval1" {
+		t.Fatalf("bad apply response: %#v", resp)
+	}
+
+	if _, err := recs.Delete([]string{"rec2"}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"rec2"}) {
+		t.Fatalf("bad deleted recommendations: %v", deleted)
+	}
+}
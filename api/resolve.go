@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Context is a kind of object whose ID can be resolved from a prefix.
+type Context string
+
+const (
+	// ContextAllocs, ContextEvals, ContextJobs and ContextNodes are the
+	// contexts of allocations, evaluations, jobs and nodes.
+	ContextAllocs Context = "allocs"
+	ContextEvals  Context = "evals"
+	ContextJobs   Context = "jobs"
+	ContextNodes  Context = "nodes"
+)
+
+// AmbiguousPrefixError is returned when resolving a prefix that matches the
+// IDs of more than one object.
+type AmbiguousPrefixError struct {
+	Context Context
+	Prefix  string
+
+	// Matches are the matching IDs, sorted.
+	Matches []string
+}
+
+func (e *AmbiguousPrefixError) Error() string {
+	return fmt.Sprintf("prefix %q matched multiple %s: %s",
+		e.Prefix, e.Context, strings.Join(e.Matches, ", "))
+}
+
+// resolvePrefix expands the prefix of the ID of an object of the given context
+// to its full ID. An ID that is itself the prefix of other IDs resolves to
+// itself. If the prefix matches several IDs, an *AmbiguousPrefixError listing
+// them is returned. The prefix is matched by the prefix filter of the list
+// endpoint of the context, which every server supports.
+func (c *Client) resolvePrefix(context Context, prefix string, q *QueryOptions) (string, error) {
+	if prefix == "" {
+		return "", fmt.Errorf("prefix must be specified")
+	}
+
+	var endpoint string
+	switch context {
+	case ContextAllocs:
+		endpoint = "/v1/allocations"
+	case ContextEvals:
+		endpoint = "/v1/evaluations"
+	case ContextJobs:
+		endpoint = "/v1/jobs"
+	case ContextNodes:
+		endpoint = "/v1/nodes"
+	default:
+		return "", fmt.Errorf("unknown context %q", context)
+	}
+
+	pq := new(QueryOptions)
+	if q != nil {
+		*pq = *q
+	}
+	pq.Prefix = prefix
+
+	var resp []struct{ ID string }
+	if _, err := c.query(endpoint, &resp, pq); err != nil {
+		return "", err
+	}
+
+	matches := make([]string, 0, len(resp))
+	for _, obj := range resp {
+		if obj.ID == prefix {
+			return obj.ID, nil
+		}
+		matches = append(matches, obj.ID)
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no %s with prefix %q found", context, prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		sort.Strings(matches)
+		return "", &AmbiguousPrefixError{Context: context, Prefix: prefix, Matches: matches}
+	}
+}
+
+// ResolveID expands the prefix of a job ID to the full job ID.
+func (j *Jobs) ResolveID(prefix string, q *QueryOptions) (string, error) {
+	return j.client.resolvePrefix(ContextJobs, prefix, q)
+}
+
+// ResolveID expands the prefix of an allocation ID to the full allocation ID.
+func (a *Allocations) ResolveID(prefix string, q *QueryOptions) (string, error) {
+	return a.client.resolvePrefix(ContextAllocs, prefix, q)
+}
+
+// ResolveID expands the prefix of an evaluation ID to the full evaluation ID.
+func (e *Evaluations) ResolveID(prefix string, q *QueryOptions) (string, error) {
+	return e.client.resolvePrefix(ContextEvals, prefix, q)
+}
+
+// ResolveID expands the prefix of a node ID to the full node ID.
+func (n *Nodes) ResolveID(prefix string, q *QueryOptions) (string, error) {
+	return n.client.resolvePrefix(ContextNodes, prefix, q)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolvePrefix(t *testing.T) {
+	ids := map[string][]string{
+		"/v1/jobs":        {"web", "web2", "worker"},
+		"/v1/allocations": {"aaaa1111", "aaaa2222", "bbbb1111"},
+		"/v1/evaluations": {"cccc1111"},
+		"/v1/nodes":       {"dddd1111"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		all, ok := ids[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var out []map[string]string
+		for _, id := range all {
+			if strings.HasPrefix(id, r.URL.Query().Get("prefix")) {
+				out = append(out, map[string]string{"ID": id})
+			}
+		}
+		w.Header().Set("X-Nomad-Index", "1")
+		json.NewEncoder(w).Encode(out)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		resolve func(string, *QueryOptions) (string, error)
+		prefix  string
+		id      string
+		err     string
+	}{
+		{c.Jobs().ResolveID, "wo", "worker", ""},
+		{c.Jobs().ResolveID, "web", "web", ""},
+		{c.Jobs().ResolveID, "w", "", `prefix "w" matched multiple jobs: web, web2, worker`},
+		{c.Jobs().ResolveID, "api", "", `no jobs with prefix "api" found`},
+		{c.Allocations().ResolveID, "bb", "bbbb1111", ""},
+		{c.Allocations().ResolveID, "aaaa", "", "matched multiple allocs"},
+		{c.Evaluations().ResolveID, "cc", "cccc1111", ""},
+		{c.Nodes().ResolveID, "dd", "dddd1111", ""},
+		{c.Nodes().ResolveID, "", "", "prefix must be specified"},
+	}
+	for _, tc := range cases {
+		id, err := tc.resolve(tc.prefix, nil)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("prefix %q: expected error %q, got: %v", tc.prefix, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("prefix %q: err: %v", tc.prefix, err)
+		}
+		if id != tc.id {
+			t.Fatalf("prefix %q: expected %q, got %q", tc.prefix, tc.id, id)
+		}
+	}
+
+	// Ambiguous prefixes list the candidates
+	_, err = c.Allocations().ResolveID("aaaa", nil)
+	aErr, ok := err.(*AmbiguousPrefixError)
+	if !ok {
+		t.Fatalf("expected *AmbiguousPrefixError, got: %#v", err)
+	}
+	if !reflect.DeepEqual(aErr.Matches, []string{"aaaa1111", "aaaa2222"}) {
+		t.Fatalf("bad matches: %v", aErr.Matches)
+	}
+}
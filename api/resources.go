@@ -1,13 +1,230 @@
 package api
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// NetworkModeHost, NetworkModeBridge and NetworkModeNone are the network
+	// modes of a task group. Host networking shares the network namespace of
+	// the node, bridge networking gives the group its own namespace bridged
+	// to the node and none leaves the group without networking.
+	NetworkModeHost   = "host"
+	NetworkModeBridge = "bridge"
+	NetworkModeNone   = "none"
+
+	// NetworkModeCNIPrefix prefixes the name of the CNI network of the cni
+	// network mode, such as "cni/mynet".
+	NetworkModeCNIPrefix = "cni/"
+)
+
 // Resources encapsulates the required resources of
 // a given task or task group.
 type Resources struct {
-	CPU      int
-	MemoryMB int
-	DiskMB   int
-	IOPS     int
-	Networks []*NetworkResource
+	CPU      int                `json:",omitempty"`
+	MemoryMB int                `json:",omitempty"`
+	DiskMB   int                `json:",omitempty"`
+	IOPS     int                `json:",omitempty"`
+	Networks []*NetworkResource `json:",omitempty"`
+
+	// RequestedDevices are the devices, such as GPUs, required by the task.
+	RequestedDevices []*RequestedDevice `json:",omitempty"`
+}
+
+// Validate is used to sanity check the resources.
+func (r *Resources) Validate() error {
+	var mErr multierror.Error
+	for idx, device := range r.RequestedDevices {
+		if device == nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Device %d must not be empty", idx+1))
+			continue
+		}
+		if err := device.Validate(); err != nil {
+			outer := fmt.Errorf("Device %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// RequestedDevice is a request for devices of a task.
+type RequestedDevice struct {
+	// Name selects the devices by type, vendor and type, or vendor, type
+	// and model, such as "gpu", "nvidia/gpu" or "nvidia/gpu/Tesla K80".
+	Name string
+
+	// Count is the number of devices required. If zero, one is required.
+	Count uint64 `json:",omitempty"`
+
+	// Constraints restrict the devices that may be selected by their
+	// attributes, such as ${device.attr.memory}.
+	Constraints []*Constraint `json:",omitempty"`
+}
+
+// NewRequestedDevice returns a request for count devices of the given name.
+func NewRequestedDevice(name string, count uint64) *RequestedDevice {
+	return &RequestedDevice{Name: name, Count: count}
+}
+
+// Constrain is used to add a constraint to the device request.
+func (d *RequestedDevice) Constrain(c *Constraint) *RequestedDevice {
+	d.Constraints = addConstraint(d.Constraints, c)
+	return d
+}
+
+// Validate is used to sanity check a device request.
+func (d *RequestedDevice) Validate() error {
+	var mErr multierror.Error
+	parts := strings.Split(d.Name, "/")
+	if len(parts) > 3 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("device name must be of the form <type>, <vendor>/<type> or <vendor>/<type>/<model> but found %q", d.Name))
+	} else {
+		for _, part := range parts {
+			if strings.TrimSpace(part) == "" {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("device name must be of the form <type>, <vendor>/<type> or <vendor>/<type>/<model> but found %q", d.Name))
+				break
+			}
+		}
+	}
+	for idx, constr := range d.Constraints {
+		if err := constr.Validate(); err != nil {
+			outer := fmt.Errorf("Constraint %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// String renders the resources in human units, such as
+// "cpu: 1.5GHz, memory: 2GiB, disk: 300MiB". Unset resources are omitted.
+func (r *Resources) String() string {
+	var parts []string
+	if r.CPU != 0 {
+		parts = append(parts, "cpu: "+formatCPUMHz(r.CPU))
+	}
+	if r.MemoryMB != 0 {
+		parts = append(parts, "memory: "+formatMemoryMB(r.MemoryMB))
+	}
+	if r.DiskMB != 0 {
+		parts = append(parts, "disk: "+formatMemoryMB(r.DiskMB))
+	}
+	if r.IOPS != 0 {
+		parts = append(parts, fmt.Sprintf("iops: %d", r.IOPS))
+	}
+	mbits := 0
+	for _, n := range r.Networks {
+		if n != nil {
+			mbits += n.MBits
+		}
+	}
+	if mbits != 0 {
+		parts = append(parts, fmt.Sprintf("network: %dMbits", mbits))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// memoryUnits are the sizes of the units accepted by ParseMemoryMB in
+// mebibytes, the unit of the MemoryMB and DiskMB resources. Decimal suffixes
+// scale by powers of 1000 bytes and binary suffixes by powers of 1024 bytes.
+var memoryUnits = map[string]float64{
+	"":    1,
+	"kb":  1e3 / (1 << 20),
+	"kib": 1.0 / 1024,
+	"mb":  1e6 / (1 << 20),
+	"mib": 1,
+	"gb":  1e9 / (1 << 20),
+	"gib": 1024,
+	"tb":  1e12 / (1 << 20),
+	"tib": 1024 * 1024,
+}
+
+// cpuUnits are the multiples of a megahertz accepted by ParseCPUMHz.
+var cpuUnits = map[string]float64{
+	"":    1,
+	"mhz": 1,
+	"ghz": 1000,
+}
+
+// ParseMemoryMB parses a human readable size, such as "512MiB", "1.5GB" or
+// "2048", into the mebibytes of the MemoryMB and DiskMB resources. Numbers
+// without a suffix are taken as mebibytes. Suffixes are case insensitive and
+// sizes in decimal units are rounded to the nearest mebibyte, so "1GB" is 954.
+// Sizes in binary units must be a whole number of mebibytes.
+func ParseMemoryMB(s string) (int, error) {
+	return parseUnits(s, memoryUnits, "mebibytes")
+}
+
+// ParseCPUMHz parses a human readable frequency, such as "500MHz" or
+// "1.5GHz", into megahertz. Numbers without a suffix are taken as megahertz.
+// Suffixes are case insensitive and the frequency must be a whole number of
+// megahertz.
+func ParseCPUMHz(s string) (int, error) {
+	return parseUnits(s, cpuUnits, "megahertz")
+}
+
+// parseUnits parses a number followed by an optional unit suffix, returning
+// the number scaled by the multiple of the unit.
+func parseUnits(s string, units map[string]float64, unitName string) (int, error) {
+	trimmed := strings.TrimSpace(s)
+	split := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if split == -1 {
+		split = len(trimmed)
+	}
+	number, suffix := trimmed[:split], strings.ToLower(strings.TrimSpace(trimmed[split:]))
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q: must be a number with an optional unit", s)
+	}
+	multiple, ok := units[suffix]
+	if !ok {
+		return 0, fmt.Errorf("invalid value %q: unknown unit %q", s, trimmed[split:])
+	}
+
+	scaled := value * multiple
+	rounded := math.Round(scaled)
+	// Units that aren't a whole multiple or fraction of the base unit, such
+	// as decimal memory units, are rounded to the nearest base unit.
+	exact := multiple == math.Trunc(multiple) || 1/multiple == math.Trunc(1/multiple)
+	if exact && math.Abs(scaled-rounded) > 1e-9 {
+		return 0, fmt.Errorf("invalid value %q: must be a whole number of %s", s, unitName)
+	}
+	if rounded == 0 && value != 0 {
+		return 0, fmt.Errorf("invalid value %q: rounds to zero %s", s, unitName)
+	}
+	if rounded > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid value %q: too large", s)
+	}
+	return int(rounded), nil
+}
+
+// formatCPUMHz renders megahertz in the largest unit that keeps the value
+// exact.
+func formatCPUMHz(mhz int) string {
+	if mhz >= 1000 {
+		return strconv.FormatFloat(float64(mhz)/1000, 'f', -1, 64) + "GHz"
+	}
+	return strconv.Itoa(mhz) + "MHz"
+}
+
+// formatMemoryMB renders mebibytes in the largest binary unit that divides
+// them, so that the result parses back to the same value.
+func formatMemoryMB(mb int) string {
+	switch {
+	case mb != 0 && mb%(1024*1024) == 0:
+		return strconv.Itoa(mb/(1024*1024)) + "TiB"
+	case mb != 0 && mb%1024 == 0:
+		return strconv.Itoa(mb/1024) + "GiB"
+	default:
+		return strconv.Itoa(mb) + "MiB"
+	}
 }
 
 type Port struct {
@@ -24,4 +241,36 @@ type NetworkResource struct {
 	DynamicPorts  []Port
 	IP            string
 	MBits         int
+
+	// Mode is the network mode of a group network. If unset, the host mode
+	// is used.
+	Mode string `json:",omitempty"`
+
+	// CNI is passed to the CNI plugin of a network in the cni mode.
+	CNI *CNIConfig `json:",omitempty"`
+}
+
+// CNIConfig is the configuration passed to the CNI plugin of a network.
+type CNIConfig struct {
+	// Args are passed to the plugin as CNI_ARGS.
+	Args map[string]string `json:",omitempty"`
+}
+
+// Validate is used to sanity check the mode of a network.
+func (n *NetworkResource) Validate() error {
+	var mErr multierror.Error
+	switch {
+	case n.Mode == "", n.Mode == NetworkModeHost, n.Mode == NetworkModeBridge, n.Mode == NetworkModeNone:
+		if n.CNI != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("CNI config is only allowed in the %q network mode", NetworkModeCNIPrefix+"<name>"))
+		}
+	case strings.HasPrefix(n.Mode, NetworkModeCNIPrefix):
+		if n.Mode == NetworkModeCNIPrefix {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("CNI network mode must name a network"))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("network mode must be one of %q, %q, %q or %q but found %q",
+			NetworkModeHost, NetworkModeBridge, NetworkModeNone, NetworkModeCNIPrefix+"<name>", n.Mode))
+	}
+	return mErr.ErrorOrNil()
 }
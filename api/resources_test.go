@@ -0,0 +1,148 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMemoryMB(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected int
+		err      bool
+	}{
+		{"512", 512, false},
+		{"512MiB", 512, false},
+		{"512 mib", 512, false},
+		{"512MB", 488, false},
+		{"1GB", 954, false},
+		{"1GiB", 1024, false},
+		{"1.5GiB", 1536, false},
+		{"1.5gb", 1431, false},
+		{"1TB", 953674, false},
+		{"1TiB", 1048576, false},
+		{"2048KiB", 2, false},
+		{"3000KB", 3, false},
+		{"1500KiB", 0, true},
+		{"0.5MiB", 0, true},
+		{"100KB", 0, true},
+		{"", 0, true},
+		{"GiB", 0, true},
+		{"1.2.3MB", 0, true},
+		{"10PB", 0, true},
+		{"-1MB", 0, true},
+	}
+	for _, c := range cases {
+		out, err := ParseMemoryMB(c.input)
+		if c.err {
+			if err == nil {
+				t.Fatalf("%q: expected error, got %d", c.input, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: err: %v", c.input, err)
+		}
+		if out != c.expected {
+			t.Fatalf("%q: expected %d, got %d", c.input, c.expected, out)
+		}
+	}
+}
+
+func TestParseCPUMHz(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected int
+		err      bool
+	}{
+		{"500", 500, false},
+		{"500MHz", 500, false},
+		{"500mhz", 500, false},
+		{"1GHz", 1000, false},
+		{"1.5 GHz", 1500, false},
+		{"2.0005GHz", 0, true},
+		{"1Hz", 0, true},
+		{"fast", 0, true},
+	}
+	for _, c := range cases {
+		out, err := ParseCPUMHz(c.input)
+		if c.err {
+			if err == nil {
+				t.Fatalf("%q: expected error, got %d", c.input, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: err: %v", c.input, err)
+		}
+		if out != c.expected {
+			t.Fatalf("%q: expected %d, got %d", c.input, c.expected, out)
+		}
+	}
+}
+
+func TestResources_String(t *testing.T) {
+	r := &Resources{
+		CPU:      1500,
+		MemoryMB: 2048,
+		DiskMB:   300,
+		IOPS:     10,
+		Networks: []*NetworkResource{{MBits: 10}, {MBits: 5}},
+	}
+	expected := "cpu: 1.5GHz, memory: 2GiB, disk: 300MiB, iops: 10, network: 15Mbits"
+	if out := r.String(); out != expected {
+		t.Fatalf("expected %q, got %q", expected, out)
+	}
+
+	if out := (&Resources{CPU: 250}).String(); out != "cpu: 250MHz" {
+		t.Fatalf("bad: %q", out)
+	}
+
+	// Rendered units parse back to the same values
+	for _, mb := range []int{1, 512, 1024, 1536, 1048576} {
+		out, err := ParseMemoryMB(formatMemoryMB(mb))
+		if err != nil || out != mb {
+			t.Fatalf("%d: round trip gave %d (%v)", mb, out, err)
+		}
+	}
+	for _, mhz := range []int{1, 999, 1000, 1500, 2333} {
+		out, err := ParseCPUMHz(formatCPUMHz(mhz))
+		if err != nil || out != mhz {
+			t.Fatalf("%d: round trip gave %d (%v)", mhz, out, err)
+		}
+	}
+}
+
+func TestRequestedDevice_Validate(t *testing.T) {
+	for _, name := range []string{"gpu", "nvidia/gpu", "nvidia/gpu/Tesla K80"} {
+		device := NewRequestedDevice(name, 2)
+		if err := device.Validate(); err != nil {
+			t.Fatalf("name %q: err: %s", name, err)
+		}
+	}
+	for _, name := range []string{"", "nvidia/", "/gpu", "nvidia/gpu/Tesla K80/x"} {
+		device := NewRequestedDevice(name, 1)
+		if err := device.Validate(); err == nil || !strings.Contains(err.Error(), "device name must be") {
+			t.Fatalf("name %q: expected name error, got: %v", name, err)
+		}
+	}
+
+	// Device constraints are validated along with the task
+	device := NewRequestedDevice("nvidia/gpu", 1).
+		Constrain(NewConstraint("${device.attr.memory}", ">=", "2 GiB")).
+		Constrain(&Constraint{LTarget: "${device.model}"})
+	if len(device.Constraints) != 2 {
+		t.Fatalf("expected 2 constraints, got: %#v", device.Constraints)
+	}
+	task := NewTask("task1", "exec").Require(&Resources{RequestedDevices: []*RequestedDevice{device}})
+	err := task.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Device 1 validation failed") || !strings.Contains(err.Error(), "Constraint 2") {
+		t.Fatalf("expected constraint error, got: %v", err)
+	}
+
+	// Empty device requests are reported rather than dereferenced
+	resources := &Resources{RequestedDevices: []*RequestedDevice{NewRequestedDevice("gpu", 1), nil}}
+	if err := resources.Validate(); err == nil || !strings.Contains(err.Error(), "Device 2 must not be empty") {
+		t.Fatalf("expected empty device error, got: %v", err)
+	}
+}
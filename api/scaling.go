@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// ScalingTargetJob and ScalingTargetGroup are the keys of the target of
+	// a scaling policy set for policies defined inline in a task group, and
+	// of the target of a scaling request.
+	ScalingTargetJob   = "Job"
+	ScalingTargetGroup = "Group"
+)
+
+// Scaling is used to query the scaling policy endpoints.
+type Scaling struct {
+	client *Client
+}
+
+// Scaling returns a new handle on the scaling policies.
+func (c *Client) Scaling() *Scaling {
+	return &Scaling{client: c}
+}
+
+// ListPolicies is used to list the scaling policies of all jobs.
+func (s *Scaling) ListPolicies(q *QueryOptions) ([]*ScalingPolicyListStub, *QueryMeta, error) {
+	var resp []*ScalingPolicyListStub
+	qm, err := s.client.query("/v1/scaling/policies", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(ScalingPolicyIDSort(resp))
+	return resp, qm, nil
+}
+
+// GetPolicy is used to query a single scaling policy by its ID.
+func (s *Scaling) GetPolicy(id string, q *QueryOptions) (*ScalingPolicy, *QueryMeta, error) {
+	var resp ScalingPolicy
+	qm, err := s.client.query("/v1/scaling/policy/"+id, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// ScalingPolicy is the scaling policy of a task group. The policy itself is
+// opaque to Nomad and is interpreted by the autoscaler.
+type ScalingPolicy struct {
+	ID string `json:",omitempty"`
+
+	// Target identifies what is scaled. It is set by the servers for
+	// policies defined inline in a task group.
+	Target map[string]string `json:",omitempty"`
+
+	// Min and Max bound the count of the group.
+	Min int64
+	Max int64
+
+	Enabled bool
+	Policy  map[string]interface{} `json:",omitempty"`
+
+	CreateIndex uint64 `json:",omitempty"`
+	ModifyIndex uint64 `json:",omitempty"`
+}
+
+// Validate is used to sanity check a scaling policy.
+func (p *ScalingPolicy) Validate() error {
+	var mErr multierror.Error
+	if p.Min < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum count must be greater than or equal to 0 but found %d", p.Min))
+	}
+	if p.Max < p.Min {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("maximum count %d must be greater than or equal to the minimum %d", p.Max, p.Min))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// ScalingPolicyListStub is used to return a subset of a scaling policy.
+type ScalingPolicyListStub struct {
+	ID          string
+	Enabled     bool
+	Target      map[string]string
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// ScalingPolicyIDSort is used to sort scaling policies by their ID's.
+type ScalingPolicyIDSort []*ScalingPolicyListStub
+
+func (s ScalingPolicyIDSort) Len() int {
+	return len(s)
+}
+
+func (s ScalingPolicyIDSort) Less(i, j int) bool {
+	return s[i].ID < s[j].ID
+}
+
+func (s ScalingPolicyIDSort) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
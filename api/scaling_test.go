@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScaling_Policies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "7")
+		switch r.URL.Path {
+		case "/v1/scaling/policies":
+			fmt.Fprint(w, `[
+				{"ID": "policy2", "Enabled": false, "Target": {"Job": "job2", "Group": "cache"}},
+				{"ID": "policy1", "Enabled": true, "Target": {"Job": "job1", "Group": "web"}}
+			]`)
+		case "/v1/scaling/policy/policy1":
+			fmt.Fprint(w, `{
+				"ID": "policy1",
+				"Target": {"Job": "job1", "Group": "web"},
+				"Min": 1,
+				"Max": 10,
+				"Enabled": true,
+				"Policy": {"cooldown": "1m"}
+			}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	scaling := c.Scaling()
+
+	stubs, qm, err := scaling.ListPolicies(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if qm.LastIndex != 7 {
+		t.Fatalf("bad index: %d", qm.LastIndex)
+	}
+	if len(stubs) != 2 || stubs[0].ID != "policy1" || stubs[1].ID != "policy2" {
+		t.Fatalf("bad policies: %#v", stubs)
+	}
+	if stubs[0].Target[ScalingTargetGroup] != "web" || !stubs[0].Enabled {
+		t.Fatalf("bad policy: %#v", stubs[0])
+	}
+
+	policy, _, err := scaling.GetPolicy("policy1", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if policy.Min != 1 || policy.Max != 10 || !policy.Enabled {
+		t.Fatalf("bad policy: %#v", policy)
+	}
+	if policy.Policy["cooldown"] != "1m" {
+		t.Fatalf("bad policy document: %#v", policy.Policy)
+	}
+
+	if _, _, err := scaling.GetPolicy("nope", nil); err == nil {
+		t.Fatalf("expected error for a missing policy")
+	}
+}
+
+func TestScalingPolicy_Validate(t *testing.T) {
+	policy := &ScalingPolicy{Min: 1, Max: 5, Enabled: true}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	policy.Max = 0
+	if err := policy.Validate(); err == nil || !strings.Contains(err.Error(), "maximum count") {
+		t.Fatalf("expected maximum error, got: %v", err)
+	}
+
+	// Inline policies bound the count of their group
+	grp := NewTaskGroup("web", 10).SetScaling(&ScalingPolicy{Min: 1, Max: 5})
+	if err := grp.Validate(); err == nil || !strings.Contains(err.Error(), "scaling policy bounds") {
+		t.Fatalf("expected bounds error, got: %v", err)
+	}
+	grp.SetCount(3)
+	if err := grp.Validate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
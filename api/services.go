@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Services is used to query the endpoints of the services registered with
+// Nomad's native service discovery.
+type Services struct {
+	client *Client
+}
+
+// Services returns a new handle on the services.
+func (c *Client) Services() *Services {
+	return &Services{client: c}
+}
+
+// List is used to list the names and tags of the registered services,
+// grouped by namespace.
+func (s *Services) List(q *QueryOptions) ([]*ServiceRegistrationListStub, *QueryMeta, error) {
+	var resp []*ServiceRegistrationListStub
+	qm, err := s.client.query("/v1/services", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// Get is used to query the registered instances of a service by its name.
+// Instances are sorted by the allocation running them.
+func (s *Services) Get(name string, q *QueryOptions) ([]*ServiceRegistration, *QueryMeta, error) {
+	if name == "" {
+		return nil, nil, fmt.Errorf("missing service name")
+	}
+	var resp []*ServiceRegistration
+	qm, err := s.client.query("/v1/service/"+name, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(ServiceRegistrationSort(resp))
+	return resp, qm, nil
+}
+
+// Delete is used to deregister a single instance of a service by its ID.
+// Instances are registered and deregistered by the clients running them, so
+// this is only needed to clean up instances left behind by lost clients.
+func (s *Services) Delete(name, id string, q *WriteOptions) (*WriteMeta, error) {
+	if name == "" || id == "" {
+		return nil, fmt.Errorf("missing service name or ID")
+	}
+	wm, err := s.client.delete("/v1/service/"+name+"/"+id, nil, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// ServiceRegistration is an instance of a service registered by the task
+// or group of an allocation.
+type ServiceRegistration struct {
+	ID          string
+	ServiceName string
+	Namespace   string
+	NodeID      string
+	Datacenter  string
+	JobID       string
+	AllocID     string
+	Tags        []string
+	Address     string
+	Port        int
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// ServiceRegistrationListStub lists the services of a namespace.
+type ServiceRegistrationListStub struct {
+	Namespace string
+	Services  []*ServiceRegistrationStub
+}
+
+// ServiceRegistrationStub is the name of a service and the tags of its
+// instances.
+type ServiceRegistrationStub struct {
+	ServiceName string
+	Tags        []string
+}
+
+// ServiceRegistrationSort is used to sort service instances by the
+// allocation running them and their IDs.
+type ServiceRegistrationSort []*ServiceRegistration
+
+func (s ServiceRegistrationSort) Len() int {
+	return len(s)
+}
+
+func (s ServiceRegistrationSort) Less(i, j int) bool {
+	if s[i].AllocID != s[j].AllocID {
+		return s[i].AllocID < s[j].AllocID
+	}
+	return s[i].ID < s[j].ID
+}
+
+func (s ServiceRegistrationSort) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServices(t *testing.T) {
+	var deleted string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "10")
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/services":
+			fmt.Fprint(w, `[{"Namespace": "default", "Services": [
+				{"ServiceName": "redis", "Tags": ["cache"]},
+				{"ServiceName": "web", "Tags": ["http", "public"]}
+			]}]`)
+		case r.Method == "GET" && r.URL.Path == "/v1/service/web":
+			fmt.Fprint(w, `[
+				{"ID": "_nomad-task-alloc2-web", "ServiceName": "web", "AllocID": "alloc2",
+				 "Datacenter": "dc1", "Address": "10.0.0.2", "Port": 8080, "Tags": ["http"]},
+				{"ID": "_nomad-task-alloc1-web", "ServiceName": "web", "AllocID": "alloc1",
+				 "Datacenter": "dc1", "Address": "10.0.0.1", "Port": 8080, "Tags": ["http"]}
+			]`)
+		case r.Method == "DELETE":
+			deleted = r.URL.Path
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	services := c.Services()
+
+	list, qm, err := services.List(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(list) != 1 || len(list[0].Services) != 2 || list[0].Services[1].ServiceName != "web" {
+		t.Fatalf("bad services: %#v", list)
+	}
+
+	// Instances are sorted by allocation
+	instances, _, err := services.Get("web", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(instances) != 2 || instances[0].AllocID != "alloc1" || instances[0].Address != "10.0.0.1" ||
+		instances[0].Port != 8080 || instances[0].Datacenter != "dc1" {
+		t.Fatalf("bad instances: %#v", instances)
+	}
+
+	if _, _, err := services.Get("", nil); err == nil {
+		t.Fatalf("expected missing name error")
+	}
+
+	wm, err := services.Delete("web", instances[1].ID, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertWriteMeta(t, wm)
+	if deleted != "/v1/service/web/_nomad-task-alloc2-web" {
+		t.Fatalf("bad deleted path: %q", deleted)
+	}
+}
@@ -1,5 +1,11 @@
 package api
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 // Status is used to query the status-related endpoints.
 type Status struct {
 	client *Client
@@ -20,6 +26,36 @@ func (s *Status) Leader() (string, error) {
 	return resp, nil
 }
 
+const (
+	// waitForLeaderMinBackoff and waitForLeaderMaxBackoff bound the delay
+	// between the polls of WaitForLeader.
+	waitForLeaderMinBackoff = 50 * time.Millisecond
+	waitForLeaderMaxBackoff = time.Second
+)
+
+// WaitForLeader blocks until the cluster has elected a leader or the context
+// is done, polling the leader with a short backoff. Errors querying the
+// leader, such as the servers not having a leader yet, are retried.
+func (s *Status) WaitForLeader(ctx context.Context) error {
+	var lastErr error
+	err := poll(ctx, waitForLeaderMinBackoff, waitForLeaderMaxBackoff, 0, func() (bool, error) {
+		var leader string
+		q := (&QueryOptions{}).WithContext(ctx)
+		_, err := s.client.query("/v1/status/leader", &leader, q)
+		if err != nil {
+			lastErr = err
+		}
+		return err == nil && leader != "", nil
+	})
+	if err == nil {
+		return nil
+	}
+	if lastErr != nil {
+		return fmt.Errorf("timed out waiting for a leader: %v", lastErr)
+	}
+	return fmt.Errorf("timed out waiting for a leader: %v", err)
+}
+
 // RegionLeader is used to query for the leader in the passed region.
 func (s *Status) RegionLeader(region string) (string, error) {
 	var resp string
@@ -1,7 +1,12 @@
 package api
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestStatus_Leader(t *testing.T) {
@@ -18,3 +23,58 @@ func TestStatus_Leader(t *testing.T) {
 		t.Fatalf("expected leader, got: %q", out)
 	}
 }
+
+func TestStatus_WaitForLeader(t *testing.T) {
+	// The first polls fail or report no leader before one is elected
+	responses := []func(w http.ResponseWriter){
+		func(w http.ResponseWriter) { http.Error(w, "No cluster leader", http.StatusInternalServerError) },
+		func(w http.ResponseWriter) { w.Write([]byte(`""`)) },
+		func(w http.ResponseWriter) { w.Write([]byte(`"127.0.0.1:4647"`)) },
+	}
+	var polls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		responses[polls](w)
+		if polls < len(responses)-1 {
+			polls++
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Status().WaitForLeader(ctx); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if polls != 2 {
+		t.Fatalf("expected the leader to be polled until elected, polls: %d", polls)
+	}
+}
+
+func TestStatus_WaitForLeader_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "No cluster leader", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err = c.Status().WaitForLeader(ctx)
+	if err == nil || !strings.Contains(err.Error(), "No cluster leader") {
+		t.Fatalf("expected timeout error, got: %v", err)
+	}
+}
@@ -1,7 +1,12 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/hashicorp/go-multierror"
 )
 
 // MemoryStats holds memory usage related stats
@@ -71,6 +76,93 @@ type ServiceCheck struct {
 	Interval      time.Duration
 	Timeout       time.Duration
 	InitialStatus string `mapstructure:"initial_status"`
+	Method        string
+	Header        map[string][]string
+	TLSSkipVerify bool          `mapstructure:"tls_skip_verify"`
+	CheckRestart  *CheckRestart `mapstructure:"check_restart"`
+}
+
+const (
+	// ServiceCheckHTTP, ServiceCheckTCP and ServiceCheckScript are the
+	// types of service checks. Script checks run a command in the task of
+	// the service, while the others probe the service over the network.
+	ServiceCheckHTTP   = "http"
+	ServiceCheckTCP    = "tcp"
+	ServiceCheckScript = "script"
+)
+
+// CheckRestart describes if and when a task should be restarted based on
+// failing health checks.
+type CheckRestart struct {
+	// Limit is the number of consecutive failures after which the task is
+	// restarted. Zero disables restarts.
+	Limit int `mapstructure:"limit"`
+
+	// Grace is the duration to wait after a task starts or restarts before
+	// failures are counted.
+	Grace time.Duration `mapstructure:"grace"`
+
+	// IgnoreWarnings treats checks in the warning state as passing.
+	IgnoreWarnings bool `mapstructure:"ignore_warnings"`
+}
+
+// Validate is used to sanity check a check restart stanza.
+func (c *CheckRestart) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	var mErr multierror.Error
+	if c.Limit < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("limit must be greater than or equal to 0 but found %d", c.Limit))
+	}
+	if c.Grace < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("grace period must be greater than or equal to 0 but found %s", c.Grace))
+	}
+	return mErr.ErrorOrNil()
+}
+
+const (
+	// HealthPassing, HealthWarning and HealthCritical are the Consul health
+	// states a check can be initially put in.
+	HealthPassing  = "passing"
+	HealthWarning  = "warning"
+	HealthCritical = "critical"
+)
+
+// Validate is used to sanity check a service check.
+func (sc *ServiceCheck) Validate() error {
+	var mErr multierror.Error
+
+	// The servers match check types regardless of case
+	switch strings.ToLower(sc.Type) {
+	case ServiceCheckHTTP, ServiceCheckTCP:
+	case ServiceCheckScript:
+		if sc.Command == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("script checks must have a command"))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("check type must be %q, %q or %q",
+			ServiceCheckHTTP, ServiceCheckTCP, ServiceCheckScript))
+	}
+
+	switch sc.Method {
+	case "", "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "TRACE", "CONNECT":
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("method %q is not a valid HTTP method", sc.Method))
+	}
+
+	switch sc.InitialStatus {
+	case "", HealthPassing, HealthWarning, HealthCritical:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("initial status must be %q, %q, or %q",
+			HealthPassing, HealthWarning, HealthCritical))
+	}
+
+	if err := sc.CheckRestart.Validate(); err != nil {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("check_restart validation failed: %s", err))
+	}
+	return mErr.ErrorOrNil()
 }
 
 // The Service model represents a Consul service definition
@@ -80,6 +172,76 @@ type Service struct {
 	Tags      []string
 	PortLabel string `mapstructure:"port"`
 	Checks    []ServiceCheck
+
+	// AddressMode selects which address is advertised for the service. It
+	// is one of "auto", "host" or "driver" and defaults to "auto".
+	AddressMode string `mapstructure:"address_mode"`
+
+	// Connect configures the Consul Connect integration of the service.
+	Connect *ConsulConnect `json:",omitempty"`
+
+	// Provider is the service discovery the service is registered with,
+	// "consul" or "nomad". It defaults to "consul".
+	Provider string `json:",omitempty"`
+}
+
+const (
+	// ServiceProviderConsul and ServiceProviderNomad are the service
+	// discovery providers services can be registered with.
+	ServiceProviderConsul = "consul"
+	ServiceProviderNomad  = "nomad"
+)
+
+const (
+	// AddressModeAuto advertises the address reported by the driver if it
+	// has one and the host address otherwise.
+	AddressModeAuto = "auto"
+
+	// AddressModeHost advertises the host address.
+	AddressModeHost = "host"
+
+	// AddressModeDriver advertises the address of the network reported by
+	// the driver, such as the container IP of a bridge network.
+	AddressModeDriver = "driver"
+)
+
+// Validate is used to sanity check a service and its checks.
+func (s *Service) Validate() error {
+	var mErr multierror.Error
+	switch s.AddressMode {
+	case "", AddressModeAuto, AddressModeHost, AddressModeDriver:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("address mode must be %q, %q, or %q",
+			AddressModeAuto, AddressModeHost, AddressModeDriver))
+	}
+	switch s.Provider {
+	case "", ServiceProviderConsul, ServiceProviderNomad:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("provider must be %q or %q",
+			ServiceProviderConsul, ServiceProviderNomad))
+	}
+	for idx, check := range s.Checks {
+		if err := check.Validate(); err != nil {
+			outer := fmt.Errorf("Check %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+
+		// Nomad only runs a subset of the checks Consul does
+		checkType := strings.ToLower(check.Type)
+		if s.Provider == ServiceProviderNomad && checkType != ServiceCheckHTTP && checkType != ServiceCheckTCP {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Check %d: type %q is not supported by the %q provider, only %q and %q checks are",
+				idx+1, check.Type, ServiceProviderNomad, ServiceCheckHTTP, ServiceCheckTCP))
+		}
+	}
+	if s.Connect != nil && s.Provider == ServiceProviderNomad {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Connect is only supported by the %q provider", ServiceProviderConsul))
+	}
+	if s.Connect != nil {
+		if err := s.Connect.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Connect validation failed: %s", err))
+		}
+	}
+	return mErr.ErrorOrNil()
 }
 
 // EphemeralDisk is an ephemeral disk object
@@ -91,29 +253,134 @@ type EphemeralDisk struct {
 
 // TaskGroup is the unit of scheduling.
 type TaskGroup struct {
-	Name          string
-	Count         int
-	Constraints   []*Constraint
-	Tasks         []*Task
-	RestartPolicy *RestartPolicy
-	EphemeralDisk *EphemeralDisk
-	Meta          map[string]string
+	Name string
+
+	// Count is the number of allocations of the group. Unset counts are
+	// distinguished from a count of 0: when a job is registered, an unset
+	// count keeps the current count of the group, or defaults to the
+	// minimum of the scaling policy, or 1, for new groups.
+	Count *int `json:",omitempty"`
+
+	Constraints   []*Constraint     `json:",omitempty"`
+	Tasks         []*Task           `json:",omitempty"`
+	RestartPolicy *RestartPolicy    `json:",omitempty"`
+	EphemeralDisk *EphemeralDisk    `json:",omitempty"`
+	Meta          map[string]string `json:",omitempty"`
+
+	// Timeout is the maximum duration allocations of the group may run
+	// before they are killed and marked as failed. It may only be set on
+	// groups of batch jobs.
+	Timeout time.Duration `json:",omitempty"`
+
+	// Scaling is the policy used by autoscalers to scale the count of the
+	// group.
+	Scaling *ScalingPolicy `json:",omitempty"`
+
+	// Networks are the networks shared by the tasks of the group, such as
+	// a bridge network. A group may only have one network.
+	Networks []*NetworkResource `json:",omitempty"`
+
+	// Volumes are the volumes requested by the group, keyed by the names
+	// its tasks mount them by.
+	Volumes map[string]*VolumeRequest `json:",omitempty"`
 }
 
 // NewTaskGroup creates a new TaskGroup.
 func NewTaskGroup(name string, count int) *TaskGroup {
 	return &TaskGroup{
 		Name:  name,
-		Count: count,
+		Count: &count,
+	}
+}
+
+// SetCount sets the count of the task group.
+func (g *TaskGroup) SetCount(count int) *TaskGroup {
+	g.Count = &count
+	return g
+}
+
+// GetCount returns the count of the task group, or 0 if it is unset.
+func (g *TaskGroup) GetCount() int {
+	if g.Count == nil {
+		return 0
 	}
+	return *g.Count
 }
 
-// Constrain is used to add a constraint to a task group.
+// Canonicalize fills in the count of the task group if it is unset, using
+// the minimum of its scaling policy or 1.
+func (g *TaskGroup) Canonicalize() {
+	if g.Count != nil {
+		return
+	}
+	count := 1
+	if g.Scaling != nil {
+		count = int(g.Scaling.Min)
+	}
+	g.Count = &count
+}
+
+// Constrain is used to add a constraint to a task group. Constraints
+// identical to an existing constraint of the group are skipped.
 func (g *TaskGroup) Constrain(c *Constraint) *TaskGroup {
-	g.Constraints = append(g.Constraints, c)
+	g.Constraints = addConstraint(g.Constraints, c)
 	return g
 }
 
+// Validate is used to sanity check a task group and its tasks.
+func (g *TaskGroup) Validate() error {
+	var mErr multierror.Error
+	if g.Count != nil && *g.Count < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Task group count can't be negative"))
+	}
+	for idx, constr := range g.Constraints {
+		if err := constr.Validate(); err != nil {
+			outer := fmt.Errorf("Constraint %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	if g.Scaling != nil {
+		if err := g.Scaling.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Scaling policy validation failed: %s", err))
+		} else if g.Count != nil && (int64(*g.Count) < g.Scaling.Min || int64(*g.Count) > g.Scaling.Max) {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Task group count %d must be between the scaling policy bounds [%d, %d]",
+				*g.Count, g.Scaling.Min, g.Scaling.Max))
+		}
+	}
+	if len(g.Networks) > 1 {
+		mErr.Errors = append(mErr.Errors, errors.New("Only one network may be specified for a task group"))
+	}
+	for idx, net := range g.Networks {
+		if net == nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Network %d must not be empty", idx+1))
+			continue
+		}
+		if err := net.Validate(); err != nil {
+			outer := fmt.Errorf("Network %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	for name, vol := range g.Volumes {
+		if err := vol.Validate(); err != nil {
+			outer := fmt.Errorf("Volume %s validation failed: %s", name, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	for _, task := range g.Tasks {
+		if err := task.Validate(); err != nil {
+			outer := fmt.Errorf("Task %s validation failed: %s", task.Name, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+		for idx, mount := range task.VolumeMounts {
+			if _, ok := g.Volumes[mount.Volume]; mount.Volume != "" && !ok {
+				outer := fmt.Errorf("Task %s volume mount %d references undeclared volume %q", task.Name, idx+1, mount.Volume)
+				mErr.Errors = append(mErr.Errors, outer)
+			}
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
 // AddMeta is used to add a meta k/v pair to a task group
 func (g *TaskGroup) SetMeta(key, val string) *TaskGroup {
 	if g.Meta == nil {
@@ -123,12 +390,40 @@ func (g *TaskGroup) SetMeta(key, val string) *TaskGroup {
 	return g
 }
 
+// AddNetwork is used to add a network shared by the tasks of the group.
+func (g *TaskGroup) AddNetwork(n *NetworkResource) *TaskGroup {
+	g.Networks = append(g.Networks, n)
+	return g
+}
+
+// AddVolume is used to add a volume request to a task group, keyed by its
+// name.
+func (g *TaskGroup) AddVolume(v *VolumeRequest) *TaskGroup {
+	if g.Volumes == nil {
+		g.Volumes = make(map[string]*VolumeRequest)
+	}
+	g.Volumes[v.Name] = v
+	return g
+}
+
 // AddTask is used to add a new task to a task group.
 func (g *TaskGroup) AddTask(t *Task) *TaskGroup {
 	g.Tasks = append(g.Tasks, t)
 	return g
 }
 
+// SetTimeout sets the maximum run duration of the task group
+func (g *TaskGroup) SetTimeout(timeout time.Duration) *TaskGroup {
+	g.Timeout = timeout
+	return g
+}
+
+// SetScaling sets the scaling policy of the task group
+func (g *TaskGroup) SetScaling(policy *ScalingPolicy) *TaskGroup {
+	g.Scaling = policy
+	return g
+}
+
 // RequireDisk adds a ephemeral disk to the task group
 func (g *TaskGroup) RequireDisk(disk *EphemeralDisk) *TaskGroup {
 	g.EphemeralDisk = disk
@@ -145,18 +440,117 @@ type LogConfig struct {
 type Task struct {
 	Name        string
 	Driver      string
-	User        string
-	Config      map[string]interface{}
-	Constraints []*Constraint
-	Env         map[string]string
-	Services    []Service
-	Resources   *Resources
-	Meta        map[string]string
-	KillTimeout time.Duration
-	LogConfig   *LogConfig
-	Artifacts   []*TaskArtifact
-	Vault       *Vault
-	Templates   []*Template
+	User        string                 `json:",omitempty"`
+	Config      map[string]interface{} `json:",omitempty"`
+	Constraints []*Constraint          `json:",omitempty"`
+	Env         map[string]string      `json:",omitempty"`
+	Services    []Service              `json:",omitempty"`
+	Resources   *Resources             `json:",omitempty"`
+	Meta        map[string]string      `json:",omitempty"`
+	KillTimeout time.Duration          `json:",omitempty"`
+	LogConfig   *LogConfig             `json:",omitempty"`
+	Artifacts   []*TaskArtifact        `json:",omitempty"`
+	Vault       *Vault                 `json:",omitempty"`
+	Templates   []*Template            `json:",omitempty"`
+
+	// DispatchPayload configures where the payload of a dispatched
+	// parameterized job is written for the task.
+	DispatchPayload *DispatchPayload `json:",omitempty"`
+
+	// ShutdownDelay is the duration to wait between deregistering the
+	// task's services from Consul and killing the task, allowing in-flight
+	// connections to drain.
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay" json:",omitempty"`
+
+	// VolumeMounts mount volumes of the task group into the task.
+	VolumeMounts []*VolumeMount `json:",omitempty"`
+
+	// Identity configures how the default workload identity of the task is
+	// exposed to it, while Identities declare additional identities, such
+	// as for authenticating to Vault or Consul.
+	Identity   *WorkloadIdentity   `json:",omitempty"`
+	Identities []*WorkloadIdentity `json:",omitempty"`
+}
+
+const (
+	// WorkloadIdentityDefaultName is the name of the default identity of a
+	// task.
+	WorkloadIdentityDefaultName = "default"
+
+	// WorkloadIdentityChangeModeNoop, WorkloadIdentityChangeModeRestart and
+	// WorkloadIdentityChangeModeSignal are the ways a task is notified that
+	// its identity token has been renewed.
+	WorkloadIdentityChangeModeNoop    = "noop"
+	WorkloadIdentityChangeModeRestart = "restart"
+	WorkloadIdentityChangeModeSignal  = "signal"
+)
+
+// WorkloadIdentity configures how the signed identity of a task is exposed to
+// it. The token is injected as an environment variable, written to the
+// secrets directory of the task, or both.
+type WorkloadIdentity struct {
+	Name string `json:",omitempty"`
+
+	// Audience is the audience claim of the token.
+	Audience []string `json:",omitempty"`
+
+	Env  bool
+	File bool
+
+	// ChangeMode and ChangeSignal determine how the task is notified when
+	// the token is renewed.
+	ChangeMode   string `json:",omitempty"`
+	ChangeSignal string `json:",omitempty"`
+}
+
+// NewWorkloadIdentity returns a new workload identity with the given name.
+func NewWorkloadIdentity(name string) *WorkloadIdentity {
+	return &WorkloadIdentity{Name: name}
+}
+
+// SetEnv sets whether the token is injected as an environment variable.
+func (w *WorkloadIdentity) SetEnv(env bool) *WorkloadIdentity {
+	w.Env = env
+	return w
+}
+
+// SetFile sets whether the token is written to the secrets directory.
+func (w *WorkloadIdentity) SetFile(file bool) *WorkloadIdentity {
+	w.File = file
+	return w
+}
+
+// AddAudience adds an audience to the token of the identity.
+func (w *WorkloadIdentity) AddAudience(aud string) *WorkloadIdentity {
+	w.Audience = append(w.Audience, aud)
+	return w
+}
+
+// Validate is used to sanity check a workload identity.
+func (w *WorkloadIdentity) Validate() error {
+	var mErr multierror.Error
+	if !w.Env && !w.File {
+		mErr.Errors = append(mErr.Errors, errors.New("at least one of env or file must be set"))
+	}
+	for idx, aud := range w.Audience {
+		if aud == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("audience %d must not be empty", idx+1))
+		}
+	}
+	switch w.ChangeMode {
+	case "", WorkloadIdentityChangeModeNoop, WorkloadIdentityChangeModeRestart:
+		if w.ChangeSignal != "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("change signal is only allowed with the %q change mode", WorkloadIdentityChangeModeSignal))
+		}
+	case WorkloadIdentityChangeModeSignal:
+		if w.ChangeSignal == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("change signal must be set with the %q change mode", WorkloadIdentityChangeModeSignal))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("change mode must be one of %q, %q or %q but found %q",
+			WorkloadIdentityChangeModeNoop, WorkloadIdentityChangeModeRestart, WorkloadIdentityChangeModeSignal, w.ChangeMode))
+	}
+	return mErr.ErrorOrNil()
 }
 
 // TaskArtifact is used to download artifacts before running a task.
@@ -176,6 +570,11 @@ type Template struct {
 	Once         bool
 }
 
+// DispatchPayload configures how a task gets its input from a job dispatch
+type DispatchPayload struct {
+	File string
+}
+
 type Vault struct {
 	Policies []string
 	Env      bool
@@ -208,15 +607,22 @@ func (t *Task) SetMeta(key, val string) *Task {
 	return t
 }
 
+// SetUser sets the user the task is run as.
+func (t *Task) SetUser(user string) *Task {
+	t.User = user
+	return t
+}
+
 // Require is used to add resource requirements to a task.
 func (t *Task) Require(r *Resources) *Task {
 	t.Resources = r
 	return t
 }
 
-// Constraint adds a new constraints to a single task.
+// Constraint adds a new constraints to a single task. Constraints identical
+// to an existing constraint of the task are skipped.
 func (t *Task) Constrain(c *Constraint) *Task {
-	t.Constraints = append(t.Constraints, c)
+	t.Constraints = addConstraint(t.Constraints, c)
 	return t
 }
 
@@ -226,13 +632,121 @@ func (t *Task) SetLogConfig(l *LogConfig) *Task {
 	return t
 }
 
+// SetShutdownDelay sets the delay between deregistering the task's services
+// and killing it.
+func (t *Task) SetShutdownDelay(delay time.Duration) *Task {
+	t.ShutdownDelay = delay
+	return t
+}
+
+// AddVolumeMount is used to mount a volume of the task group into the task.
+func (t *Task) AddVolumeMount(m *VolumeMount) *Task {
+	t.VolumeMounts = append(t.VolumeMounts, m)
+	return t
+}
+
+// AddIdentity adds an additional workload identity to the task.
+func (t *Task) AddIdentity(w *WorkloadIdentity) *Task {
+	t.Identities = append(t.Identities, w)
+	return t
+}
+
+// Validate is used to sanity check a task.
+func (t *Task) Validate() error {
+	var mErr multierror.Error
+	if strings.ContainsAny(t.User, " \t\r\n") {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("user must not contain whitespace but found %q", t.User))
+	}
+	if t.Resources != nil {
+		if err := t.Resources.Validate(); err != nil {
+			outer := fmt.Errorf("Resources validation failed: %s", err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	for idx, constr := range t.Constraints {
+		if err := constr.Validate(); err != nil {
+			outer := fmt.Errorf("Constraint %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	for _, service := range t.Services {
+		if err := service.Validate(); err != nil {
+			outer := fmt.Errorf("Service %s validation failed: %s", service.Name, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	for idx, mount := range t.VolumeMounts {
+		if err := mount.Validate(); err != nil {
+			outer := fmt.Errorf("Volume mount %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	if t.ShutdownDelay < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("shutdown delay must be greater than or equal to 0 but found %s", t.ShutdownDelay))
+	}
+	if t.Identity != nil {
+		if t.Identity.Name != "" && t.Identity.Name != WorkloadIdentityDefaultName {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Identity must be named %q but found %q", WorkloadIdentityDefaultName, t.Identity.Name))
+		}
+		if err := t.Identity.Validate(); err != nil {
+			outer := fmt.Errorf("Identity validation failed: %s", err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	names := make(map[string]struct{}, len(t.Identities))
+	for idx, w := range t.Identities {
+		if w.Name == "" || w.Name == WorkloadIdentityDefaultName {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Identity %d must have a name other than %q", idx+1, WorkloadIdentityDefaultName))
+		} else if _, ok := names[w.Name]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Identity %d has duplicate name %q", idx+1, w.Name))
+		}
+		names[w.Name] = struct{}{}
+		if err := w.Validate(); err != nil {
+			outer := fmt.Errorf("Identity %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Warnings returns problems with the task that do not prevent it from being
+// run but are likely mistakes.
+func (t *Task) Warnings() error {
+	var mErr multierror.Error
+	if t.KillTimeout > 0 && t.ShutdownDelay > t.KillTimeout {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("shutdown delay (%s) exceeds the kill timeout (%s)", t.ShutdownDelay, t.KillTimeout))
+	}
+	return mErr.ErrorOrNil()
+}
+
 // TaskState tracks the current state of a task and events that caused state
 // transitions.
 type TaskState struct {
-	State  string
+	State string
+
+	// Failed marks a task that is dead because of a failure.
+	Failed bool
+
+	// Restarts is the number of times the task has been restarted.
+	Restarts uint64
+
+	// StartedAt is the time the task was last started and FinishedAt the
+	// time it died. They are zero until the task starts or dies.
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// Events are the most recent events of the task, oldest first.
 	Events []*TaskEvent
 }
 
+// LastEvent returns the most recent event of the task, or nil if it has none.
+func (ts *TaskState) LastEvent() *TaskEvent {
+	if len(ts.Events) == 0 {
+		return nil
+	}
+	return ts.Events[len(ts.Events)-1]
+}
+
 const (
 	TaskDriverFailure          = "Driver Failure"
 	TaskReceived               = "Received"
@@ -274,4 +788,8 @@ type TaskEvent struct {
 	VaultError       string
 	TaskSignalReason string
 	TaskSignal       string
+
+	// Details holds the fields of the event specific to its type, such as
+	// the exit code of a terminated task, keyed by their snake cased names.
+	Details map[string]string
 }
@@ -1,6 +1,12 @@
 package api
 
-import "testing"
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
 
 func assertQueryMeta(t *testing.T, qm *QueryMeta) {
 	if qm.LastIndex == 0 {
@@ -51,3 +57,34 @@ func testPeriodicJob() *Job {
 	})
 	return job
 }
+
+// makeNodeClient returns a client for a fake agent that serves the
+// allocation "alloc1" placed on the node "node1", whose HTTP address points
+// back at the fake agent. All other requests are passed to the handler.
+func makeNodeClient(t *testing.T, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/allocation/alloc1":
+			fmt.Fprint(w, `{"ID": "alloc1", "NodeID": "node1"}`)
+		case "/v1/node/node1":
+			addr := strings.TrimPrefix(srv.URL, "http://")
+			fmt.Fprintf(w, `{"ID": "node1", "HTTPAddr": %q}`, addr)
+		default:
+			handler(w, r)
+		}
+	}))
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	client, err := NewClient(conf)
+	if err != nil {
+		srv.Close()
+		t.Fatalf("err: %v", err)
+	}
+	return client, srv
+}
+
+func intToPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,401 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// validVariablePath matches the paths variables can be stored at.
+var validVariablePath = regexp.MustCompile(`^[a-zA-Z0-9-_~/]{1,128}$`)
+
+const (
+	// minVariableLockTTL and maxVariableLockTTL bound the TTLs of the
+	// leases of variable locks.
+	minVariableLockTTL = 10 * time.Second
+	maxVariableLockTTL = 24 * time.Hour
+)
+
+var (
+	// ErrLockHeld is returned when acquiring the lock of a variable whose
+	// lock is held by another lease.
+	ErrLockHeld = errors.New("variable lock is held by another lease")
+
+	// ErrLockLost is returned when renewing or releasing the lock of a
+	// variable whose lease expired or was released, so the lock may have
+	// been acquired by someone else.
+	ErrLockLost = errors.New("variable lock lost")
+)
+
+// ErrCASConflict is returned by the checked writes of variables when their
+// check index doesn't match the modify index of the stored variable.
+type ErrCASConflict struct {
+	// CheckIndex is the index the write was conditioned on.
+	CheckIndex uint64
+
+	// Conflict is the variable currently stored. Its Items are only set if
+	// the token of the write may read them.
+	Conflict *Variable
+}
+
+func (e *ErrCASConflict) Error() string {
+	return fmt.Sprintf("cas conflict: expected ModifyIndex %d; found %d", e.CheckIndex, e.Conflict.ModifyIndex)
+}
+
+// Variables is used to query the variable endpoints.
+type Variables struct {
+	client *Client
+}
+
+// Variables returns a new handle on the variables.
+func (c *Client) Variables() *Variables {
+	return &Variables{client: c}
+}
+
+// List is used to list the metadata of all of the variables of the
+// namespace, without their items.
+func (v *Variables) List(q *QueryOptions) ([]*VariableMetadata, *QueryMeta, error) {
+	var resp []*VariableMetadata
+	qm, err := v.client.query("/v1/vars", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(VariablePathSort(resp))
+	return resp, qm, nil
+}
+
+// PrefixList is used to list the metadata of the variables whose paths
+// start with the prefix.
+func (v *Variables) PrefixList(prefix string, q *QueryOptions) ([]*VariableMetadata, *QueryMeta, error) {
+	pq := new(QueryOptions)
+	if q != nil {
+		*pq = *q
+	}
+	pq.Prefix = prefix
+	return v.List(pq)
+}
+
+// Read is used to query a single variable, including its items, by its path.
+func (v *Variables) Read(path string, q *QueryOptions) (*Variable, *QueryMeta, error) {
+	if err := validateVariablePath(path); err != nil {
+		return nil, nil, err
+	}
+	var resp Variable
+	qm, err := v.client.query("/v1/var/"+path, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Create is used to store a variable, overwriting the variable stored at its
+// path if there is one. The stored variable is returned.
+func (v *Variables) Create(variable *Variable, q *WriteOptions) (*Variable, *WriteMeta, error) {
+	if err := variable.Validate(); err != nil {
+		return nil, nil, err
+	}
+	var resp Variable
+	wm, err := v.client.write("/v1/var/"+variable.Path, variable, &resp, variable.writeOptions(q))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// CheckedCreate is used to store a variable only if no variable is stored
+// at its path yet. Otherwise an *ErrCASConflict holding the stored variable
+// is returned.
+func (v *Variables) CheckedCreate(variable *Variable, q *WriteOptions) (*Variable, *WriteMeta, error) {
+	return v.checkedPut(variable, 0, q)
+}
+
+// Update is used to update a variable, regardless of the variable stored at
+// its path.
+func (v *Variables) Update(variable *Variable, q *WriteOptions) (*Variable, *WriteMeta, error) {
+	return v.Create(variable, q)
+}
+
+// CheckedUpdate is used to update a variable only if the ModifyIndex of the
+// variable matches that of the stored variable, so that concurrent updates
+// are not lost. Otherwise an *ErrCASConflict holding the stored variable is
+// returned.
+func (v *Variables) CheckedUpdate(variable *Variable, q *WriteOptions) (*Variable, *WriteMeta, error) {
+	return v.checkedPut(variable, variable.ModifyIndex, q)
+}
+
+// Delete is used to delete the variable stored at a path.
+func (v *Variables) Delete(path string, q *WriteOptions) (*WriteMeta, error) {
+	if err := validateVariablePath(path); err != nil {
+		return nil, err
+	}
+	return v.client.delete("/v1/var/"+path, nil, nil, q)
+}
+
+// CheckedDelete is used to delete the variable stored at a path only if its
+// modify index matches checkIndex. Otherwise an *ErrCASConflict holding the
+// stored variable is returned.
+func (v *Variables) CheckedDelete(path string, checkIndex uint64, q *WriteOptions) (*WriteMeta, error) {
+	if err := validateVariablePath(path); err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("/v1/var/%s?cas=%d", path, checkIndex)
+	_, wm, err := v.checkedWrite("DELETE", endpoint, nil, checkIndex, q)
+	return wm, err
+}
+
+// Lock is used to acquire the lock of the variable at a path, creating the
+// variable if it doesn't exist. The lock is held by a lease on the servers
+// that expires unless it is renewed within the TTL, so only one caller holds
+// the lock at a time, which can be used for leader election. ErrLockHeld is
+// returned if the lock is already held.
+func (v *Variables) Lock(path string, ttl time.Duration, q *WriteOptions) (*VariableLock, error) {
+	if err := validateVariablePath(path); err != nil {
+		return nil, err
+	}
+	if ttl < minVariableLockTTL || ttl > maxVariableLockTTL {
+		return nil, fmt.Errorf("lock TTL must be between %s and %s", minVariableLockTTL, maxVariableLockTTL)
+	}
+
+	req := &Variable{Path: path, Lock: &VariableLock{TTL: ttl.String()}}
+	resp, ok, err := v.lockRequest("lock-acquire", req, q)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || resp.Lock == nil {
+		return nil, ErrLockHeld
+	}
+
+	lock := *resp.Lock
+	lock.path = path
+	if q != nil {
+		lock.namespace = q.Namespace
+	}
+	return &lock, nil
+}
+
+// Renew is used to renew the lease of a held variable lock for another TTL.
+// ErrLockLost is returned if the lease already expired.
+func (v *Variables) Renew(lock *VariableLock, q *WriteOptions) error {
+	_, ok, err := v.lockRequest("lock-renew", lock.variable(), lock.writeOptions(q))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Release is used to release a held variable lock so it can be acquired by
+// others right away. ErrLockLost is returned if the lease already expired.
+func (v *Variables) Release(lock *VariableLock, q *WriteOptions) error {
+	_, ok, err := v.lockRequest("lock-release", lock.variable(), lock.writeOptions(q))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// lockRequest runs a lock operation on a variable. The conflicts the servers
+// answer with when the lock is held or its lease is unknown are reported as
+// not ok rather than as errors. Any other failure, such as the variable not
+// being found, is returned as an error.
+func (v *Variables) lockRequest(operation string, variable *Variable, q *WriteOptions) (*Variable, bool, error) {
+	r := v.client.newRequest("PUT", "/v1/var/"+variable.Path)
+	r.setWriteOptions(q)
+	r.params.Set(operation, "")
+	r.obj = variable
+	rtt, resp, err := v.client.doRequest(r)
+	if err == nil && resp.StatusCode == http.StatusConflict {
+		resp.Body.Close()
+		return nil, false, nil
+	}
+	_, resp, err = requireOK(rtt, resp, err)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	var out Variable
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, false, err
+	}
+	return &out, true, nil
+}
+
+// checkedPut writes a variable only if the modify index of the stored
+// variable matches checkIndex, zero meaning that no variable is stored.
+func (v *Variables) checkedPut(variable *Variable, checkIndex uint64, q *WriteOptions) (*Variable, *WriteMeta, error) {
+	if err := variable.Validate(); err != nil {
+		return nil, nil, err
+	}
+	endpoint := fmt.Sprintf("/v1/var/%s?cas=%d", variable.Path, checkIndex)
+	return v.checkedWrite("PUT", endpoint, variable, checkIndex, variable.writeOptions(q))
+}
+
+// checkedWrite runs a write conditioned on a check index, converting the
+// conflicts the servers answer with into an *ErrCASConflict.
+func (v *Variables) checkedWrite(method, endpoint string, in interface{}, checkIndex uint64, q *WriteOptions) (*Variable, *WriteMeta, error) {
+	r := v.client.newRequest(method, endpoint)
+	r.setWriteOptions(q)
+	r.obj = in
+	rtt, resp, err := v.client.doRequest(r)
+	if err == nil && resp.StatusCode == http.StatusConflict {
+		defer resp.Body.Close()
+		var conflict Variable
+		if err := decodeBody(resp, &conflict); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, &ErrCASConflict{CheckIndex: checkIndex, Conflict: &conflict}
+	}
+	rtt, resp, err = requireOK(rtt, resp, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	parseWriteMeta(resp, wm)
+
+	// Deletes answer without a body
+	if method == "DELETE" {
+		return nil, wm, nil
+	}
+	var out Variable
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, wm, nil
+}
+
+// validateVariablePath is used to sanity check the path of a variable.
+func validateVariablePath(path string) error {
+	if !validVariablePath.MatchString(path) {
+		return fmt.Errorf("invalid variable path %q: paths may only contain letters, digits, '-', '_', '~' and '/' and be at most 128 characters", path)
+	}
+	return nil
+}
+
+// Variable is a set of items stored encrypted by the servers at a path of a
+// namespace. Access to it is governed by the ACL policies of the namespace.
+type Variable struct {
+	// Namespace is the namespace of the variable. If set, it overrides the
+	// namespace of the write options.
+	Namespace string `json:",omitempty"`
+	Path      string
+
+	// Items are the secrets or configuration stored in the variable.
+	Items map[string]string
+
+	// Lock is the lock of the variable, set on lock operations.
+	Lock *VariableLock `json:",omitempty"`
+
+	CreateIndex uint64
+	CreateTime  int64
+	ModifyIndex uint64
+	ModifyTime  int64
+}
+
+// NewVariable creates a new variable at the given path.
+func NewVariable(path string) *Variable {
+	return &Variable{
+		Path:  path,
+		Items: make(map[string]string),
+	}
+}
+
+// Validate is used to sanity check a variable before it is written.
+func (v *Variable) Validate() error {
+	var mErr multierror.Error
+	if err := validateVariablePath(v.Path); err != nil {
+		mErr.Errors = append(mErr.Errors, err)
+	}
+	if len(v.Items) == 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("variables must have at least one item"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// writeOptions returns the write options for a write of the variable,
+// targeting its namespace if it is set.
+func (v *Variable) writeOptions(q *WriteOptions) *WriteOptions {
+	if v.Namespace == "" {
+		return q
+	}
+	wq := new(WriteOptions)
+	if q != nil {
+		*wq = *q
+	}
+	wq.Namespace = v.Namespace
+	return wq
+}
+
+// VariableLock is the lock of a variable, held by a lease on the servers.
+type VariableLock struct {
+	// ID identifies the lease holding the lock. It is set by the servers
+	// when the lock is acquired and used to renew and release it.
+	ID string `json:",omitempty"`
+
+	// TTL is the duration the lease is held for unless it is renewed, such
+	// as "15s".
+	TTL string
+
+	// LockDelay is the duration after the lease expires during which the
+	// lock can't be acquired, giving the previous holder time to notice.
+	LockDelay string `json:",omitempty"`
+
+	path      string
+	namespace string
+}
+
+// variable returns the variable to send for an operation on the lock.
+func (l *VariableLock) variable() *Variable {
+	return &Variable{
+		Namespace: l.namespace,
+		Path:      l.path,
+		Lock:      &VariableLock{ID: l.ID, TTL: l.TTL},
+	}
+}
+
+// writeOptions returns the write options for an operation on the lock,
+// targeting the namespace it was acquired in.
+func (l *VariableLock) writeOptions(q *WriteOptions) *WriteOptions {
+	return l.variable().writeOptions(q)
+}
+
+// VariableMetadata is used to return a variable without its items during
+// list operations.
+type VariableMetadata struct {
+	Namespace   string
+	Path        string
+	CreateIndex uint64
+	CreateTime  int64
+	ModifyIndex uint64
+	ModifyTime  int64
+}
+
+// VariablePathSort is used to sort variables by their paths.
+type VariablePathSort []*VariableMetadata
+
+func (v VariablePathSort) Len() int {
+	return len(v)
+}
+
+func (v VariablePathSort) Less(i, j int) bool {
+	if v[i].Namespace != v[j].Namespace {
+		return v[i].Namespace < v[j].Namespace
+	}
+	return v[i].Path < v[j].Path
+}
+
+func (v VariablePathSort) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
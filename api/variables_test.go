@@ -0,0 +1,325 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testVariablesServer serves the variable endpoints from memory, enforcing
+// check indexes and recording the tokens and namespaces of the requests.
+func testVariablesServer(t *testing.T) (*Client, *httptest.Server, *[]string) {
+	stored := make(map[string]*Variable)
+	var index uint64
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ns := r.URL.Query().Get("namespace")
+		if ns == "" {
+			ns = "default"
+		}
+		seen = append(seen, r.Method+" "+ns+" "+r.Header.Get("X-Nomad-Token"))
+		w.Header().Set("X-Nomad-Index", strconv.FormatUint(index, 10))
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		enc := json.NewEncoder(w)
+
+		if r.URL.Path == "/v1/vars" {
+			out := []*VariableMetadata{}
+			for _, v := range stored {
+				if v.Namespace == ns && strings.HasPrefix(v.Path, r.URL.Query().Get("prefix")) {
+					out = append(out, &VariableMetadata{Namespace: v.Namespace, Path: v.Path, ModifyIndex: v.ModifyIndex})
+				}
+			}
+			enc.Encode(out)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/v1/var/")
+		key := ns + "/" + path
+		existing := stored[key]
+		if cas := r.URL.Query().Get("cas"); cas != "" {
+			check, _ := strconv.ParseUint(cas, 10, 64)
+			if (existing == nil && check != 0) || (existing != nil && existing.ModifyIndex != check) {
+				w.WriteHeader(http.StatusConflict)
+				if existing == nil {
+					existing = &Variable{Namespace: ns, Path: path}
+				}
+				enc.Encode(existing)
+				return
+			}
+		}
+
+		switch r.Method {
+		case "GET":
+			if existing == nil {
+				http.NotFound(w, r)
+				return
+			}
+			enc.Encode(existing)
+		case "PUT":
+			var v Variable
+			if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			index++
+			w.Header().Set("X-Nomad-Index", strconv.FormatUint(index, 10))
+			v.Namespace = ns
+			v.ModifyIndex = index
+			if existing != nil {
+				v.CreateIndex = existing.CreateIndex
+			} else {
+				v.CreateIndex = index
+			}
+			stored[key] = &v
+			enc.Encode(&v)
+		case "DELETE":
+			index++
+			w.Header().Set("X-Nomad-Index", strconv.FormatUint(index, 10))
+			delete(stored, key)
+		}
+	}))
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.SecretID = "token1"
+	c, err := NewClient(conf)
+	if err != nil {
+		srv.Close()
+		t.Fatalf("err: %v", err)
+	}
+	return c, srv, &seen
+}
+
+func TestVariable_Validate(t *testing.T) {
+	v := NewVariable("nomad/jobs/web")
+	if err := v.Validate(); err == nil || !strings.Contains(err.Error(), "at least one item") {
+		t.Fatalf("expected items error, got: %v", err)
+	}
+	v.Items["password"] = "hunter2"
+	if err := v.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for _, path := range []string{"", "has space", "dots/../up", strings.Repeat("a", 129)} {
+		v.Path = path
+		if err := v.Validate(); err == nil || !strings.Contains(err.Error(), "invalid variable path") {
+			t.Fatalf("expected path error for %q, got: %v", path, err)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	c, srv, seen := testVariablesServer(t)
+	defer srv.Close()
+	vars := c.Variables()
+
+	v := NewVariable("nomad/jobs/web")
+	v.Items["password"] = "hunter2"
+	out, wm, err := vars.Create(v, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertWriteMeta(t, wm)
+	if out.ModifyIndex != 1 || out.Items["password"] != "hunter2" {
+		t.Fatalf("bad variable: %#v", out)
+	}
+
+	// Variables in other namespaces are kept apart
+	other := NewVariable("nomad/jobs/web")
+	other.Namespace = "prod"
+	other.Items["password"] = "correct horse"
+	if _, _, err := vars.Create(other, &WriteOptions{AuthToken: "token2"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if last := (*seen)[len(*seen)-1]; last != "PUT prod token2" {
+		t.Fatalf("bad namespace or token: %q", last)
+	}
+
+	read, qm, err := vars.Read("nomad/jobs/web", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertQueryMeta(t, qm)
+	if read.Namespace != "default" || read.Items["password"] != "hunter2" {
+		t.Fatalf("bad variable: %#v", read)
+	}
+
+	list, _, err := vars.PrefixList("nomad/", &QueryOptions{Namespace: "prod"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(list) != 1 || list[0].Namespace != "prod" {
+		t.Fatalf("bad variables: %#v", list)
+	}
+
+	read.Items["password"] = "hunter3"
+	if _, _, err := vars.Update(read, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := vars.Delete("nomad/jobs/web", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := vars.Read("nomad/jobs/web", nil); err == nil {
+		t.Fatalf("expected deleted variable to be gone")
+	}
+
+	// Every request is authenticated
+	for _, req := range *seen {
+		if strings.HasSuffix(req, " ") {
+			t.Fatalf("request without token: %q", req)
+		}
+	}
+}
+
+func TestVariables_Checked(t *testing.T) {
+	c, srv, _ := testVariablesServer(t)
+	defer srv.Close()
+	vars := c.Variables()
+
+	v := NewVariable("config")
+	v.Items["level"] = "debug"
+	created, _, err := vars.CheckedCreate(v, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Creating the variable again conflicts with the stored one, reporting
+	// the check index that was sent
+	_, _, err = vars.CheckedCreate(created, nil)
+	cErr, ok := err.(*ErrCASConflict)
+	if !ok {
+		t.Fatalf("expected cas conflict, got: %v", err)
+	}
+	if cErr.CheckIndex != 0 || cErr.Conflict.ModifyIndex != created.ModifyIndex || cErr.Conflict.Items["level"] != "debug" {
+		t.Fatalf("bad conflict: %#v", cErr)
+	}
+
+	created.Items["level"] = "info"
+	updated, _, err := vars.CheckedUpdate(created, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Updates of stale variables conflict
+	created.Items["level"] = "warn"
+	if _, _, err := vars.CheckedUpdate(created, nil); err == nil {
+		t.Fatalf("expected cas conflict")
+	} else if _, ok := err.(*ErrCASConflict); !ok {
+		t.Fatalf("expected cas conflict, got: %v", err)
+	}
+
+	if _, err := vars.CheckedDelete("config", created.ModifyIndex, nil); err == nil {
+		t.Fatalf("expected cas conflict")
+	}
+	if _, err := vars.CheckedDelete("config", updated.ModifyIndex, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestVariables_Lock(t *testing.T) {
+	// The lease of the lock, keyed by namespace and path
+	leases := make(map[string]string)
+	var leaseCount int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/agent/self" {
+			// The version of the agent is unknown, so requests aren't gated
+			http.NotFound(w, r)
+			return
+		}
+		var req Variable
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		key := r.URL.Query().Get("namespace") + "/" + strings.TrimPrefix(r.URL.Path, "/v1/var/")
+		w.Header().Set("X-Nomad-Index", "1")
+		query := r.URL.Query()
+		switch {
+		case query.Has("lock-acquire"):
+			if req.Lock == nil || req.Lock.TTL != "15s" {
+				t.Fatalf("bad lock: %#v", req.Lock)
+			}
+			if _, ok := leases[key]; ok {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(&Variable{Path: req.Path})
+				return
+			}
+			leaseCount++
+			leases[key] = fmt.Sprintf("lease%d", leaseCount)
+			req.Lock.ID = leases[key]
+			json.NewEncoder(w).Encode(&req)
+		case query.Has("lock-renew"), query.Has("lock-release"):
+			if req.Path == "missing" {
+				http.NotFound(w, r)
+				return
+			}
+			if req.Lock == nil || leases[key] != req.Lock.ID {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			if query.Has("lock-release") {
+				delete(leases, key)
+			}
+			json.NewEncoder(w).Encode(&req)
+		default:
+			t.Fatalf("bad query: %v", query)
+		}
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	vars := c.Variables()
+
+	if _, err := vars.Lock("leader", time.Second, nil); err == nil || !strings.Contains(err.Error(), "TTL") {
+		t.Fatalf("expected TTL error, got: %v", err)
+	}
+
+	q := &WriteOptions{Namespace: "prod"}
+	lock, err := vars.Lock("leader", 15*time.Second, q)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if lock.ID != "lease1" {
+		t.Fatalf("bad lock: %#v", lock)
+	}
+
+	// Only one caller holds the lock, but other namespaces are apart
+	if _, err := vars.Lock("leader", 15*time.Second, q); err != ErrLockHeld {
+		t.Fatalf("expected lock held error, got: %v", err)
+	}
+	if _, err := vars.Lock("leader", 15*time.Second, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The lock is renewed and released in the namespace it was acquired in
+	if err := vars.Renew(lock, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := vars.Release(lock, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Once the lease is gone the lock is lost
+	if err := vars.Renew(lock, nil); err != ErrLockLost {
+		t.Fatalf("expected lock lost error, got: %v", err)
+	}
+	if err := vars.Release(lock, nil); err != ErrLockLost {
+		t.Fatalf("expected lock lost error, got: %v", err)
+	}
+
+	// Other failures are not mistaken for lost locks
+	missing := *lock
+	missing.path = "missing"
+	if err := vars.Renew(&missing, nil); err == nil || err == ErrLockLost || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected not found error, got: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// VolumeTypeHost and VolumeTypeCSI are the types of volumes. Host
+	// volumes are directories configured on client nodes while CSI volumes
+	// are provided by CSI plugins.
+	VolumeTypeHost = "host"
+	VolumeTypeCSI  = "csi"
+)
+
+// validHostVolumeName matches the names host volumes can be configured with
+// on client nodes.
+var validHostVolumeName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// VolumeRequest is a volume requested by a task group. Its tasks mount it
+// with a VolumeMount referencing its name.
+type VolumeRequest struct {
+	Name string
+	Type string
+
+	// Source identifies the volume to claim: the name of a host volume
+	// configured on the node or the ID of a registered CSI volume.
+	Source string
+
+	ReadOnly bool
+
+	// AccessMode and AttachmentMode override the modes of CSI volumes.
+	AccessMode     string `json:",omitempty"`
+	AttachmentMode string `json:",omitempty"`
+}
+
+// Validate is used to sanity check a volume request.
+func (v *VolumeRequest) Validate() error {
+	var mErr multierror.Error
+	if v.Source == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing volume source"))
+	}
+	switch v.Type {
+	case VolumeTypeHost:
+		if v.Source != "" && !validHostVolumeName.MatchString(v.Source) {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Host volume source %q must be the name of a host volume configured on the node, not a path", v.Source))
+		}
+		if v.AccessMode != "" || v.AttachmentMode != "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Access and attachment modes may only be set on CSI volumes"))
+		}
+	case VolumeTypeCSI:
+		if v.AccessMode != "" || v.AttachmentMode != "" {
+			if err := validateCSIModes(v.AccessMode, v.AttachmentMode); err != nil {
+				mErr.Errors = append(mErr.Errors, err.(*multierror.Error).Errors...)
+			}
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Invalid volume type %q", v.Type))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// VolumeMount mounts a volume of the task group into a task.
+type VolumeMount struct {
+	// Volume is the name of the volume in the task group.
+	Volume      string
+	Destination string
+	ReadOnly    bool
+}
+
+// Validate is used to sanity check a volume mount.
+func (m *VolumeMount) Validate() error {
+	var mErr multierror.Error
+	if m.Volume == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing volume name"))
+	}
+	if m.Destination == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Missing mount destination"))
+	}
+	return mErr.ErrorOrNil()
+}
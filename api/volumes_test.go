@@ -0,0 +1,75 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVolumeRequest_Validate(t *testing.T) {
+	vol := &VolumeRequest{Name: "data", Type: VolumeTypeCSI, Source: "db"}
+	if err := vol.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	vol.AccessMode = CSIVolumeAccessModeMultiNodeReader
+	vol.AttachmentMode = CSIVolumeAttachmentModeFilesystem
+	if err := vol.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	vol.AttachmentMode = "tape"
+	if err := vol.Validate(); err == nil || !strings.Contains(err.Error(), "attachment mode") {
+		t.Fatalf("expected attachment mode error, got: %v", err)
+	}
+
+	err := (&VolumeRequest{Type: "nfs"}).Validate()
+	if err == nil || !strings.Contains(err.Error(), "source") || !strings.Contains(err.Error(), "volume type") {
+		t.Fatalf("expected source and type errors, got: %v", err)
+	}
+}
+
+func TestTaskGroup_Volumes(t *testing.T) {
+	task := NewTask("web", "docker").
+		AddVolumeMount(&VolumeMount{Volume: "data", Destination: "/srv/data", ReadOnly: true})
+	grp := NewTaskGroup("web", 1).
+		AddVolume(&VolumeRequest{Name: "data", Type: VolumeTypeCSI, Source: "db", ReadOnly: true}).
+		AddTask(task)
+	if err := grp.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if grp.Volumes["data"].Source != "db" {
+		t.Fatalf("bad volumes: %#v", grp.Volumes)
+	}
+
+	// Mounts must reference a volume of the group
+	task.AddVolumeMount(&VolumeMount{Volume: "logs", Destination: "/srv/logs"})
+	err := grp.Validate()
+	if err == nil || !strings.Contains(err.Error(), `undeclared volume "logs"`) {
+		t.Fatalf("expected undeclared volume error, got: %v", err)
+	}
+
+	task.VolumeMounts = []*VolumeMount{{Volume: "data"}}
+	err = grp.Validate()
+	if err == nil || !strings.Contains(err.Error(), "destination") {
+		t.Fatalf("expected destination error, got: %v", err)
+	}
+}
+
+func TestVolumeRequest_Validate_Host(t *testing.T) {
+	vol := &VolumeRequest{Name: "certs", Type: VolumeTypeHost, Source: "ca-certs", ReadOnly: true}
+	if err := vol.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Host volumes are referenced by name rather than by path
+	vol.Source = "/etc/ssl/certs"
+	if err := vol.Validate(); err == nil || !strings.Contains(err.Error(), "not a path") {
+		t.Fatalf("expected host volume name error, got: %v", err)
+	}
+
+	vol.Source = "ca-certs"
+	vol.AccessMode = CSIVolumeAccessModeSingleNodeWriter
+	if err := vol.Validate(); err == nil || !strings.Contains(err.Error(), "only be set on CSI volumes") {
+		t.Fatalf("expected access mode error, got: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+)
+
+// WatchFunc runs a blocking query with the given query options, handling its
+// result, and returns the meta of the query.
+type WatchFunc func(q *QueryOptions) (*QueryMeta, error)
+
+// Watch repeatedly runs the blocking query fn, each time waiting on the last
+// index seen, until fn returns an error or the context is done. The query
+// options are copied and their WaitIndex seeds the first query.
+//
+// If a query returns an index lower than the last one seen, such as after
+// the state of the servers was reset by a failover to a fresh cluster, the
+// wait index is reset and the next query returns immediately to re-seed the
+// watch with the current state. Waiting on an index that went backwards
+// would otherwise stall the watch until the servers caught up with it.
+func (c *Client) Watch(ctx context.Context, q *QueryOptions, fn WatchFunc) error {
+	wq := new(QueryOptions)
+	if q != nil {
+		*wq = *q
+	}
+	wq.ctx = ctx
+
+	for {
+		qm, err := fn(wq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		wq.WaitIndex = nextWaitIndex(wq.WaitIndex, qm.LastIndex)
+	}
+}
+
+// nextWaitIndex returns the index the next blocking query should wait on
+// given the index it last waited on and the index it returned. An index
+// that went backwards resets the wait so the next query returns right away,
+// while a zero index is bumped so the query keeps blocking rather than
+// spinning.
+func nextWaitIndex(waited, returned uint64) uint64 {
+	switch {
+	case returned < waited:
+		return 0
+	case returned == 0:
+		return 1
+	default:
+		return returned
+	}
+}
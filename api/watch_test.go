@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestWatch_IndexReset(t *testing.T) {
+	// The servers are reset after the second query, with the index going
+	// backwards from 7 to 3
+	indexes := []uint64{5, 7, 3, 4, 6}
+	var waited []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		waited = append(waited, r.URL.Query().Get("index"))
+		index := indexes[len(waited)-1]
+		w.Header().Set("X-Nomad-Index", strconv.FormatUint(index, 10))
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var seen []uint64
+	err = c.Watch(ctx, nil, func(q *QueryOptions) (*QueryMeta, error) {
+		_, qm, err := c.Jobs().List(q)
+		if err != nil {
+			return nil, err
+		}
+		seen = append(seen, qm.LastIndex)
+		if len(seen) == len(indexes) {
+			cancel()
+		}
+		return qm, nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected cancellation, got: %v", err)
+	}
+
+	// The query after the reset doesn't block and re-seeds the watch
+	if expected := []string{"", "5", "7", "", "4"}; !reflect.DeepEqual(waited, expected) {
+		t.Fatalf("expected wait indexes %v, got %v", expected, waited)
+	}
+	if !reflect.DeepEqual(seen, indexes) {
+		t.Fatalf("expected indexes %v, got %v", indexes, seen)
+	}
+}
+
+func TestNextWaitIndex(t *testing.T) {
+	cases := []struct {
+		waited, returned, next uint64
+	}{
+		{0, 10, 10},
+		{10, 10, 10},
+		{10, 12, 12},
+		{10, 4, 0},
+		{0, 0, 1},
+	}
+	for _, tc := range cases {
+		if next := nextWaitIndex(tc.waited, tc.returned); next != tc.next {
+			t.Fatalf("waited %d returned %d: expected %d, got %d", tc.waited, tc.returned, tc.next, next)
+		}
+	}
+}
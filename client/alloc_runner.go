@@ -133,6 +133,9 @@ func (r *AllocRunner) RestoreState() error {
 	r.allocClientStatus = snap.AllocClientStatus
 	r.allocClientDescription = snap.AllocClientDescription
 	r.taskStates = snap.Alloc.TaskStates
+	for _, state := range r.taskStates {
+		state.Canonicalize()
+	}
 
 	var snapshotErrors multierror.Error
 	if r.alloc == nil {
@@ -306,7 +309,7 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 		case structs.TaskStatePending:
 			pending = true
 		case structs.TaskStateDead:
-			if state.Failed() {
+			if state.Failed {
 				failed = true
 			} else {
 				dead = true
@@ -372,7 +375,7 @@ func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEv
 	}
 
 	// Set the tasks state.
-	taskState.State = state
+	taskState.Transition(state, event)
 	r.appendTaskEvent(taskState, event)
 
 	if state == structs.TaskStateDead {
@@ -384,7 +387,7 @@ func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEv
 		}
 
 		// If the task failed, we should kill all the other tasks in the task group.
-		if taskState.Failed() {
+		if taskState.Failed {
 			var destroyingTasks []string
 			for task, tr := range r.tasks {
 				if task != taskName {
@@ -774,6 +777,24 @@ func (r *AllocRunner) getTaskRunners() []*TaskRunner {
 	return runners
 }
 
+// RestartTasks restarts the running tasks of the allocation, or only the
+// named task if taskName is set. User restarts don't count against the
+// restart policy of the task group.
+func (r *AllocRunner) RestartTasks(taskName, source, reason string) error {
+	found := false
+	for _, tr := range r.getTaskRunners() {
+		if taskName != "" && tr.task.Name != taskName {
+			continue
+		}
+		found = true
+		tr.Restart(source, reason)
+	}
+	if !found {
+		return fmt.Errorf("task %q not found", taskName)
+	}
+	return nil
+}
+
 // LatestAllocStats returns the latest allocation stats. If the optional taskFilter is set
 // the allocation stats will only include the given task.
 func (r *AllocRunner) LatestAllocStats(taskFilter string) (*cstructs.AllocResourceUsage, error) {
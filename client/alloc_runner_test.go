@@ -111,7 +111,7 @@ func TestAllocRunner_RetryArtifact(t *testing.T) {
 		if badstate.State != structs.TaskStateDead {
 			return false, fmt.Errorf("expected bad to be dead but found %q", last.TaskStates["web"].State)
 		}
-		if !badstate.Failed() {
+		if !badstate.Failed {
 			return false, fmt.Errorf("expected bad to have failed")
 		}
 		return true, nil
@@ -610,7 +610,7 @@ func TestAllocRunner_TaskFailed_KillTG(t *testing.T) {
 		if state2.State != structs.TaskStateDead {
 			return false, fmt.Errorf("got state %v; want %v", state2.State, structs.TaskStateDead)
 		}
-		if !state2.Failed() {
+		if !state2.Failed {
 			return false, fmt.Errorf("task2 should have failed")
 		}
 
@@ -620,6 +620,62 @@ func TestAllocRunner_TaskFailed_KillTG(t *testing.T) {
 	})
 }
 
+func TestAllocRunner_RestartTasks(t *testing.T) {
+	upd, ar := testAllocRunner(false)
+	task := ar.alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"run_for": "10s",
+	}
+	go ar.Run()
+	defer ar.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, fmt.Errorf("No updates")
+		}
+		last := upd.Allocs[upd.Count-1]
+		if last.ClientStatus != structs.AllocClientStatusRunning {
+			return false, fmt.Errorf("got status %v; want %v", last.ClientStatus, structs.AllocClientStatusRunning)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	if err := ar.RestartTasks("missing", "test", "restart"); err == nil {
+		t.Fatalf("expected unknown task to be rejected")
+	}
+	if err := ar.RestartTasks("", "test", "restart"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The task is restarted without counting against the restart policy,
+	// which allows no restarts
+	testutil.WaitForResult(func() (bool, error) {
+		last := upd.Allocs[upd.Count-1]
+		state := last.TaskStates[task.Name]
+		if state == nil || state.State != structs.TaskStateRunning {
+			return false, fmt.Errorf("task not running: %#v", state)
+		}
+		signaled, started := false, 0
+		for _, e := range state.Events {
+			switch e.Type {
+			case structs.TaskRestartSignal:
+				signaled = true
+			case structs.TaskStarted:
+				started++
+			}
+		}
+		if !signaled || started != 2 {
+			return false, fmt.Errorf("task not restarted: %#v", state.Events)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
 func TestAllocRunner_SimpleRun_VaultToken(t *testing.T) {
 	alloc := mock.Alloc()
 	task := alloc.Job.TaskGroups[0].Tasks[0]
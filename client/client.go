@@ -25,6 +25,7 @@ import (
 	"github.com/hashicorp/nomad/client/driver"
 	"github.com/hashicorp/nomad/client/fingerprint"
 	"github.com/hashicorp/nomad/client/stats"
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/client/vaultclient"
 	"github.com/hashicorp/nomad/command/agent/consul"
 	"github.com/hashicorp/nomad/nomad"
@@ -452,6 +453,63 @@ func (c *Client) GetAllocFS(allocID string) (allocdir.AllocDirFS, error) {
 	return ar.GetAllocDir(), nil
 }
 
+// GetAllocChecks returns the status of the Consul checks of the services
+// registered by the tasks of an allocation.
+func (c *Client) GetAllocChecks(allocID string) ([]*cstructs.AllocCheckStatus, error) {
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("alloc not found")
+	}
+
+	alloc := ar.Alloc()
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	if tg == nil {
+		return nil, fmt.Errorf("task group %q not found", alloc.TaskGroup)
+	}
+
+	// The services of each task are registered by its executor, under a
+	// domain of the task
+	tasks := make(map[consul.ServiceDomain]string, len(tg.Tasks))
+	domains := make([]consul.ServiceDomain, 0, len(tg.Tasks))
+	for _, task := range tg.Tasks {
+		domain := consul.NewExecutorDomain(alloc.ID, task.Name)
+		tasks[domain] = task.Name
+		domains = append(domains, domain)
+	}
+	checks, err := c.consulSyncer.DomainChecks(domains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Consul checks: %v", err)
+	}
+
+	var statuses []*cstructs.AllocCheckStatus
+	for domain, domainChecks := range checks {
+		for _, check := range domainChecks {
+			statuses = append(statuses, &cstructs.AllocCheckStatus{
+				Task:    tasks[domain],
+				Service: check.ServiceName,
+				Check:   check.Name,
+				Status:  check.Status,
+				Output:  check.Output,
+			})
+		}
+	}
+	return statuses, nil
+}
+
+// RestartAllocation restarts the running tasks of an allocation, or only the
+// named task if taskName is set.
+func (c *Client) RestartAllocation(allocID, taskName string) error {
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("alloc not found")
+	}
+	return ar.RestartTasks(taskName, "user", "restart requested through the HTTP API")
+}
+
 // GetServers returns the list of nomad servers this client is aware of.
 func (c *Client) GetServers() []string {
 	endpoints := c.servers.all()
@@ -101,6 +101,16 @@ func (r *RestartTracker) GetState() (string, time.Duration) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
+	// Hot path if a restart was triggered. Triggered restarts are applied
+	// immediately and don't count against the policy.
+	if r.restartTriggered {
+		r.reason = ""
+		r.startErr = nil
+		r.waitRes = nil
+		r.restartTriggered = false
+		return structs.TaskRestarting, 0
+	}
+
 	// Hot path if no attempts are expected
 	if r.policy.Attempts == 0 {
 		r.reason = ReasonNoRestartsAllowed
@@ -127,9 +137,6 @@ func (r *RestartTracker) GetState() (string, time.Duration) {
 		state, dur = r.handleStartError()
 	} else if r.waitRes != nil {
 		state, dur = r.handleWaitResult()
-	} else if r.restartTriggered {
-		state, dur = structs.TaskRestarting, 0
-		r.reason = ""
 	} else {
 		state, dur = "", 0
 	}
@@ -137,7 +144,6 @@ func (r *RestartTracker) GetState() (string, time.Duration) {
 	// Clear out the existing state
 	r.startErr = nil
 	r.waitRes = nil
-	r.restartTriggered = false
 
 	return state, dur
 }
@@ -99,9 +99,21 @@ func TestClient_RestartTracker_RestartTriggered(t *testing.T) {
 	p := testPolicy(true, structs.RestartPolicyModeFail)
 	p.Attempts = 0
 	rt := newRestartTracker(p, structs.JobTypeService)
-	if state, when := rt.SetRestartTriggered().GetState(); state != structs.TaskRestarting && when != 0 {
+	if state, when := rt.SetRestartTriggered().GetState(); state != structs.TaskRestarting || when != 0 {
 		t.Fatalf("expect restart immediately, got %v %v", state, when)
 	}
+
+	// Triggered restarts don't count against the policy
+	p.Attempts = 1
+	rt = newRestartTracker(p, structs.JobTypeService)
+	for i := 0; i < 3; i++ {
+		if state, when := rt.SetRestartTriggered().GetState(); state != structs.TaskRestarting || when != 0 {
+			t.Fatalf("expect restart immediately, got %v %v", state, when)
+		}
+	}
+	if state, _ := rt.SetWaitResult(testWaitResult(1)).GetState(); state != structs.TaskRestarting {
+		t.Fatalf("expect restart within policy, got %v", state)
+	}
 }
 
 func TestClient_RestartTracker_StartError_Recoverable_Fail(t *testing.T) {
@@ -78,6 +78,28 @@ type AllocResourceUsage struct {
 	Timestamp int64
 }
 
+// AllocCheckStatus is the status of a Consul check of a service registered
+// by a task of an allocation.
+type AllocCheckStatus struct {
+	Task    string
+	Service string
+	Check   string
+
+	// Status is the status of the check in Consul, such as "passing" or
+	// "critical"
+	Status string
+
+	// Output is the output of the last run of the check
+	Output string
+}
+
+// AllocRestartRequest is used to restart the tasks of an allocation.
+type AllocRestartRequest struct {
+	// TaskName is the task to restart. If empty, every task of the
+	// allocation is restarted.
+	TaskName string
+}
+
 // joinStringSet takes two slices of strings and joins them
 func joinStringSet(s1, s2 []string) []string {
 	lookup := make(map[string]struct{}, len(s1))
@@ -57,6 +57,20 @@ func (s *HTTPServer) AgentSelfRequest(resp http.ResponseWriter, req *http.Reques
 	return self, nil
 }
 
+// agentHealth is the response of the health endpoint.
+type agentHealth struct {
+	OK bool `json:"ok"`
+}
+
+// AgentHealthRequest is a cheap liveness check of the agent. It is answered
+// by the agent itself without contacting the servers.
+func (s *HTTPServer) AgentHealthRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	return agentHealth{OK: true}, nil
+}
+
 func (s *HTTPServer) AgentJoinRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if req.Method != "PUT" && req.Method != "POST" {
 		return nil, CodedError(405, ErrInvalidMethod)
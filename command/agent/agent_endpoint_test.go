@@ -34,6 +34,33 @@ func TestHTTP_AgentSelf(t *testing.T) {
 	})
 }
 
+func TestHTTP_AgentHealth(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/agent/health", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		obj, err := s.Server.AgentHealthRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if health := obj.(agentHealth); !health.OK {
+			t.Fatalf("bad: %#v", health)
+		}
+
+		// Only reads are allowed
+		req, err = http.NewRequest("PUT", "/v1/agent/health", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, err := s.Server.AgentHealthRequest(respW, req); err == nil {
+			t.Fatalf("expected method error")
+		}
+	})
+}
+
 func TestHTTP_AgentJoin(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Determine the join address
@@ -2,9 +2,11 @@ package agent
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -79,6 +81,10 @@ func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Requ
 		return s.allocStats(allocID, resp, req)
 	case "snapshot":
 		return s.allocSnapshot(allocID, resp, req)
+	case "checks":
+		return s.allocChecks(allocID, resp, req)
+	case "restart":
+		return s.allocRestart(allocID, resp, req)
 	}
 
 	return nil, CodedError(404, resourceNotFoundErr)
@@ -95,6 +101,34 @@ func (s *HTTPServer) allocSnapshot(allocID string, resp http.ResponseWriter, req
 	return nil, nil
 }
 
+func (s *HTTPServer) allocChecks(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if _, err := s.agent.Client().GetAllocFS(allocID); err != nil {
+		return nil, fmt.Errorf(allocNotFoundErr)
+	}
+	return s.agent.Client().GetAllocChecks(allocID)
+}
+
+func (s *HTTPServer) allocRestart(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	// The body naming the task to restart is optional
+	var args cstructs.AllocRestartRequest
+	if req.Body != nil {
+		if err := decodeBody(req, &args); err != nil && err != io.EOF {
+			return nil, CodedError(400, err.Error())
+		}
+	}
+	if _, err := s.agent.Client().GetAllocFS(allocID); err != nil {
+		return nil, fmt.Errorf(allocNotFoundErr)
+	}
+	if err := s.agent.Client().RestartAllocation(allocID, args.TaskName); err != nil {
+		return nil, CodedError(404, err.Error())
+	}
+	return nil, nil
+}
+
 func (s *HTTPServer) allocStats(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	clientStats := s.agent.client.StatsReporter()
 	aStats, err := clientStats.GetAllocStats(allocID)
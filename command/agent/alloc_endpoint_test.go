@@ -194,3 +194,48 @@ func TestHTTP_AllocSnapshot(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_AllocChecks(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/client/allocation/123/checks", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		if err == nil || !strings.Contains(err.Error(), allocNotFoundErr) {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_AllocRestart(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Restarts must be writes
+		req, err := http.NewRequest("GET", "/v1/client/allocation/123/restart", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		if err == nil || !strings.Contains(err.Error(), ErrInvalidMethod) {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err = http.NewRequest("PUT", "/v1/client/allocation/123/restart", strings.NewReader(`{"TaskName": "web"}`))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+
+		// Make the request
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		if err == nil || !strings.Contains(err.Error(), allocNotFoundErr) {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
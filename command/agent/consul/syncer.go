@@ -902,6 +902,38 @@ func (c *Syncer) filterConsulChecks(consulChecks map[string]*consul.AgentCheck)
 	return localChecks
 }
 
+// DomainChecks returns the checks registered with Consul for the services of
+// the given domains, including domains this Syncer doesn't manage such as
+// those of executors.
+func (c *Syncer) DomainChecks(domains []ServiceDomain) (map[ServiceDomain][]*consul.AgentCheck, error) {
+	consulChecks, err := c.client.Agent().Checks()
+	if err != nil {
+		return nil, err
+	}
+	checks := make(map[ServiceDomain][]*consul.AgentCheck, len(domains))
+	for _, check := range consulChecks {
+		if domain, ok := serviceDomain(check.ServiceID, domains); ok {
+			checks[domain] = append(checks[domain], check)
+		}
+	}
+	return checks, nil
+}
+
+// serviceDomain returns the domain of the given domains a Consul service ID
+// belongs to. Domains may prefix each other, such as those of tasks whose
+// names do, so the longest matching domain is returned.
+func serviceDomain(serviceID string, domains []ServiceDomain) (ServiceDomain, bool) {
+	var match ServiceDomain
+	found := false
+	for _, domain := range domains {
+		prefix := fmt.Sprintf("%s-%s-", nomadServicePrefix, domain)
+		if strings.HasPrefix(serviceID, prefix) && len(domain) >= len(match) {
+			match, found = domain, true
+		}
+	}
+	return match, found
+}
+
 // consulPresent indicates whether the Consul Agent is responding
 func (c *Syncer) consulPresent() bool {
 	_, err := c.client.Agent().Self()
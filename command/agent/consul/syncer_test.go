@@ -277,3 +277,26 @@ func TestConsulServiceUpdateService(t *testing.T) {
 		t.Fatalf("expected 3 services locally but found %d", found)
 	}
 }
+
+func TestServiceDomain(t *testing.T) {
+	web := NewExecutorDomain(allocID, "web")
+	webAPI := NewExecutorDomain(allocID, "web-api")
+	domains := []ServiceDomain{web, webAPI}
+
+	cases := []struct {
+		serviceID string
+		domain    ServiceDomain
+		found     bool
+	}{
+		{string(generateConsulServiceID(web, "http")), web, true},
+		{string(generateConsulServiceID(webAPI, "http")), webAPI, true},
+		{string(generateConsulServiceID(NewExecutorDomain(allocID, "db"), "tcp")), "", false},
+		{string(generateConsulServiceID(ClientDomain, "http")), "", false},
+	}
+	for _, c := range cases {
+		domain, found := serviceDomain(c.serviceID, domains)
+		if domain != c.domain || found != c.found {
+			t.Fatalf("%s: expected %q %v, got %q %v", c.serviceID, c.domain, c.found, domain, found)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package agent
 
 import (
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -19,7 +21,9 @@ func (s *HTTPServer) JobsRequest(resp http.ResponseWriter, req *http.Request) (i
 }
 
 func (s *HTTPServer) jobListRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
-	args := structs.JobListRequest{}
+	args := structs.JobListRequest{
+		ParentID: req.URL.Query().Get("parent"),
+	}
 	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
 		return nil, nil
 	}
@@ -67,9 +71,18 @@ func (s *HTTPServer) jobForceEvaluate(resp http.ResponseWriter, req *http.Reques
 	if req.Method != "PUT" && req.Method != "POST" {
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
-	args := structs.JobEvaluateRequest{
-		JobID: jobName,
+
+	// The body carrying the eval options is optional
+	var args structs.JobEvaluateRequest
+	if req.Body != nil {
+		if err := decodeBody(req, &args); err != nil && err != io.EOF {
+			return nil, CodedError(400, err.Error())
+		}
 	}
+	if args.JobID != "" && args.JobID != jobName {
+		return nil, CodedError(400, "Job ID does not match")
+	}
+	args.JobID = jobName
 	s.parseRegion(req, &args.Region)
 
 	var out structs.JobRegisterResponse
@@ -205,7 +218,23 @@ func (s *HTTPServer) jobQuery(resp http.ResponseWriter, req *http.Request,
 	if out.Job == nil {
 		return nil, CodedError(404, "job not found")
 	}
-	return out.Job, nil
+
+	// Optionally return the summary of the job along with it
+	if withSummary, _ := strconv.ParseBool(req.URL.Query().Get("summary")); !withSummary {
+		return out.Job, nil
+	}
+	sumArgs := structs.JobSummaryRequest{
+		JobID: jobName,
+		QueryOptions: structs.QueryOptions{
+			Region:     args.Region,
+			AllowStale: args.AllowStale,
+		},
+	}
+	var sumOut structs.JobSummaryResponse
+	if err := s.agent.RPC("Job.Summary", &sumArgs, &sumOut); err != nil {
+		return nil, err
+	}
+	return &structs.JobWithSummary{Job: out.Job, Summary: sumOut.JobSummary}, nil
 }
 
 func (s *HTTPServer) jobUpdate(resp http.ResponseWriter, req *http.Request,
@@ -237,6 +266,29 @@ func (s *HTTPServer) jobDelete(resp http.ResponseWriter, req *http.Request,
 	}
 	s.parseRegion(req, &args.Region)
 
+	// Check if the deregister is conditioned on the job modify index
+	if enforce := req.URL.Query().Get("enforce_index"); enforce != "" {
+		enforceIndex, err := strconv.ParseBool(enforce)
+		if err != nil {
+			return nil, CodedError(400, "Failed to parse enforce_index")
+		}
+		jmi, err := strconv.ParseUint(req.URL.Query().Get("job_modify_index"), 10, 64)
+		if err != nil {
+			return nil, CodedError(400, "Failed to parse job_modify_index")
+		}
+		args.EnforceIndex = enforceIndex
+		args.JobModifyIndex = jmi
+	}
+
+	// Check if the priority of the created eval is overridden
+	if priority := req.URL.Query().Get("eval_priority"); priority != "" {
+		evalPriority, err := strconv.Atoi(priority)
+		if err != nil {
+			return nil, CodedError(400, "Failed to parse eval_priority")
+		}
+		args.EvalPriority = evalPriority
+	}
+
 	var out structs.JobDeregisterResponse
 	if err := s.agent.RPC("Job.Deregister", &args, &out); err != nil {
 		return nil, err
@@ -205,6 +205,43 @@ func TestHTTP_JobQuery(t *testing.T) {
 	})
 }
 
+func TestHTTP_JobQuery_Summary(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job
+		job := mock.Job()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/job/"+job.ID+"?summary=true", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the job and its summary
+		out := obj.(*structs.JobWithSummary)
+		if out.Job.ID != job.ID {
+			t.Fatalf("bad: %#v", out.Job)
+		}
+		if out.Summary == nil || out.Summary.JobID != job.ID {
+			t.Fatalf("bad: %#v", out.Summary)
+		}
+	})
+}
+
 func TestHTTP_JobUpdate(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Create the job
@@ -346,6 +383,60 @@ func TestHTTP_JobForceEvaluate(t *testing.T) {
 	})
 }
 
+func TestHTTP_JobForceEvaluate_EvalOptions(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job
+		job := mock.Job()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request with the eval options
+		evalReq := structs.JobEvaluateRequest{
+			JobID:       job.ID,
+			EvalOptions: structs.EvalOptions{Reason: "config rotated", Priority: 80},
+		}
+		buf := encodeReq(evalReq)
+		req, err := http.NewRequest("POST", "/v1/job/"+job.ID+"/evaluate", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the options were applied to the evaluation
+		reg := obj.(structs.JobRegisterResponse)
+		state := s.Agent.server.State()
+		eval, err := state.EvalByID(reg.EvalID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if eval == nil || eval.Priority != 80 || eval.TriggerReason != "config rotated" {
+			t.Fatalf("bad eval: %#v", eval)
+		}
+
+		// A body naming another job is rejected
+		evalReq.JobID = "other"
+		req, err = http.NewRequest("POST", "/v1/job/"+job.ID+"/evaluate", encodeReq(evalReq))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, err := s.Server.JobSpecificRequest(httptest.NewRecorder(), req); err == nil {
+			t.Fatalf("expected job ID mismatch error")
+		}
+	})
+}
+
 func TestHTTP_JobEvaluations(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Create the job
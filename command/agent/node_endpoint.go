@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -18,12 +19,28 @@ func (s *HTTPServer) NodesRequest(resp http.ResponseWriter, req *http.Request) (
 		return nil, nil
 	}
 
+	// Parse the attribute filter, given as repeated "attribute=key=value"
+	// parameters
+	for _, attr := range req.URL.Query()["attribute"] {
+		parts := strings.SplitN(attr, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, CodedError(400, fmt.Sprintf("invalid attribute filter %q, expected key=value", attr))
+		}
+		if args.Attributes == nil {
+			args.Attributes = make(map[string]string)
+		}
+		args.Attributes[parts[0]] = parts[1]
+	}
+
 	var out structs.NodeListResponse
 	if err := s.agent.RPC("Node.List", &args, &out); err != nil {
 		return nil, err
 	}
 
 	setMeta(resp, &out.QueryMeta)
+	if len(args.Attributes) != 0 {
+		resp.Header().Set("X-Nomad-Node-Filter", "attributes")
+	}
 	if out.Nodes == nil {
 		out.Nodes = make([]*structs.NodeListStub, 0)
 	}
@@ -199,12 +199,12 @@ func (c *RunCommand) Run(args []string) int {
 	}
 
 	// Submit the job
-	var evalID string
+	opts := &api.RegisterOptions{}
 	if enforce {
-		evalID, _, err = client.Jobs().EnforceRegister(apiJob, checkIndex, nil)
-	} else {
-		evalID, _, err = client.Jobs().Register(apiJob, nil)
+		opts.EnforceIndex = true
+		opts.ModifyIndex = checkIndex
 	}
+	resp, _, err := client.Jobs().RegisterOpts(apiJob, opts, nil)
 	if err != nil {
 		if strings.Contains(err.Error(), api.RegisterEnforceIndexErrPrefix) {
 			// Format the error specially if the error is due to index
@@ -221,6 +221,12 @@ func (c *RunCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Print any warnings of the registration
+	if resp.Warnings != "" {
+		c.Ui.Warn(fmt.Sprintf("Job Warnings:\n%s\n", resp.Warnings))
+	}
+	evalID := resp.EvalID
+
 	// Check if we should enter monitor mode
 	if detach || periodic {
 		c.Ui.Output("Job registration successful")
@@ -1004,6 +1004,33 @@ func TestFSM_SnapshotRestore_Allocs_NoSharedResources(t *testing.T) {
 	}
 }
 
+func TestFSM_SnapshotRestore_Allocs_TaskStateFailed(t *testing.T) {
+	// Add an allocation whose task state was recorded before task failures
+	// were tracked
+	fsm := testFSM(t)
+	state := fsm.State()
+	alloc := mock.Alloc()
+	alloc.TaskStates = map[string]*structs.TaskState{
+		"web": &structs.TaskState{
+			State: structs.TaskStateDead,
+			Events: []*structs.TaskEvent{
+				structs.NewTaskEvent(structs.TaskStarted),
+				structs.NewTaskEvent(structs.TaskNotRestarting),
+			},
+		},
+	}
+	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
+	state.UpsertAllocs(1000, []*structs.Allocation{alloc})
+
+	// Verify the failure is backfilled
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out, _ := state2.AllocByID(alloc.ID)
+	if !out.TaskStates["web"].Failed {
+		t.Fatalf("expected failed task: %#v", out.TaskStates["web"])
+	}
+}
+
 func TestFSM_SnapshotRestore_Indexes(t *testing.T) {
 	// Add some state
 	fsm := testFSM(t)
@@ -18,7 +18,7 @@ import (
 
 const (
 	// RegisterEnforceIndexErrPrefix is the prefix to use in errors caused by
-	// enforcing the job modify index during registers.
+	// enforcing the job modify index during registers and deregisters.
 	RegisterEnforceIndexErrPrefix = "Enforcing job modify index"
 )
 
@@ -56,6 +56,9 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 	if err := validateJob(args.Job); err != nil {
 		return err
 	}
+	if err := validateEvalPriority(args.EvalPriority); err != nil {
+		return err
+	}
 
 	if args.EnforceIndex {
 		// Lookup the job
@@ -158,9 +161,13 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 	}
 
 	// Create a new evaluation
+	priority := args.Job.Priority
+	if args.EvalPriority != 0 {
+		priority = args.EvalPriority
+	}
 	eval := &structs.Evaluation{
 		ID:             structs.GenerateUUID(),
-		Priority:       args.Job.Priority,
+		Priority:       priority,
 		Type:           args.Job.Type,
 		TriggeredBy:    structs.EvalTriggerJobRegister,
 		JobID:          args.Job.ID,
@@ -243,6 +250,9 @@ func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegis
 	if args.JobID == "" {
 		return fmt.Errorf("missing job ID for evaluation")
 	}
+	if err := validateEvalPriority(args.EvalOptions.Priority); err != nil {
+		return err
+	}
 
 	// Lookup the job
 	snap, err := j.srv.fsm.State().Snapshot()
@@ -262,11 +272,16 @@ func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegis
 	}
 
 	// Create a new evaluation
+	priority := job.Priority
+	if args.EvalOptions.Priority != 0 {
+		priority = args.EvalOptions.Priority
+	}
 	eval := &structs.Evaluation{
 		ID:             structs.GenerateUUID(),
-		Priority:       job.Priority,
+		Priority:       priority,
 		Type:           job.Type,
 		TriggeredBy:    structs.EvalTriggerJobRegister,
+		TriggerReason:  args.EvalOptions.Reason,
 		JobID:          job.ID,
 		JobModifyIndex: job.ModifyIndex,
 		Status:         structs.EvalStatusPending,
@@ -302,6 +317,9 @@ func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobD
 	if args.JobID == "" {
 		return fmt.Errorf("missing job ID for evaluation")
 	}
+	if err := validateEvalPriority(args.EvalPriority); err != nil {
+		return err
+	}
 
 	// Lookup the job
 	snap, err := j.srv.fsm.State().Snapshot()
@@ -313,6 +331,16 @@ func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobD
 		return err
 	}
 
+	if args.EnforceIndex {
+		jmi := args.JobModifyIndex
+		if job == nil {
+			return fmt.Errorf("%s %d: job does not exist", RegisterEnforceIndexErrPrefix, jmi)
+		} else if jmi != job.JobModifyIndex {
+			return fmt.Errorf("%s %d: job exists with conflicting job modify index: %d",
+				RegisterEnforceIndexErrPrefix, jmi, job.JobModifyIndex)
+		}
+	}
+
 	// Commit this update via Raft
 	_, index, err := j.srv.raftApply(structs.JobDeregisterRequestType, args)
 	if err != nil {
@@ -332,9 +360,13 @@ func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobD
 	// XXX: The job priority / type is strange for this, since it's not a high
 	// priority even if the job was. The scheduler itself also doesn't matter,
 	// since all should be able to handle deregistration in the same way.
+	priority := structs.JobDefaultPriority
+	if args.EvalPriority != 0 {
+		priority = args.EvalPriority
+	}
 	eval := &structs.Evaluation{
 		ID:             structs.GenerateUUID(),
-		Priority:       structs.JobDefaultPriority,
+		Priority:       priority,
 		Type:           structs.JobTypeService,
 		TriggeredBy:    structs.EvalTriggerJobDeregister,
 		JobID:          args.JobID,
@@ -441,6 +473,9 @@ func (j *Job) List(args *structs.JobListRequest,
 					break
 				}
 				job := raw.(*structs.Job)
+				if args.ParentID != "" && job.ParentID != args.ParentID {
+					continue
+				}
 				summary, err := snap.JobSummaryByID(job.ID)
 				if err != nil {
 					return fmt.Errorf("unable to look up summary for job: %v", job.ID)
@@ -682,3 +717,15 @@ func validateJob(job *structs.Job) error {
 
 	return validationErrors.ErrorOrNil()
 }
+
+// validateEvalPriority validates the priority requested for the evaluation
+// created by a job write. A zero priority is unset.
+func validateEvalPriority(priority int) error {
+	if priority == 0 {
+		return nil
+	}
+	if priority < structs.JobMinPriority || priority > structs.JobMaxPriority {
+		return fmt.Errorf("eval priority must be between [%d, %d]", structs.JobMinPriority, structs.JobMaxPriority)
+	}
+	return nil
+}
@@ -659,6 +659,53 @@ func TestJobEndpoint_Evaluate(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Evaluate_EvalOptions(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	job := mock.Job()
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Force a re-evaluation with an out of range priority
+	reEval := &structs.JobEvaluateRequest{
+		JobID:        job.ID,
+		EvalOptions:  structs.EvalOptions{Reason: "config rotated", Priority: structs.JobMaxPriority + 1},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	err := msgpackrpc.CallWithCodec(codec, "Job.Evaluate", reEval, &resp)
+	if err == nil || !strings.Contains(err.Error(), "eval priority") {
+		t.Fatalf("expected eval priority error: %v", err)
+	}
+
+	// Force a re-evaluation with a valid priority
+	reEval.EvalOptions.Priority = 80
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Evaluate", reEval, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The options are recorded on the evaluation
+	state := s1.fsm.State()
+	eval, err := state.EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.Priority != 80 || eval.TriggerReason != "config rotated" {
+		t.Fatalf("bad eval: %#v", eval)
+	}
+}
+
 func TestJobEndpoint_Evaluate_Periodic(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -771,6 +818,124 @@ func TestJobEndpoint_Deregister(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Deregister_EnforceIndex(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	job := mock.Job()
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Deregister with the wrong index
+	dereg := &structs.JobDeregisterRequest{
+		JobID:          job.ID,
+		EnforceIndex:   true,
+		JobModifyIndex: resp.JobModifyIndex + 1,
+		WriteRequest:   structs.WriteRequest{Region: "global"},
+	}
+	var resp2 structs.JobDeregisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Deregister", dereg, &resp2)
+	if err == nil || !strings.Contains(err.Error(), RegisterEnforceIndexErrPrefix) {
+		t.Fatalf("expected enforcement error: %v", err)
+	}
+
+	// Check the job still exists
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected job")
+	}
+
+	// Deregister with the correct index
+	dereg.JobModifyIndex = out.JobModifyIndex
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Deregister", dereg, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("unexpected job")
+	}
+
+	// Deregistering a job that doesn't exist fails
+	err = msgpackrpc.CallWithCodec(codec, "Job.Deregister", dereg, &resp2)
+	if err == nil || !strings.Contains(err.Error(), "job does not exist") {
+		t.Fatalf("expected enforcement error: %v", err)
+	}
+}
+
+func TestJobEndpoint_Deregister_EvalPriority(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register the job with an out of range eval priority
+	job := mock.Job()
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		EvalPriority: structs.JobMaxPriority + 1,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.JobRegisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp)
+	if err == nil || !strings.Contains(err.Error(), "eval priority") {
+		t.Fatalf("expected eval priority error: %v", err)
+	}
+
+	// Register the job with a valid eval priority
+	reg.EvalPriority = 80
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	state := s1.fsm.State()
+	eval, err := state.EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.Priority != 80 {
+		t.Fatalf("bad eval: %#v", eval)
+	}
+
+	// Deregister it with a lower eval priority
+	dereg := &structs.JobDeregisterRequest{
+		JobID:        job.ID,
+		EvalPriority: 10,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp2 structs.JobDeregisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Deregister", dereg, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	eval, err = state.EvalByID(resp2.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.Priority != 10 {
+		t.Fatalf("bad eval: %#v", eval)
+	}
+}
+
 func TestJobEndpoint_Deregister_NonExistent(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -1211,6 +1376,42 @@ func TestJobEndpoint_ListJobs(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_ListJobs_ParentID(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a parent and a child job
+	parent := mock.PeriodicJob()
+	child := mock.Job()
+	child.ID = parent.ID + "/periodic-1"
+	child.ParentID = parent.ID
+	state := s1.fsm.State()
+	if err := state.UpsertJob(1000, parent); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertJob(1001, child); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the children of the parent
+	get := &structs.JobListRequest{
+		ParentID:     parent.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.JobListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Jobs) != 1 {
+		t.Fatalf("bad: %#v", resp.Jobs)
+	}
+	if resp.Jobs[0].ID != child.ID || resp.Jobs[0].ParentID != parent.ID {
+		t.Fatalf("bad: %#v", resp.Jobs[0])
+	}
+}
+
 func TestJobEndpoint_ListJobs_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -672,6 +672,13 @@ func (n *Node) UpdateAlloc(args *structs.AllocUpdateRequest, reply *structs.Gene
 		return fmt.Errorf("must update at least one allocation")
 	}
 
+	// Backfill the task states of clients that don't track failures
+	for _, alloc := range args.Alloc {
+		for _, state := range alloc.TaskStates {
+			state.Canonicalize()
+		}
+	}
+
 	// Add this to the batch
 	n.updatesLock.Lock()
 	n.updates = append(n.updates, args.Alloc...)
@@ -789,6 +796,9 @@ func (n *Node) List(args *structs.NodeListRequest,
 					break
 				}
 				node := raw.(*structs.Node)
+				if !nodeHasAttributes(node, args.Attributes) {
+					continue
+				}
 				nodes = append(nodes, node.Stub())
 			}
 			reply.Nodes = nodes
@@ -807,6 +817,17 @@ func (n *Node) List(args *structs.NodeListRequest,
 	return n.srv.blockingRPC(&opts)
 }
 
+// nodeHasAttributes returns whether the node has all of the given attribute
+// values.
+func nodeHasAttributes(node *structs.Node, attrs map[string]string) bool {
+	for k, v := range attrs {
+		if actual, ok := node.Attributes[k]; !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
 // createNodeEvals is used to create evaluations for each alloc on a node.
 // Each Eval is scoped to a job, so we need to potentially trigger many evals.
 func (n *Node) createNodeEvals(nodeID string, nodeIndex uint64) ([]string, uint64, error) {
@@ -1651,6 +1651,40 @@ func TestClientEndpoint_ListNodes(t *testing.T) {
 	}
 }
 
+func TestClientEndpoint_ListNodes_Attributes(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register two nodes, only one of which has a GPU
+	gpu := mock.Node()
+	gpu.Attributes["gpu"] = "true"
+	other := mock.Node()
+	for _, node := range []*structs.Node{gpu, other} {
+		reg := &structs.NodeRegisterRequest{
+			Node:         node,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.GenericResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Node.Register", reg, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	get := &structs.NodeListRequest{
+		Attributes:   map[string]string{"gpu": "true"},
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.NodeListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.List", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Nodes) != 1 || resp.Nodes[0].ID != gpu.ID {
+		t.Fatalf("bad: %#v", resp.Nodes)
+	}
+}
+
 func TestClientEndpoint_ListNodes_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
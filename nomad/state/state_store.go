@@ -1756,6 +1756,11 @@ func (r *StateRestore) AllocRestore(alloc *structs.Allocation) error {
 		r.addEphemeralDiskToTaskGroups(alloc.Job)
 	}
 
+	// Backfill the failure of task states recorded before it was tracked
+	for _, state := range alloc.TaskStates {
+		state.Canonicalize()
+	}
+
 	if err := r.txn.Insert("allocs", alloc); err != nil {
 		return fmt.Errorf("alloc insert failed: %v", err)
 	}
@@ -229,6 +229,10 @@ type JobRegisterRequest struct {
 	EnforceIndex   bool
 	JobModifyIndex uint64
 
+	// EvalPriority, if set, is the priority of the created evaluation,
+	// overriding that of the job.
+	EvalPriority int
+
 	WriteRequest
 }
 
@@ -236,15 +240,38 @@ type JobRegisterRequest struct {
 // to deregister a job as being a schedulable entity.
 type JobDeregisterRequest struct {
 	JobID string
+
+	// If EnforceIndex is set then the job will only be deregistered if the
+	// passed JobModifyIndex matches the current Jobs index.
+	EnforceIndex   bool
+	JobModifyIndex uint64
+
+	// EvalPriority, if set, is the priority of the created evaluation,
+	// overriding the default priority of deregistrations.
+	EvalPriority int
+
 	WriteRequest
 }
 
 // JobEvaluateRequest is used when we just need to re-evaluate a target job
 type JobEvaluateRequest struct {
-	JobID string
+	JobID       string
+	EvalOptions EvalOptions
 	WriteRequest
 }
 
+// EvalOptions is used to annotate the evaluation created by a forced
+// re-evaluation of a job.
+type EvalOptions struct {
+	// Reason is a human readable explanation of why the evaluation was
+	// forced. It is recorded as the TriggerReason of the evaluation.
+	Reason string
+
+	// Priority, if set, is the priority of the created evaluation,
+	// overriding that of the job.
+	Priority int
+}
+
 // JobSpecificRequest is used when we just need to specify a target job
 type JobSpecificRequest struct {
 	JobID string
@@ -253,6 +280,9 @@ type JobSpecificRequest struct {
 
 // JobListRequest is used to parameterize a list request
 type JobListRequest struct {
+	// ParentID restricts the listing to the children of the given job
+	ParentID string
+
 	QueryOptions
 }
 
@@ -272,6 +302,10 @@ type JobSummaryRequest struct {
 
 // NodeListRequest is used to parameterize a list request
 type NodeListRequest struct {
+	// Attributes, if set, restricts the listed nodes to those whose
+	// attributes have all of the given values.
+	Attributes map[string]string
+
 	QueryOptions
 }
 
@@ -488,6 +522,12 @@ type SingleJobResponse struct {
 	QueryMeta
 }
 
+// JobWithSummary is used to return a job together with its summary
+type JobWithSummary struct {
+	Job     *Job
+	Summary *JobSummary
+}
+
 // JobSummaryResponse is used to return a single job summary
 type JobSummaryResponse struct {
 	JobSummary *JobSummary
@@ -1263,6 +1303,7 @@ func (j *Job) Stub(summary *JobSummary) *JobListStub {
 		Name:              j.Name,
 		Type:              j.Type,
 		Priority:          j.Priority,
+		Datacenters:       j.Datacenters,
 		Status:            j.Status,
 		StatusDescription: j.StatusDescription,
 		CreateIndex:       j.CreateIndex,
@@ -1308,6 +1349,7 @@ type JobListStub struct {
 	Name              string
 	Type              string
 	Priority          int
+	Datacenters       []string
 	Status            string
 	StatusDescription string
 	JobSummary        *JobSummary
@@ -2318,6 +2360,17 @@ type TaskState struct {
 	// The current state of the task.
 	State string
 
+	// Failed marks a task that is dead because of a failure.
+	Failed bool
+
+	// Restarts is the number of times the task has been restarted.
+	Restarts uint64
+
+	// StartedAt is the time the task was last started and FinishedAt the
+	// time it died. They are zero until the task starts or dies.
+	StartedAt  time.Time
+	FinishedAt time.Time
+
 	// Series of task events that transition the state of the task.
 	Events []*TaskEvent
 }
@@ -2328,6 +2381,10 @@ func (ts *TaskState) Copy() *TaskState {
 	}
 	copy := new(TaskState)
 	copy.State = ts.State
+	copy.Failed = ts.Failed
+	copy.Restarts = ts.Restarts
+	copy.StartedAt = ts.StartedAt
+	copy.FinishedAt = ts.FinishedAt
 
 	if ts.Events != nil {
 		copy.Events = make([]*TaskEvent, len(ts.Events))
@@ -2338,20 +2395,47 @@ func (ts *TaskState) Copy() *TaskState {
 	return copy
 }
 
-// Failed returns true if the task has has failed.
-func (ts *TaskState) Failed() bool {
-	l := len(ts.Events)
-	if ts.State != TaskStateDead || l == 0 {
-		return false
+// Transition moves the task to the given state, recording the event that
+// caused the transition. The start and finish times, restart count and
+// failure of the task are updated along with it. A task fails when it dies
+// following an event that fails it.
+func (ts *TaskState) Transition(state string, event *TaskEvent) {
+	now := time.Now().UTC()
+	switch state {
+	case TaskStateRunning:
+		if ts.State != TaskStateRunning {
+			ts.StartedAt = now
+			ts.FinishedAt = time.Time{}
+		}
+	case TaskStateDead:
+		if ts.State != TaskStateDead {
+			ts.FinishedAt = now
+		}
 	}
+	if state != TaskStateDead {
+		ts.Failed = false
+	}
+	ts.State = state
 
-	switch ts.Events[l-1].Type {
-	case TaskDiskExceeded, TaskNotRestarting, TaskArtifactDownloadFailed,
-		TaskFailedValidation, TaskVaultRenewalFailed:
-		return true
-	default:
-		return false
+	if event == nil {
+		return
 	}
+	if event.Type == TaskRestarting {
+		ts.Restarts++
+		ts.FinishedAt = time.Time{}
+	}
+	if state == TaskStateDead {
+		ts.Failed = event.FailsTask()
+	}
+}
+
+// Canonicalize backfills the failure of task states recorded before it was
+// tracked, deriving it from the last event of a dead task.
+func (ts *TaskState) Canonicalize() {
+	if ts.State != TaskStateDead || ts.Failed || len(ts.Events) == 0 {
+		return
+	}
+	ts.Failed = ts.Events[len(ts.Events)-1].FailsTask()
 }
 
 // Successful returns whether a task finished successfully.
@@ -2482,6 +2566,21 @@ type TaskEvent struct {
 
 	// TaskSignal is the signal that was sent to the task
 	TaskSignal string
+
+	// Details holds the fields of the event specific to its type, such as
+	// the exit code of a terminated task, keyed by their snake cased names.
+	Details map[string]string
+}
+
+// FailsTask returns whether the event fails the task it is emitted for.
+func (te *TaskEvent) FailsTask() bool {
+	switch te.Type {
+	case TaskDiskExceeded, TaskNotRestarting, TaskArtifactDownloadFailed,
+		TaskFailedValidation, TaskVaultRenewalFailed:
+		return true
+	default:
+		return false
+	}
 }
 
 func (te *TaskEvent) GoString() string {
@@ -2494,6 +2593,7 @@ func (te *TaskEvent) Copy() *TaskEvent {
 	}
 	copy := new(TaskEvent)
 	*copy = *te
+	copy.Details = CopyMapStringString(te.Details)
 	return copy
 }
 
@@ -2504,26 +2604,38 @@ func NewTaskEvent(event string) *TaskEvent {
 	}
 }
 
+// setDetail records a field of the event in its details.
+func (e *TaskEvent) setDetail(key, value string) {
+	if e.Details == nil {
+		e.Details = make(map[string]string)
+	}
+	e.Details[key] = value
+}
+
 func (e *TaskEvent) SetDriverError(err error) *TaskEvent {
 	if err != nil {
 		e.DriverError = err.Error()
+		e.setDetail("driver_error", e.DriverError)
 	}
 	return e
 }
 
 func (e *TaskEvent) SetExitCode(c int) *TaskEvent {
 	e.ExitCode = c
+	e.setDetail("exit_code", strconv.Itoa(c))
 	return e
 }
 
 func (e *TaskEvent) SetSignal(s int) *TaskEvent {
 	e.Signal = s
+	e.setDetail("signal", strconv.Itoa(s))
 	return e
 }
 
 func (e *TaskEvent) SetExitMessage(err error) *TaskEvent {
 	if err != nil {
 		e.Message = err.Error()
+		e.setDetail("exit_message", e.Message)
 	}
 	return e
 }
@@ -2531,38 +2643,45 @@ func (e *TaskEvent) SetExitMessage(err error) *TaskEvent {
 func (e *TaskEvent) SetKillError(err error) *TaskEvent {
 	if err != nil {
 		e.KillError = err.Error()
+		e.setDetail("kill_error", e.KillError)
 	}
 	return e
 }
 
 func (e *TaskEvent) SetKillReason(r string) *TaskEvent {
 	e.KillReason = r
+	e.setDetail("kill_reason", r)
 	return e
 }
 
 func (e *TaskEvent) SetRestartDelay(delay time.Duration) *TaskEvent {
 	e.StartDelay = int64(delay)
+	e.setDetail("start_delay", delay.String())
 	return e
 }
 
 func (e *TaskEvent) SetRestartReason(reason string) *TaskEvent {
 	e.RestartReason = reason
+	e.setDetail("restart_reason", reason)
 	return e
 }
 
 func (e *TaskEvent) SetTaskSignalReason(r string) *TaskEvent {
 	e.TaskSignalReason = r
+	e.setDetail("task_signal_reason", r)
 	return e
 }
 
 func (e *TaskEvent) SetTaskSignal(s os.Signal) *TaskEvent {
 	e.TaskSignal = s.String()
+	e.setDetail("task_signal", e.TaskSignal)
 	return e
 }
 
 func (e *TaskEvent) SetDownloadError(err error) *TaskEvent {
 	if err != nil {
 		e.DownloadError = err.Error()
+		e.setDetail("download_error", e.DownloadError)
 	}
 	return e
 }
@@ -2570,33 +2689,39 @@ func (e *TaskEvent) SetDownloadError(err error) *TaskEvent {
 func (e *TaskEvent) SetValidationError(err error) *TaskEvent {
 	if err != nil {
 		e.ValidationError = err.Error()
+		e.setDetail("validation_error", e.ValidationError)
 	}
 	return e
 }
 
 func (e *TaskEvent) SetKillTimeout(timeout time.Duration) *TaskEvent {
 	e.KillTimeout = timeout
+	e.setDetail("kill_timeout", timeout.String())
 	return e
 }
 
 func (e *TaskEvent) SetDiskLimit(limit int64) *TaskEvent {
 	e.DiskLimit = limit
+	e.setDetail("disk_limit", strconv.FormatInt(limit, 10))
 	return e
 }
 
 func (e *TaskEvent) SetDiskSize(size int64) *TaskEvent {
 	e.DiskSize = size
+	e.setDetail("disk_size", strconv.FormatInt(size, 10))
 	return e
 }
 
 func (e *TaskEvent) SetFailedSibling(sibling string) *TaskEvent {
 	e.FailedSibling = sibling
+	e.setDetail("failed_sibling", sibling)
 	return e
 }
 
 func (e *TaskEvent) SetVaultRenewalError(err error) *TaskEvent {
 	if err != nil {
 		e.VaultError = err.Error()
+		e.setDetail("vault_renewal_error", e.VaultError)
 	}
 	return e
 }
@@ -3233,6 +3358,10 @@ type Evaluation struct {
 	// was created. (Job change, node failure, alloc failure, etc).
 	TriggeredBy string
 
+	// TriggerReason is a human readable explanation of why the evaluation
+	// was created, such as the reason given when forcing an evaluation.
+	TriggerReason string
+
 	// JobID is the job this evaluation is scoped to. Evaluations cannot
 	// be run in parallel for a given JobID, so we serialize on this.
 	JobID string
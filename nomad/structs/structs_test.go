@@ -1307,3 +1307,92 @@ func TestAllocation_Terminated(t *testing.T) {
 		}
 	}
 }
+
+func TestTaskState_Transition(t *testing.T) {
+	ts := &TaskState{}
+	ts.Transition(TaskStatePending, NewTaskEvent(TaskReceived))
+	if !ts.StartedAt.IsZero() || !ts.FinishedAt.IsZero() {
+		t.Fatalf("unexpected times: %#v", ts)
+	}
+
+	ts.Transition(TaskStateRunning, NewTaskEvent(TaskStarted))
+	started := ts.StartedAt
+	if started.IsZero() {
+		t.Fatalf("expected start time")
+	}
+
+	// Restarts are counted and restarting tasks get a new start time
+	ts.Transition(TaskStatePending, NewTaskEvent(TaskRestarting).SetRestartReason("exited"))
+	time.Sleep(time.Millisecond)
+	ts.Transition(TaskStateRunning, NewTaskEvent(TaskStarted))
+	if ts.Restarts != 1 || !ts.StartedAt.After(started) {
+		t.Fatalf("bad restart: %#v", ts)
+	}
+
+	// Dying after a failing event fails the task
+	ts.Transition(TaskStateDead, NewTaskEvent(TaskNotRestarting))
+	if !ts.Failed || ts.FinishedAt.IsZero() || ts.State != TaskStateDead {
+		t.Fatalf("expected failed task: %#v", ts)
+	}
+
+	// Restarting a dead task clears its finish time and failure
+	ts.Transition(TaskStatePending, NewTaskEvent(TaskRestarting).SetRestartReason("restarted"))
+	if ts.Failed || !ts.FinishedAt.IsZero() || ts.Restarts != 2 {
+		t.Fatalf("bad restart: %#v", ts)
+	}
+	ts.Transition(TaskStateRunning, NewTaskEvent(TaskStarted))
+	if !ts.FinishedAt.IsZero() {
+		t.Fatalf("unexpected finish time: %#v", ts)
+	}
+
+	ok := &TaskState{}
+	ok.Transition(TaskStateRunning, NewTaskEvent(TaskStarted))
+	ok.Transition(TaskStateDead, NewTaskEvent(TaskTerminated).SetExitCode(0))
+	if ok.Failed {
+		t.Fatalf("unexpected failed task: %#v", ok)
+	}
+}
+
+func TestTaskState_Canonicalize(t *testing.T) {
+	// Task states recorded before failures were tracked are backfilled
+	ts := &TaskState{
+		State:  TaskStateDead,
+		Events: []*TaskEvent{NewTaskEvent(TaskStarted), NewTaskEvent(TaskNotRestarting)},
+	}
+	ts.Canonicalize()
+	if !ts.Failed {
+		t.Fatalf("expected failed task: %#v", ts)
+	}
+
+	ok := &TaskState{
+		State:  TaskStateDead,
+		Events: []*TaskEvent{NewTaskEvent(TaskStarted), NewTaskEvent(TaskTerminated)},
+	}
+	ok.Canonicalize()
+	if ok.Failed {
+		t.Fatalf("unexpected failed task: %#v", ok)
+	}
+
+	running := &TaskState{
+		State:  TaskStateRunning,
+		Events: []*TaskEvent{NewTaskEvent(TaskNotRestarting)},
+	}
+	running.Canonicalize()
+	if running.Failed {
+		t.Fatalf("unexpected failed task: %#v", running)
+	}
+}
+
+func TestTaskEvent_Details(t *testing.T) {
+	e := NewTaskEvent(TaskTerminated).SetExitCode(2).SetSignal(9).SetKillTimeout(5 * time.Second)
+	expected := map[string]string{"exit_code": "2", "signal": "9", "kill_timeout": "5s"}
+	if !reflect.DeepEqual(e.Details, expected) {
+		t.Fatalf("expected details %v, got %v", expected, e.Details)
+	}
+
+	c := e.Copy()
+	c.Details["exit_code"] = "3"
+	if e.Details["exit_code"] != "2" {
+		t.Fatalf("details not copied")
+	}
+}